@@ -3,18 +3,29 @@ package main
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/schools24/backend/internal/config"
 	"github.com/schools24/backend/internal/modules/academic"
 	"github.com/schools24/backend/internal/modules/admin"
+	"github.com/schools24/backend/internal/modules/apikey"
 	"github.com/schools24/backend/internal/modules/auth"
+	"github.com/schools24/backend/internal/modules/parent"
+	"github.com/schools24/backend/internal/modules/role"
+	"github.com/schools24/backend/internal/modules/rollover"
+	"github.com/schools24/backend/internal/modules/school"
+	"github.com/schools24/backend/internal/modules/settings"
 	"github.com/schools24/backend/internal/modules/student"
 	"github.com/schools24/backend/internal/modules/teacher"
 	"github.com/schools24/backend/internal/shared/cache"
 	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/mailer"
 	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/permission"
+	"github.com/schools24/backend/internal/shared/sms"
 )
 
 func main() {
@@ -23,6 +34,16 @@ func main() {
 
 	log.Printf("Starting %s in %s mode", cfg.App.Name, cfg.App.Env)
 
+	// Log and file senders expose reset links and login codes to anyone who can read them
+	if cfg.App.Env != "development" {
+		if !mailer.Delivers(cfg.Email) {
+			log.Fatalf("EMAIL_PROVIDER=%q is only allowed when APP_ENV=development; use sendgrid", cfg.Email.Provider)
+		}
+		if !sms.Delivers(cfg.SMS) {
+			log.Fatalf("SMS_PROVIDER=%q is only allowed when APP_ENV=development; use twilio", cfg.SMS.Provider)
+		}
+	}
+
 	// 2. Set Gin Mode
 	gin.SetMode(cfg.App.GinMode)
 
@@ -35,6 +56,34 @@ func main() {
 	defer appCache.Close()
 	log.Printf("In-memory cache initialized (max %d MB)", cacheConfig.MaxSizeMB)
 
+	// Permission cache and token denylist share Redis when it is configured.
+	// Without it, permissions are cached in memory and the denylist is kept in
+	// Postgres (set up after migrations) so revocations survive restarts and
+	// reach every instance.
+	accessTokenTTL := time.Duration(cfg.JWT.ExpirationHours) * time.Hour
+	var redisClient *cache.RedisClient
+	var permissionStore cache.Store = appCache
+	if cfg.Redis.Enabled {
+		redisClient, err = cache.NewRedisClient(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+		if err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		defer redisClient.Close()
+		permissionStore = redisClient
+		log.Printf("Token denylist and permission cache using Redis at %s", cfg.Redis.Addr)
+	}
+
+	// Token signing keys (HS256 secret or RS256/EdDSA key files)
+	jwtKeys, err := middleware.LoadKeySet(middleware.KeySetConfig{
+		KeysDir:      cfg.JWT.KeysDir,
+		SigningKeyID: cfg.JWT.SigningKeyID,
+		HMACSecret:   cfg.JWT.Secret,
+		AcceptHMAC:   cfg.JWT.AcceptHS256,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
 	// 4. Initialize PostgreSQL (Neon)
 	db, err := database.NewPostgresDB(cfg.Database.URL)
 	if err != nil {
@@ -64,6 +113,50 @@ func main() {
 	if err := db.RunAdminMigrations(ctx); err != nil {
 		log.Fatalf("Failed to run admin migrations: %v", err)
 	}
+	if err := db.RunTenancyMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run tenancy migrations: %v", err)
+	}
+	if err := db.RunParentMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run parent migrations: %v", err)
+	}
+	if err := db.RunOnboardingMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run onboarding migrations: %v", err)
+	}
+	if err := db.RunSSOMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run SSO migrations: %v", err)
+	}
+	if err := db.RunAPIKeyMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run API key migrations: %v", err)
+	}
+	if err := db.RunRolloverMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run rollover migrations: %v", err)
+	}
+	if err := db.RunEnrollmentMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run enrollment migrations: %v", err)
+	}
+	if err := db.RunFeeMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run fee migrations: %v", err)
+	}
+	if err := db.RunSettingsMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run settings migrations: %v", err)
+	}
+	if err := db.RunRBACMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run RBAC migrations: %v", err)
+	}
+	if err := db.RunAuditMigrations(ctx); err != nil {
+		log.Fatalf("Failed to run audit migrations: %v", err)
+	}
+
+	var denylistStore cache.Store
+	if redisClient != nil {
+		denylistStore = redisClient
+	} else {
+		pgDenylist := cache.NewPostgresStore(db, 5*time.Minute)
+		defer pgDenylist.Close()
+		denylistStore = pgDenylist
+		log.Println("Token denylist using PostgreSQL")
+	}
+	tokenDenylist := middleware.NewTokenDenylist(denylistStore, accessTokenTTL)
 
 	// 6. Initialize MongoDB (optional, for quizzes/questions)
 	mongoDB, err := database.NewMongoDB(cfg.MongoDB.URI, cfg.MongoDB.Database)
@@ -74,31 +167,78 @@ func main() {
 	}
 
 	// 7. Initialize Modules
+	// Shared Services
+	mailSender := mailer.New(cfg.Email)
+	smsSender := sms.New(cfg.SMS)
+	passwords := password.New(db, cfg.Password)
+
+	// Admin repository is shared with auth for security audit events
+	adminRepo := admin.NewRepository(db)
+
 	// Auth Module
 	authRepo := auth.NewRepository(db)
-	authService := auth.NewService(authRepo, cfg)
+	authService := auth.NewService(authRepo, mailSender, smsSender, passwords, tokenDenylist, adminRepo, jwtKeys, cfg)
 	authHandler := auth.NewHandler(authService)
 
+	// Settings Module (per-school values cached in memory)
+	settingsRepo := settings.NewRepository(db)
+	settingsService := settings.NewService(settingsRepo, appCache, adminRepo, cfg)
+	settingsHandler := settings.NewHandler(settingsService)
+
 	// Student Module
 	studentRepo := student.NewRepository(db)
-	studentService := student.NewService(studentRepo, cfg)
+	studentService := student.NewService(studentRepo, settingsService, cfg)
 	studentHandler := student.NewHandler(studentService)
 
 	// Academic Module
 	academicRepo := academic.NewRepository(db)
-	academicService := academic.NewService(academicRepo, studentRepo, cfg)
+	academicService := academic.NewService(academicRepo, studentRepo, settingsService, cfg)
 	academicHandler := academic.NewHandler(academicService)
 
 	// Teacher Module
 	teacherRepo := teacher.NewRepository(db)
-	teacherService := teacher.NewService(teacherRepo, cfg)
+	teacherService := teacher.NewService(teacherRepo, settingsService, cfg)
 	teacherHandler := teacher.NewHandler(teacherService)
 
+	// Parent Module
+	parentRepo := parent.NewRepository(db)
+	parentService := parent.NewService(parentRepo, studentService, academicService, cfg)
+	parentHandler := parent.NewHandler(parentService)
+
+	// Rollover Module (academic-year rollovers)
+	rolloverRepo := rollover.NewRepository(db)
+	rolloverService := rollover.NewService(rolloverRepo, settingsService, adminRepo, cfg)
+	rolloverHandler := rollover.NewHandler(rolloverService)
+
+	// Role Module (permissions are re-read at most a minute after a role edit)
+	roleRepo := role.NewRepository(db)
+	authz := middleware.NewAuthorizer(roleRepo, permissionStore, time.Minute)
+	roleService := role.NewService(roleRepo, authz, tokenDenylist, cfg)
+	roleHandler := role.NewHandler(roleService)
+
 	// Admin Module
-	adminRepo := admin.NewRepository(db)
-	adminService := admin.NewService(adminRepo, cfg)
+	adminService := admin.NewService(adminRepo, passwords, tokenDenylist, authz, authService, authService, settingsService, cfg)
 	adminHandler := admin.NewHandler(adminService)
 
+	// API Key Module (service accounts for devices and integrations)
+	apiKeyRepo := apikey.NewRepository(db)
+	apiKeyService := apikey.NewService(apiKeyRepo, passwords, authz, adminRepo, cfg)
+	apiKeyHandler := apikey.NewHandler(apiKeyService)
+
+	// School Module (super admin provisioning)
+	schoolRepo := school.NewRepository(db)
+	schoolService := school.NewService(schoolRepo, passwords, cfg)
+	schoolHandler := school.NewHandler(schoolService)
+
+	if cfg.Auth.SuperAdminEmail != "" {
+		promoted, err := schoolService.PromoteSuperAdmin(ctx, cfg.Auth.SuperAdminEmail)
+		if err != nil {
+			log.Printf("Warning: failed to promote super admin: %v", err)
+		} else if promoted {
+			log.Printf("Promoted %s to super admin", cfg.Auth.SuperAdminEmail)
+		}
+	}
+
 	// 8. Initialize Gin Router
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -134,6 +274,9 @@ func main() {
 		c.JSON(200, gin.H{"ready": true})
 	})
 
+	// Public keys for verifying access tokens (gateway, internal services)
+	r.GET("/.well-known/jwks.json", middleware.JWKSHandler(jwtKeys))
+
 	// API v1 routes
 	v1 := r.Group("/api/v1")
 
@@ -142,19 +285,46 @@ func main() {
 
 	// Auth routes (public)
 	authPublic := v1.Group("/auth")
+	authPublic.Use(middleware.AuditActor())
 	{
 		authPublic.POST("/login", authHandler.Login)
+		authPublic.POST("/login/mfa", authHandler.LoginMFA)
+		authPublic.POST("/otp/request", authHandler.RequestLoginOTP)
+		authPublic.POST("/otp/verify", authHandler.LoginOTP)
 		authPublic.POST("/register", authHandler.Register)
+		authPublic.POST("/invitations/accept", authHandler.AcceptInvitation)
+		authPublic.GET("/sso/providers", authHandler.GetLoginSSOProviders)
+		authPublic.POST("/sso/start", authHandler.StartSSO)
+		authPublic.POST("/sso/callback", authHandler.CompleteSSO)
+		authPublic.POST("/refresh", authHandler.Refresh)
+		authPublic.POST("/forgot-password", authHandler.ForgotPassword)
+		authPublic.POST("/reset-password", authHandler.ResetPassword)
+		authPublic.POST("/verify-email", authHandler.VerifyEmail)
+		authPublic.POST("/verify-email/resend", authHandler.ResendVerification)
 	}
 
-	// Protected routes (require JWT)
+	// Protected routes (require JWT or, for service accounts, an API key)
 	protected := v1.Group("")
-	protected.Use(middleware.JWTAuth(middleware.DefaultJWTConfig(cfg.JWT.Secret)))
+	jwtConfig := middleware.DefaultJWTConfig(jwtKeys)
+	jwtConfig.Denylist = tokenDenylist
+	jwtConfig.APIKeys = apiKeyService
+	protected.Use(middleware.JWTAuth(jwtConfig))
+	protected.Use(middleware.TenantScope())
+	protected.Use(middleware.ImpersonationGuard(adminRepo, "/api/v1/auth/logout"))
+	protected.Use(middleware.AuditActor())
+	requireMFA := middleware.RequireMFA(strings.Split(cfg.Auth.MFARequiredRoles, ",")...)
 	{
 		// Auth protected routes
 		protected.GET("/auth/me", authHandler.GetMe)
 		protected.PUT("/auth/me", authHandler.UpdateProfile)
 		protected.POST("/auth/logout", authHandler.Logout)
+		protected.GET("/auth/sessions", authHandler.GetSessions)
+		protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+		protected.GET("/auth/mfa", authHandler.GetMFAStatus)
+		protected.POST("/auth/mfa/enroll", authHandler.EnrollMFA)
+		protected.POST("/auth/mfa/confirm", authHandler.ConfirmMFA)
+		protected.POST("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		protected.POST("/auth/mfa/disable", authHandler.DisableMFA)
 
 		// Student routes
 		studentRoutes := protected.Group("/student")
@@ -173,48 +343,139 @@ func main() {
 			academicRoutes.POST("/homework/:id/submit", academicHandler.SubmitHomework)
 			academicRoutes.GET("/grades", academicHandler.GetGrades)
 			academicRoutes.GET("/subjects", academicHandler.GetSubjects)
-			academicRoutes.POST("/subjects", middleware.RequireRole("admin"), academicHandler.CreateSubject)
+			academicRoutes.POST("/subjects", authz.RequirePermission(permission.SubjectsManage), academicHandler.CreateSubject)
 		}
 
 		// Teacher routes
 		teacherRoutes := protected.Group("/teacher")
-		teacherRoutes.Use(middleware.RequireRole("teacher", "admin"))
+		teacherRoutes.Use(authz.RequirePermission(permission.TeacherPortal), requireMFA)
 		{
 			teacherRoutes.GET("/dashboard", teacherHandler.GetDashboard)
 			teacherRoutes.GET("/profile", teacherHandler.GetProfile)
 			teacherRoutes.GET("/classes", teacherHandler.GetClasses)
 			teacherRoutes.GET("/classes/:classId/students", teacherHandler.GetClassStudents)
-			teacherRoutes.POST("/attendance", teacherHandler.MarkAttendance)
-			teacherRoutes.POST("/homework", teacherHandler.CreateHomework)
-			teacherRoutes.POST("/grades", teacherHandler.EnterGrade)
-			teacherRoutes.POST("/announcements", teacherHandler.CreateAnnouncement)
+			teacherRoutes.POST("/attendance", authz.RequirePermission(permission.AttendanceMark), teacherHandler.MarkAttendance)
+			teacherRoutes.POST("/homework", authz.RequirePermission(permission.HomeworkCreate), teacherHandler.CreateHomework)
+			teacherRoutes.POST("/grades", authz.RequirePermission(permission.GradesEnter), teacherHandler.EnterGrade)
+			teacherRoutes.POST("/announcements", authz.RequirePermission(permission.AnnouncementsCreate), teacherHandler.CreateAnnouncement)
 		}
 
+		// Parent routes (only children linked to the parent are visible)
+		parentRoutes := protected.Group("/parent")
+		parentRoutes.Use(authz.RequirePermission(permission.ParentPortal))
+		{
+			parentRoutes.GET("/children", parentHandler.GetChildren)
+			parentRoutes.GET("/children/:id/attendance", parentHandler.GetChildAttendance)
+			parentRoutes.GET("/children/:id/homework", parentHandler.GetChildHomework)
+			parentRoutes.GET("/children/:id/grades", parentHandler.GetChildGrades)
+			parentRoutes.GET("/children/:id/timetable", parentHandler.GetChildTimetable)
+			parentRoutes.GET("/children/:id/announcements", parentHandler.GetChildAnnouncements)
+		}
+
+		// School settings readable by every user
+		protected.GET("/settings", settingsHandler.GetPublicSettings)
+
 		// Announcements (all authenticated users can view)
 		protected.GET("/announcements", teacherHandler.GetAnnouncements)
 
 		// Admin routes
 		adminRoutes := protected.Group("/admin")
-		adminRoutes.Use(middleware.RequireRole("admin"))
+		adminRoutes.Use(requireMFA)
 		{
-			adminRoutes.GET("/dashboard", adminHandler.GetDashboard)
-			adminRoutes.GET("/users", adminHandler.GetUsers)
-			adminRoutes.GET("/users/:id", adminHandler.GetUser)
-			adminRoutes.POST("/users", adminHandler.CreateUser)
-			adminRoutes.PUT("/users/:id", adminHandler.UpdateUser)
-			adminRoutes.DELETE("/users/:id", adminHandler.DeleteUser)
-			adminRoutes.POST("/students", adminHandler.CreateStudent)
-			adminRoutes.POST("/teachers", adminHandler.CreateTeacher)
-			adminRoutes.GET("/fees/structures", adminHandler.GetFeeStructures)
-			adminRoutes.POST("/fees/structures", adminHandler.CreateFeeStructure)
-			adminRoutes.POST("/payments", adminHandler.RecordPayment)
-			adminRoutes.GET("/payments", adminHandler.GetPayments)
-			adminRoutes.GET("/audit-logs", adminHandler.GetAuditLogs)
+			adminRoutes.GET("/dashboard", authz.RequirePermission(permission.AdminDashboardRead), adminHandler.GetDashboard)
+			adminRoutes.GET("/users", authz.RequirePermission(permission.UsersRead), adminHandler.GetUsers)
+			adminRoutes.GET("/users/:id", authz.RequirePermission(permission.UsersRead), adminHandler.GetUser)
+			adminRoutes.POST("/users", authz.RequirePermission(permission.UsersManage), adminHandler.CreateUser)
+			adminRoutes.PUT("/users/:id", authz.RequirePermission(permission.UsersManage), adminHandler.UpdateUser)
+			adminRoutes.DELETE("/users/:id", authz.RequirePermission(permission.UsersManage), adminHandler.DeleteUser)
+			adminRoutes.POST("/users/:id/sign-out", authz.RequirePermission(permission.UsersManage), adminHandler.ForceSignOut)
+			adminRoutes.POST("/users/:id/impersonate", authz.RequirePermission(permission.UsersImpersonate), authHandler.Impersonate)
+			adminRoutes.GET("/users/:id/sessions", authz.RequirePermission(permission.UsersRead), adminHandler.GetUserSessions)
+			adminRoutes.DELETE("/users/:id/sessions/:sessionId", authz.RequirePermission(permission.UsersManage), adminHandler.RevokeUserSession)
+			adminRoutes.GET("/invitations", authz.RequirePermission(permission.UsersRead), adminHandler.GetInvitations)
+			adminRoutes.POST("/invitations", authz.RequirePermission(permission.UsersManage), adminHandler.CreateInvitation)
+			adminRoutes.POST("/invitations/:id/resend", authz.RequirePermission(permission.UsersManage), adminHandler.ResendInvitation)
+			adminRoutes.DELETE("/invitations/:id", authz.RequirePermission(permission.UsersManage), adminHandler.RevokeInvitation)
+			adminRoutes.GET("/signups", authz.RequirePermission(permission.UsersRead), adminHandler.GetParentSignups)
+			adminRoutes.POST("/signups/:id/approve", authz.RequirePermission(permission.UsersManage), adminHandler.ApproveParentSignup)
+			adminRoutes.POST("/signups/:id/reject", authz.RequirePermission(permission.UsersManage), adminHandler.RejectParentSignup)
+			adminRoutes.POST("/students", authz.RequirePermission(permission.StudentsManage), adminHandler.CreateStudent)
+			adminRoutes.POST("/students/:id/transfer", authz.RequirePermission(permission.StudentsManage), adminHandler.TransferStudent)
+			adminRoutes.GET("/students/:id/enrollments", authz.RequirePermission(permission.StudentsManage), adminHandler.GetStudentEnrollments)
+			adminRoutes.GET("/students/:id/parents", authz.RequirePermission(permission.StudentsManage), parentHandler.GetGuardians)
+			adminRoutes.POST("/students/:id/parents", authz.RequirePermission(permission.StudentsManage), parentHandler.LinkParent)
+			adminRoutes.DELETE("/students/:id/parents/:parentId", authz.RequirePermission(permission.StudentsManage), parentHandler.UnlinkParent)
+			adminRoutes.POST("/teachers", authz.RequirePermission(permission.TeachersManage), adminHandler.CreateTeacher)
+			adminRoutes.POST("/imports/students", authz.RequirePermission(permission.StudentsManage), adminHandler.ImportStudents)
+			adminRoutes.POST("/imports/teachers", authz.RequirePermission(permission.TeachersManage), adminHandler.ImportTeachers)
+			adminRoutes.GET("/exports/users", authz.RequirePermission(permission.DataExport), adminHandler.ExportUsers)
+			adminRoutes.GET("/exports/students", authz.RequirePermission(permission.DataExport), adminHandler.ExportStudents)
+			adminRoutes.GET("/exports/fee-dues", authz.RequirePermission(permission.DataExport), adminHandler.ExportFeeDues)
+			adminRoutes.GET("/exports/payments", authz.RequirePermission(permission.DataExport), adminHandler.ExportPayments)
+			adminRoutes.GET("/exports/attendance", authz.RequirePermission(permission.DataExport), adminHandler.ExportAttendance)
+			adminRoutes.GET("/fees/structures", authz.RequirePermission(permission.FeesStructuresRead), adminHandler.GetFeeStructures)
+			adminRoutes.POST("/fees/structures", authz.RequirePermission(permission.FeesStructuresManage), adminHandler.CreateFeeStructure)
+			adminRoutes.POST("/fees/generate", authz.RequirePermission(permission.FeesStructuresManage), adminHandler.GenerateStudentFees)
+			adminRoutes.GET("/fees/items/:id/opt-ins", authz.RequirePermission(permission.FeesStructuresRead), adminHandler.GetFeeOptIns)
+			adminRoutes.POST("/fees/items/:id/opt-ins", authz.RequirePermission(permission.FeesStructuresManage), adminHandler.AddFeeOptIns)
+			adminRoutes.DELETE("/fees/items/:id/opt-ins/:studentId", authz.RequirePermission(permission.FeesStructuresManage), adminHandler.RemoveFeeOptIn)
+			adminRoutes.POST("/payments", authz.RequirePermission(permission.FeesPaymentsRecord), adminHandler.RecordPayment)
+			adminRoutes.GET("/payments", authz.RequirePermission(permission.FeesPaymentsRead), adminHandler.GetPayments)
+			adminRoutes.GET("/audit-logs", authz.RequirePermission(permission.AuditRead), adminHandler.GetAuditLogs)
+			adminRoutes.GET("/audit-logs/verify", authz.RequirePermission(permission.AuditRead), adminHandler.VerifyAuditLogs)
+			adminRoutes.GET("/lockouts", authz.RequirePermission(permission.SecurityLockoutsManage), adminHandler.GetLockouts)
+			adminRoutes.DELETE("/lockouts", authz.RequirePermission(permission.SecurityLockoutsManage), adminHandler.ClearLockout)
+
+			// Role management
+			adminRoutes.GET("/permissions", authz.RequirePermission(permission.RolesManage), roleHandler.GetPermissions)
+			adminRoutes.GET("/roles", authz.RequirePermission(permission.RolesManage), roleHandler.GetRoles)
+			adminRoutes.POST("/roles", authz.RequirePermission(permission.RolesManage), roleHandler.CreateRole)
+			adminRoutes.PUT("/roles/:id", authz.RequirePermission(permission.RolesManage), roleHandler.UpdateRole)
+			adminRoutes.DELETE("/roles/:id", authz.RequirePermission(permission.RolesManage), roleHandler.DeleteRole)
+			adminRoutes.GET("/users/:id/roles", authz.RequirePermission(permission.RolesManage), roleHandler.GetUserRoles)
+			adminRoutes.PUT("/users/:id/roles", authz.RequirePermission(permission.RolesManage), roleHandler.SetUserRoles)
+
+			// Single sign-on providers
+			adminRoutes.GET("/sso-providers", authz.RequirePermission(permission.SSOManage), authHandler.GetSSOProviders)
+			adminRoutes.PUT("/sso-providers/:slug", authz.RequirePermission(permission.SSOManage), authHandler.SaveSSOProvider)
+			adminRoutes.DELETE("/sso-providers/:slug", authz.RequirePermission(permission.SSOManage), authHandler.DeleteSSOProvider)
+
+			// School settings
+			adminRoutes.GET("/settings", authz.RequirePermission(permission.SettingsManage), settingsHandler.GetSettings)
+			adminRoutes.GET("/settings/:category", authz.RequirePermission(permission.SettingsManage), settingsHandler.GetCategorySettings)
+			adminRoutes.PUT("/settings/:category", authz.RequirePermission(permission.SettingsManage), settingsHandler.UpdateCategorySettings)
+			adminRoutes.DELETE("/settings/:category/:key", authz.RequirePermission(permission.SettingsManage), settingsHandler.ResetSetting)
+			adminRoutes.GET("/settings/:category/:key/history", authz.RequirePermission(permission.SettingsManage), settingsHandler.GetSettingHistory)
+
+			// Academic-year rollovers
+			adminRoutes.POST("/rollovers/preview", authz.RequirePermission(permission.AcademicYearRollover), rolloverHandler.PreviewRollover)
+			adminRoutes.POST("/rollovers", authz.RequirePermission(permission.AcademicYearRollover), rolloverHandler.StartRollover)
+			adminRoutes.GET("/rollovers", authz.RequirePermission(permission.AcademicYearRollover), rolloverHandler.GetRollovers)
+			adminRoutes.GET("/rollovers/:id", authz.RequirePermission(permission.AcademicYearRollover), rolloverHandler.GetRollover)
+			adminRoutes.POST("/rollovers/:id/resume", authz.RequirePermission(permission.AcademicYearRollover), rolloverHandler.ResumeRollover)
+
+			// Service accounts and API keys
+			adminRoutes.GET("/api-keys", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.GetAPIKeys)
+			adminRoutes.POST("/api-keys", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.CreateAPIKey)
+			adminRoutes.DELETE("/api-keys/:id", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.RevokeAPIKey)
+			adminRoutes.GET("/api-keys/service-accounts", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.GetServiceAccounts)
+			adminRoutes.POST("/api-keys/service-accounts", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.CreateServiceAccount)
+			adminRoutes.DELETE("/api-keys/service-accounts/:id", authz.RequirePermission(permission.APIKeysManage), apiKeyHandler.DeactivateServiceAccount)
 		}
 
 		// Classes routes (shared)
 		protected.GET("/classes", studentHandler.GetClasses)
-		protected.POST("/classes", middleware.RequireRole("admin"), studentHandler.CreateClass)
+		protected.POST("/classes", authz.RequirePermission(permission.ClassesManage), studentHandler.CreateClass)
+
+		// Super admin routes (school provisioning)
+		superAdminRoutes := protected.Group("/super-admin")
+		superAdminRoutes.Use(authz.RequirePermission(permission.SchoolsManage), requireMFA)
+		{
+			superAdminRoutes.GET("/schools", schoolHandler.GetSchools)
+			superAdminRoutes.POST("/schools", schoolHandler.CreateSchool)
+			superAdminRoutes.GET("/schools/:id", schoolHandler.GetSchool)
+			superAdminRoutes.PUT("/schools/:id", schoolHandler.UpdateSchool)
+		}
 	}
 
 	// 10. Start Server
@@ -15,6 +15,8 @@ type Config struct {
 	MongoDB   MongoDBConfig
 	Redis     RedisConfig
 	JWT       JWTConfig
+	Auth      AuthConfig
+	Password  PasswordConfig
 	AWS       AWSConfig
 	Razorpay  RazorpayConfig
 	Email     EmailConfig
@@ -27,10 +29,11 @@ type Config struct {
 }
 
 type AppConfig struct {
-	Env     string
-	Name    string
-	Port    string
-	GinMode string
+	Env         string
+	Name        string
+	Port        string
+	GinMode     string
+	FrontendURL string // Used to build links in emails
 }
 
 type DatabaseConfig struct {
@@ -43,6 +46,7 @@ type MongoDBConfig struct {
 }
 
 type RedisConfig struct {
+	Enabled  bool // When false, the in-memory cache is used instead
 	Addr     string
 	Password string
 	DB       int
@@ -53,6 +57,52 @@ type JWTConfig struct {
 	Secret                string
 	ExpirationHours       int
 	RefreshExpirationDays int
+	// Asymmetric signing (RS256/EdDSA); HS256 with Secret when KeysDir is empty
+	KeysDir      string
+	SigningKeyID string
+	AcceptHS256  bool // Keep accepting Secret-signed tokens while migrating
+}
+
+type AuthConfig struct {
+	PasswordResetTTLMinutes int
+	// Email verification
+	RequireEmailVerification       bool // Block login until the email is confirmed
+	EmailVerificationTTLHours      int
+	EmailVerificationResendSeconds int // Minimum gap between verification emails
+	// Two-factor authentication
+	MFARequiredRoles       string // Comma-separated roles that must use 2FA
+	MFAChallengeTTLMinutes int
+	// Login brute-force protection
+	LoginMaxAccountFailures   int // Failures before an account is locked
+	LoginMaxIPFailures        int // Failures before an IP is locked; high because schools share NAT IPs
+	LoginLockoutMinutes       int
+	LoginFailureWindowMinutes int // Account counts reset after this quiet period; IP counts this long after their first failure
+	// Phone OTP login (parents)
+	OTPTTLMinutes    int
+	OTPMaxAttempts   int // Wrong codes before an OTP is discarded
+	OTPResendSeconds int // Minimum gap between OTP messages to one account
+	// Onboarding
+	InvitationTTLHours  int
+	ParentSignupEnabled bool   // Allow parents to self-register, pending admin approval
+	SuperAdminEmail     string // Existing account promoted to super admin on startup
+	// Support
+	ImpersonationTTLMinutes int // Lifetime of read-only "view as user" tokens
+	// OpenID Connect single sign-on (providers are configured per school)
+	OIDCRedirectURL     string // Frontend page the identity provider returns to
+	OIDCStateTTLMinutes int    // Time allowed to complete a login at the provider
+}
+
+type PasswordConfig struct {
+	MinLength    int
+	MaxLength    int
+	RejectCommon bool // Reject passwords on the embedded common-password list
+	HistoryCount int  // Last N passwords that cannot be reused; 0 disables the check
+	// Hashing: new hashes use Algorithm, older ones are upgraded at login
+	Algorithm         string // argon2id | bcrypt
+	Argon2MemoryKB    int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
 }
 
 type AWSConfig struct {
@@ -70,15 +120,20 @@ type RazorpayConfig struct {
 }
 
 type EmailConfig struct {
+	Provider       string // sendgrid | file | log (file and log only in development)
+	FileDir        string // Used by the file provider
 	SendGridAPIKey string
 	FromEmail      string
 	FromName       string
 }
 
 type SMSConfig struct {
-	TwilioAccountSID string
-	TwilioAuthToken  string
-	TwilioFromPhone  string
+	Provider           string // twilio | file | log (file and log only in development)
+	FileDir            string // Used by the file provider
+	DefaultCountryCode string // Prefixed to phone numbers stored without one, e.g. "+91"
+	TwilioAccountSID   string
+	TwilioAuthToken    string
+	TwilioFromPhone    string
 }
 
 type FCMConfig struct {
@@ -117,10 +172,11 @@ func Load() *Config {
 
 	return &Config{
 		App: AppConfig{
-			Env:     getEnv("APP_ENV", "development"),
-			Name:    getEnv("APP_NAME", "schools24-backend"),
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Env:         getEnv("APP_ENV", "development"),
+			Name:        getEnv("APP_NAME", "schools24-backend"),
+			Port:        getEnv("SERVER_PORT", "8080"),
+			GinMode:     getEnv("GIN_MODE", "debug"),
+			FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
 		Database: DatabaseConfig{
 			URL: getEnv("DATABASE_URL", ""),
@@ -130,6 +186,7 @@ func Load() *Config {
 			Database: getEnv("MONGODB_DATABASE", "schools24_mongodb"),
 		},
 		Redis: RedisConfig{
+			Enabled:  getEnvAsBool("REDIS_ENABLED", false),
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
@@ -139,6 +196,41 @@ func Load() *Config {
 			Secret:                getEnv("JWT_SECRET", "default_jwt_secret_change_me"),
 			ExpirationHours:       getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 			RefreshExpirationDays: getEnvAsInt("JWT_REFRESH_EXPIRATION_DAYS", 7),
+			KeysDir:               getEnv("JWT_KEYS_DIR", ""),
+			SigningKeyID:          getEnv("JWT_SIGNING_KEY_ID", ""),
+			AcceptHS256:           getEnvAsBool("JWT_ACCEPT_HS256", false),
+		},
+		Auth: AuthConfig{
+			PasswordResetTTLMinutes:        getEnvAsInt("PASSWORD_RESET_TTL_MINUTES", 30),
+			RequireEmailVerification:       getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationTTLHours:      getEnvAsInt("EMAIL_VERIFICATION_TTL_HOURS", 48),
+			EmailVerificationResendSeconds: getEnvAsInt("EMAIL_VERIFICATION_RESEND_SECONDS", 60),
+			MFARequiredRoles:               getEnv("MFA_REQUIRED_ROLES", "admin"),
+			MFAChallengeTTLMinutes:         getEnvAsInt("MFA_CHALLENGE_TTL_MINUTES", 5),
+			LoginMaxAccountFailures:        getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+			LoginMaxIPFailures:             getEnvAsInt("LOGIN_MAX_IP_FAILURES", 100),
+			LoginLockoutMinutes:            getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+			LoginFailureWindowMinutes:      getEnvAsInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+			OTPTTLMinutes:                  getEnvAsInt("OTP_TTL_MINUTES", 5),
+			OTPMaxAttempts:                 getEnvAsInt("OTP_MAX_ATTEMPTS", 5),
+			OTPResendSeconds:               getEnvAsInt("OTP_RESEND_SECONDS", 60),
+			InvitationTTLHours:             getEnvAsInt("INVITATION_TTL_HOURS", 168),
+			ParentSignupEnabled:            getEnvAsBool("PARENT_SIGNUP_ENABLED", false),
+			SuperAdminEmail:                getEnv("SUPER_ADMIN_EMAIL", ""),
+			ImpersonationTTLMinutes:        getEnvAsInt("IMPERSONATION_TTL_MINUTES", 15),
+			OIDCRedirectURL:                getEnv("OIDC_REDIRECT_URL", ""),
+			OIDCStateTTLMinutes:            getEnvAsInt("OIDC_STATE_TTL_MINUTES", 10),
+		},
+		Password: PasswordConfig{
+			MinLength:         getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:         getEnvAsInt("PASSWORD_MAX_LENGTH", 128),
+			RejectCommon:      getEnvAsBool("PASSWORD_REJECT_COMMON", true),
+			HistoryCount:      getEnvAsInt("PASSWORD_HISTORY_COUNT", 5),
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2MemoryKB:    getEnvAsInt("PASSWORD_ARGON2_MEMORY_KB", 19456),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 2),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 1),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 12),
 		},
 		AWS: AWSConfig{
 			Region:          getEnv("AWS_REGION", "ap-south-1"),
@@ -153,14 +245,19 @@ func Load() *Config {
 			WebhookSecret: getEnv("RAZORPAY_WEBHOOK_SECRET", ""),
 		},
 		Email: EmailConfig{
+			Provider:       getEnv("EMAIL_PROVIDER", "log"),
+			FileDir:        getEnv("EMAIL_FILE_DIR", "./mail"),
 			SendGridAPIKey: getEnv("SENDGRID_API_KEY", ""),
 			FromEmail:      getEnv("SENDGRID_FROM_EMAIL", "noreply@schools24.com"),
 			FromName:       getEnv("SENDGRID_FROM_NAME", "Schools24"),
 		},
 		SMS: SMSConfig{
-			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
-			TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
-			TwilioFromPhone:  getEnv("TWILIO_FROM_PHONE", ""),
+			Provider:           getEnv("SMS_PROVIDER", "log"),
+			FileDir:            getEnv("SMS_FILE_DIR", "./sms"),
+			DefaultCountryCode: getEnv("SMS_DEFAULT_COUNTRY_CODE", "+91"),
+			TwilioAccountSID:   getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:    getEnv("TWILIO_AUTH_TOKEN", ""),
+			TwilioFromPhone:    getEnv("TWILIO_FROM_PHONE", ""),
 		},
 		FCM: FCMConfig{
 			ServerKey: getEnv("FCM_SERVER_KEY", ""),
@@ -177,7 +274,7 @@ func Load() *Config {
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
 			AllowedMethods: getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
-			AllowedHeaders: getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization"),
+			AllowedHeaders: getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-School-ID"),
 		},
 		Features: FeatureFlags{
 			QuestionPaperManagement: getEnvAsBool("FEATURE_QUESTION_PAPER_MANAGEMENT", true),
@@ -92,8 +92,10 @@ type Grade struct {
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 
 	// Joined fields
-	SubjectName string `json:"subject_name,omitempty"`
-	StudentName string `json:"student_name,omitempty"`
+	SubjectName string     `json:"subject_name,omitempty"`
+	StudentName string     `json:"student_name,omitempty"`
+	ClassID     *uuid.UUID `json:"class_id,omitempty"` // class on the exam date
+	ClassName   string     `json:"class_name,omitempty"`
 }
 
 // Subject represents a school subject
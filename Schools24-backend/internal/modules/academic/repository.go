@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Repository handles database operations for academic module
@@ -36,10 +37,11 @@ func (r *Repository) GetTimetableByClassID(ctx context.Context, classID uuid.UUI
 		LEFT JOIN users u ON te.user_id = u.id
 		LEFT JOIN classes c ON t.class_id = c.id
 		WHERE t.class_id = $1 AND t.academic_year = $2
+		  AND ($3::uuid IS NULL OR t.school_id = $3)
 		ORDER BY t.day_of_week, t.period_number
 	`
 
-	rows, err := r.db.Query(ctx, query, classID, academicYear)
+	rows, err := r.db.Query(ctx, query, classID, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get timetable: %w", err)
 	}
@@ -63,12 +65,13 @@ func (r *Repository) GetTimetableByClassID(ctx context.Context, classID uuid.UUI
 	return timetables, nil
 }
 
-// CreateTimetableEntry creates a new timetable entry
+// CreateTimetableEntry creates a new timetable entry in the class's school
 func (r *Repository) CreateTimetableEntry(ctx context.Context, entry *Timetable) error {
 	query := `
 		INSERT INTO timetables (id, class_id, day_of_week, period_number, subject_id, teacher_id,
-		                        start_time, end_time, room_number, academic_year, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8::time, $9, $10, $11, $12)
+		                        start_time, end_time, room_number, academic_year, created_at, updated_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8::time, $9, $10, $11, $12,
+		        (SELECT school_id FROM classes WHERE id = $2 AND ($13::uuid IS NULL OR school_id = $13)))
 		RETURNING id
 	`
 
@@ -81,6 +84,7 @@ func (r *Repository) CreateTimetableEntry(ctx context.Context, entry *Timetable)
 		entry.ID, entry.ClassID, entry.DayOfWeek, entry.PeriodNumber,
 		entry.SubjectID, entry.TeacherID, entry.StartTime, entry.EndTime,
 		entry.RoomNumber, entry.AcademicYear, entry.CreatedAt, entry.UpdatedAt,
+		tenant.SchoolID(ctx),
 	).Scan(&entry.ID)
 }
 
@@ -98,10 +102,11 @@ func (r *Repository) GetHomeworkByClassID(ctx context.Context, classID uuid.UUID
 		LEFT JOIN users u ON te.user_id = u.id
 		LEFT JOIN classes c ON h.class_id = c.id
 		WHERE h.class_id = $1 AND h.status = $2
+		  AND ($3::uuid IS NULL OR h.school_id = $3)
 		ORDER BY h.due_date DESC
 	`
 
-	rows, err := r.db.Query(ctx, query, classID, status)
+	rows, err := r.db.Query(ctx, query, classID, status, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get homework: %w", err)
 	}
@@ -124,12 +129,13 @@ func (r *Repository) GetHomeworkByClassID(ctx context.Context, classID uuid.UUID
 	return homeworks, nil
 }
 
-// CreateHomework creates a new homework assignment
+// CreateHomework creates a new homework assignment in the class's school
 func (r *Repository) CreateHomework(ctx context.Context, hw *Homework) error {
 	query := `
 		INSERT INTO homework (id, title, description, class_id, subject_id, teacher_id,
-		                      due_date, max_marks, attachments, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		                      due_date, max_marks, attachments, status, created_at, updated_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+		        (SELECT school_id FROM classes WHERE id = $4 AND ($13::uuid IS NULL OR school_id = $13)))
 		RETURNING id
 	`
 
@@ -142,6 +148,7 @@ func (r *Repository) CreateHomework(ctx context.Context, hw *Homework) error {
 	return r.db.QueryRow(ctx, query,
 		hw.ID, hw.Title, hw.Description, hw.ClassID, hw.SubjectID, hw.TeacherID,
 		hw.DueDate, hw.MaxMarks, hw.Attachments, hw.Status, hw.CreatedAt, hw.UpdatedAt,
+		tenant.SchoolID(ctx),
 	).Scan(&hw.ID)
 }
 
@@ -158,11 +165,11 @@ func (r *Repository) GetHomeworkByID(ctx context.Context, homeworkID uuid.UUID)
 		LEFT JOIN teachers te ON h.teacher_id = te.id
 		LEFT JOIN users u ON te.user_id = u.id
 		LEFT JOIN classes c ON h.class_id = c.id
-		WHERE h.id = $1
+		WHERE h.id = $1 AND ($2::uuid IS NULL OR h.school_id = $2)
 	`
 
 	var h Homework
-	err := r.db.QueryRow(ctx, query, homeworkID).Scan(
+	err := r.db.QueryRow(ctx, query, homeworkID, tenant.SchoolID(ctx)).Scan(
 		&h.ID, &h.Title, &h.Description, &h.ClassID, &h.SubjectID, &h.TeacherID,
 		&h.DueDate, &h.MaxMarks, &h.Attachments, &h.Status, &h.CreatedAt, &h.UpdatedAt,
 		&h.SubjectName, &h.TeacherName, &h.ClassName,
@@ -176,11 +183,12 @@ func (r *Repository) GetHomeworkByID(ctx context.Context, homeworkID uuid.UUID)
 	return &h, nil
 }
 
-// SubmitHomework creates a homework submission
+// SubmitHomework creates a homework submission in the homework's school
 func (r *Repository) SubmitHomework(ctx context.Context, sub *HomeworkSubmission) error {
 	query := `
-		INSERT INTO homework_submissions (id, homework_id, student_id, submission_text, attachments, submitted_at, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO homework_submissions (id, homework_id, student_id, submission_text, attachments, submitted_at, status, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7,
+		        (SELECT school_id FROM homework WHERE id = $2 AND ($8::uuid IS NULL OR school_id = $8)))
 		ON CONFLICT (homework_id, student_id) DO UPDATE
 		SET submission_text = EXCLUDED.submission_text,
 		    attachments = EXCLUDED.attachments,
@@ -195,24 +203,28 @@ func (r *Repository) SubmitHomework(ctx context.Context, sub *HomeworkSubmission
 
 	return r.db.QueryRow(ctx, query,
 		sub.ID, sub.HomeworkID, sub.StudentID, sub.SubmissionText,
-		sub.Attachments, sub.SubmittedAt, sub.Status,
+		sub.Attachments, sub.SubmittedAt, sub.Status, tenant.SchoolID(ctx),
 	).Scan(&sub.ID)
 }
 
-// GetStudentGrades retrieves grades for a student
+// GetStudentGrades retrieves grades for a student, each with the class the
+// student was in on the exam date
 func (r *Repository) GetStudentGrades(ctx context.Context, studentID uuid.UUID, academicYear string) ([]Grade, error) {
 	query := `
 		SELECT g.id, g.student_id, g.subject_id, g.exam_type, g.exam_name,
 		       g.max_marks, g.marks_obtained, g.grade, g.remarks, g.graded_by,
 		       g.exam_date, g.academic_year, g.created_at, g.updated_at,
-		       COALESCE(s.name, '') as subject_name
+		       COALESCE(s.name, '') as subject_name,
+		       c.id, COALESCE(c.name, '') as class_name
 		FROM grades g
 		LEFT JOIN subjects s ON g.subject_id = s.id
+		LEFT JOIN classes c ON c.id = student_class_on(g.student_id, COALESCE(g.exam_date, g.created_at::date))
 		WHERE g.student_id = $1 AND g.academic_year = $2
+		  AND ($3::uuid IS NULL OR g.school_id = $3)
 		ORDER BY g.exam_date DESC, g.subject_id
 	`
 
-	rows, err := r.db.Query(ctx, query, studentID, academicYear)
+	rows, err := r.db.Query(ctx, query, studentID, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get grades: %w", err)
 	}
@@ -225,7 +237,7 @@ func (r *Repository) GetStudentGrades(ctx context.Context, studentID uuid.UUID,
 			&g.ID, &g.StudentID, &g.SubjectID, &g.ExamType, &g.ExamName,
 			&g.MaxMarks, &g.MarksObtained, &g.Grade, &g.Remarks, &g.GradedBy,
 			&g.ExamDate, &g.AcademicYear, &g.CreatedAt, &g.UpdatedAt,
-			&g.SubjectName,
+			&g.SubjectName, &g.ClassID, &g.ClassName,
 		)
 		if err != nil {
 			return nil, err
@@ -241,10 +253,11 @@ func (r *Repository) GetAllSubjects(ctx context.Context) ([]Subject, error) {
 	query := `
 		SELECT id, name, code, description, grade_levels, credits, is_optional, created_at
 		FROM subjects
+		WHERE ($1::uuid IS NULL OR school_id = $1)
 		ORDER BY name
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -265,9 +278,14 @@ func (r *Repository) GetAllSubjects(ctx context.Context) ([]Subject, error) {
 
 // CreateSubject creates a new subject
 func (r *Repository) CreateSubject(ctx context.Context, subject *Subject) error {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO subjects (id, name, code, description, grade_levels, credits, is_optional, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO subjects (id, name, code, description, grade_levels, credits, is_optional, created_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
@@ -276,6 +294,6 @@ func (r *Repository) CreateSubject(ctx context.Context, subject *Subject) error
 
 	return r.db.QueryRow(ctx, query,
 		subject.ID, subject.Name, subject.Code, subject.Description,
-		subject.GradeLevels, subject.Credits, subject.IsOptional, subject.CreatedAt,
+		subject.GradeLevels, subject.Credits, subject.IsOptional, subject.CreatedAt, schoolID,
 	).Scan(&subject.ID)
 }
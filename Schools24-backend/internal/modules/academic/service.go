@@ -3,10 +3,10 @@ package academic
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/settings"
 	"github.com/schools24/backend/internal/modules/student"
 )
 
@@ -14,6 +14,7 @@ import (
 type Service struct {
 	repo        *Repository
 	studentRepo *student.Repository
+	settings    *settings.Service
 	config      *config.Config
 }
 
@@ -25,10 +26,11 @@ var (
 )
 
 // NewService creates a new academic service
-func NewService(repo *Repository, studentRepo *student.Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, studentRepo *student.Repository, settingsService *settings.Service, cfg *config.Config) *Service {
 	return &Service{
 		repo:        repo,
 		studentRepo: studentRepo,
+		settings:    settingsService,
 		config:      cfg,
 	}
 }
@@ -44,8 +46,13 @@ func (s *Service) GetTimetable(ctx context.Context, userID uuid.UUID) ([]DaySche
 		return nil, student.ErrStudentNotFound
 	}
 
-	academicYear := getCurrentAcademicYear()
-	timetables, err := s.repo.GetTimetableByClassID(ctx, *studentProfile.ClassID, academicYear)
+	return s.GetClassTimetable(ctx, *studentProfile.ClassID)
+}
+
+// GetClassTimetable returns a class's timetable for the current academic year
+func (s *Service) GetClassTimetable(ctx context.Context, classID uuid.UUID) ([]DaySchedule, error) {
+	academicYear := s.settings.CurrentAcademicYear(ctx)
+	timetables, err := s.repo.GetTimetableByClassID(ctx, classID, academicYear)
 	if err != nil {
 		return nil, err
 	}
@@ -56,9 +63,9 @@ func (s *Service) GetTimetable(ctx context.Context, userID uuid.UUID) ([]DaySche
 		dayMap[t.DayOfWeek] = append(dayMap[t.DayOfWeek], t)
 	}
 
-	// Create day schedules (Monday to Saturday for Indian schools)
+	// Create day schedules for the school's working days
 	var schedules []DaySchedule
-	for day := 1; day <= 6; day++ { // 1=Monday to 6=Saturday
+	for _, day := range s.settings.WeekDays(ctx) {
 		schedule := DaySchedule{
 			DayOfWeek: day,
 			DayName:   GetDayName(day),
@@ -83,11 +90,16 @@ func (s *Service) GetHomework(ctx context.Context, userID uuid.UUID, status stri
 		return nil, student.ErrStudentNotFound
 	}
 
+	return s.GetClassHomework(ctx, *studentProfile.ClassID, status)
+}
+
+// GetClassHomework returns homework for a class
+func (s *Service) GetClassHomework(ctx context.Context, classID uuid.UUID, status string) ([]Homework, error) {
 	if status == "" {
 		status = "active"
 	}
 
-	return s.repo.GetHomeworkByClassID(ctx, *studentProfile.ClassID, status)
+	return s.repo.GetHomeworkByClassID(ctx, classID, status)
 }
 
 // GetHomeworkByID returns a single homework
@@ -141,11 +153,16 @@ func (s *Service) GetGrades(ctx context.Context, userID uuid.UUID, academicYear
 		return nil, student.ErrStudentNotFound
 	}
 
+	return s.GetStudentGrades(ctx, studentProfile.ID, academicYear)
+}
+
+// GetStudentGrades returns grades for a student profile
+func (s *Service) GetStudentGrades(ctx context.Context, studentID uuid.UUID, academicYear string) ([]Grade, error) {
 	if academicYear == "" {
-		academicYear = getCurrentAcademicYear()
+		academicYear = s.settings.CurrentAcademicYear(ctx)
 	}
 
-	return s.repo.GetStudentGrades(ctx, studentProfile.ID, academicYear)
+	return s.repo.GetStudentGrades(ctx, studentID, academicYear)
 }
 
 // GetSubjects returns all subjects
@@ -157,15 +174,3 @@ func (s *Service) GetSubjects(ctx context.Context) ([]Subject, error) {
 func (s *Service) CreateSubject(ctx context.Context, subject *Subject) error {
 	return s.repo.CreateSubject(ctx, subject)
 }
-
-// getCurrentAcademicYear returns current academic year
-func getCurrentAcademicYear() string {
-	now := time.Now()
-	year := now.Year()
-	month := now.Month()
-
-	if month < time.April {
-		return time.Date(year-1, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006") + "-" + time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
-	}
-	return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006") + "-" + time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
-}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+	// maxAuditChainBreaks caps the breaks listed by a verification
+	maxAuditChainBreaks = 100
+)
+
+// genesisAuditHash is the prev_hash of the first entry of every chain
+var genesisAuditHash = strings.Repeat("0", 64)
+
+// GetAuditLogs returns a page of audit logs matching the filter, newest first
+func (s *Service) GetAuditLogs(ctx context.Context, filter *AuditLogFilter) (*AuditLogPage, error) {
+	q := &auditLogQuery{entityType: filter.EntityType, limit: filter.Limit}
+	if q.limit <= 0 {
+		q.limit = defaultAuditLogLimit
+	}
+	if q.limit > maxAuditLogLimit {
+		q.limit = maxAuditLogLimit
+	}
+
+	var err error
+	if q.userID, err = parseOptionalUUID(filter.UserID); err != nil {
+		return nil, ErrInvalidInput
+	}
+	if q.entityID, err = parseOptionalUUID(filter.EntityID); err != nil {
+		return nil, ErrInvalidInput
+	}
+	if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+		q.actionPrefix = prefix
+	} else {
+		q.action = filter.Action
+	}
+	if filter.From != "" {
+		from, err := time.Parse("2006-01-02", filter.From)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		q.from = &from
+	}
+	if filter.To != "" {
+		to, err := time.Parse("2006-01-02", filter.To)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		to = to.AddDate(0, 0, 1)
+		q.to = &to
+	}
+	if filter.Cursor != "" {
+		if q.afterTime, q.afterID, err = decodeAuditCursor(filter.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there is a next page
+	q.limit++
+	logs, err := s.repo.GetAuditLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	page := &AuditLogPage{AuditLogs: logs}
+	if len(logs) == q.limit {
+		page.AuditLogs = logs[:len(logs)-1]
+		last := page.AuditLogs[len(page.AuditLogs)-1]
+		page.NextCursor = encodeAuditCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// VerifyAuditChain walks the request school's audit chain (the platform chain
+// for platform admins) and reports every entry that was removed, reordered or
+// altered since it was written
+func (s *Service) VerifyAuditChain(ctx context.Context) (*AuditChainReport, error) {
+	report := &AuditChainReport{Valid: true, Breaks: []AuditChainBreak{}}
+	expectedSeq := int64(1)
+	prevHash := genesisAuditHash
+
+	fail := func(seq int64, id uuid.UUID, reason string) {
+		report.Valid = false
+		if len(report.Breaks) == maxAuditChainBreaks {
+			report.Truncated = true
+			return
+		}
+		report.Breaks = append(report.Breaks, AuditChainBreak{Seq: seq, ID: id, Reason: reason})
+	}
+
+	err := s.repo.StreamAuditChain(ctx, tenant.SchoolID(ctx), func(e *auditChainEntry) error {
+		report.Checked++
+		// Entries outside the chain can only have been written with its triggers disabled
+		if e.Seq == nil || e.PrevHash == nil || e.Hash == nil {
+			fail(0, e.ID, "hash_mismatch")
+			return nil
+		}
+
+		switch {
+		case *e.Seq != expectedSeq:
+			fail(*e.Seq, e.ID, "missing_entries")
+		case *e.PrevHash != prevHash:
+			fail(*e.Seq, e.ID, "broken_link")
+		case *e.Hash != e.ExpectedHash:
+			fail(*e.Seq, e.ID, "hash_mismatch")
+		}
+		expectedSeq = *e.Seq + 1
+		prevHash = *e.Hash
+		report.HeadSeq = *e.Seq
+		report.HeadHash = *e.Hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.VerifiedAt = time.Now()
+	return report, nil
+}
+
+// encodeAuditCursor returns the keyset cursor that continues after an entry
+func encodeAuditCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeAuditCursor(cursor string) (*time.Time, *uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	ts, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	return &createdAt, &id, nil
+}
+
+func parseOptionalUUID(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
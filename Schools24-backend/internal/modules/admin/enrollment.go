@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransferStudent moves a student to another class of the same academic year.
+// The move takes effect on the effective date: the student's enrollment history
+// changes class that day and attendance they were marked for in the old class
+// from then on moves to the new one. Academic-year moves are rollovers.
+func (s *Service) TransferStudent(ctx context.Context, actorID, studentID uuid.UUID, req *TransferStudentRequest, ipAddress, userAgent string) (*StudentTransfer, error) {
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	effective := today
+	if req.EffectiveDate != "" {
+		if effective, err = time.Parse("2006-01-02", req.EffectiveDate); err != nil {
+			return nil, ErrInvalidInput
+		}
+	}
+	if effective.After(today) {
+		return nil, ErrInvalidEffectiveDate
+	}
+
+	placement, err := s.repo.GetStudentPlacement(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if placement == nil {
+		return nil, ErrStudentNotFound
+	}
+	if placement.Status != "active" || placement.Class == nil {
+		return nil, ErrStudentNotEnrolled
+	}
+	if placement.Class.ID == classID {
+		return nil, ErrSameClass
+	}
+	if placement.EnrolledSince != nil && effective.Before(*placement.EnrolledSince) {
+		return nil, ErrInvalidEffectiveDate
+	}
+
+	target, err := s.repo.GetEnrollmentClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrClassNotFound
+	}
+	if target.AcademicYear != placement.Class.AcademicYear {
+		return nil, ErrClassYearMismatch
+	}
+
+	moved, ok, err := s.repo.TransferStudent(ctx, studentID, placement.Class.ID, target, effective)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrStudentNotEnrolled
+	}
+
+	transfer := &StudentTransfer{
+		StudentID:       studentID,
+		FromClassID:     placement.Class.ID,
+		ToClassID:       classID,
+		EffectiveDate:   effective.Format("2006-01-02"),
+		AttendanceMoved: moved,
+	}
+	oldValues := map[string]interface{}{"class_id": placement.Class.ID}
+	newValues := map[string]interface{}{"class_id": classID, "effective_date": transfer.EffectiveDate, "attendance_moved": moved}
+	if err := s.repo.LogAudit(ctx, &actorID, "student.transferred", "student", &studentID, oldValues, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit transfer of student %s: %v", studentID, err)
+	}
+
+	if transfer.Enrollments, err = s.repo.GetStudentEnrollments(ctx, studentID); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetStudentEnrollments returns the classes a student has been in, oldest first
+func (s *Service) GetStudentEnrollments(ctx context.Context, studentID uuid.UUID) ([]Enrollment, error) {
+	exists, err := s.repo.StudentExists(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrStudentNotFound
+	}
+	return s.repo.GetStudentEnrollments(ctx, studentID)
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/spreadsheet"
+)
+
+// attendanceCodes are the register letters for attendance statuses
+var attendanceCodes = map[string]string{
+	"present": "P",
+	"absent":  "A",
+	"late":    "L",
+	"excused": "E",
+}
+
+// ExportUsers writes the school's users to w
+func (s *Service) ExportUsers(ctx context.Context, actorID uuid.UUID, filter *UserExportFilter, w spreadsheet.Writer, ipAddress, userAgent string) error {
+	if err := w.WriteRow("ID", "Email", "Full name", "Role", "Phone", "Active", "Email verified", "Created at", "Last login"); err != nil {
+		return err
+	}
+	if err := s.repo.ExportUsers(ctx, filter, w.WriteRow); err != nil {
+		return err
+	}
+	s.auditExport(ctx, actorID, "users", filter, ipAddress, userAgent)
+	return nil
+}
+
+// ExportStudents writes students, optionally of one class or academic year, to w
+func (s *Service) ExportStudents(ctx context.Context, actorID uuid.UUID, filter *StudentExportFilter, w spreadsheet.Writer, ipAddress, userAgent string) error {
+	classID, err := s.exportClass(ctx, filter.ClassID)
+	if err != nil {
+		return err
+	}
+
+	if err := w.WriteRow("Admission number", "Roll number", "Full name", "Email", "Class", "Section", "Academic year",
+		"Date of birth", "Gender", "Parent name", "Parent email", "Parent phone", "Address", "Admission date", "Active"); err != nil {
+		return err
+	}
+	if err := s.repo.ExportStudents(ctx, classID, filter.AcademicYear, w.WriteRow); err != nil {
+		return err
+	}
+	s.auditExport(ctx, actorID, "students", filter, ipAddress, userAgent)
+	return nil
+}
+
+// ExportFeeDues writes fees with an outstanding balance to w
+func (s *Service) ExportFeeDues(ctx context.Context, actorID uuid.UUID, filter *FeeDueExportFilter, w spreadsheet.Writer, ipAddress, userAgent string) error {
+	classID, err := s.exportClass(ctx, filter.ClassID)
+	if err != nil {
+		return err
+	}
+	var dueBefore *time.Time
+	if filter.DueBefore != "" {
+		t, err := time.Parse("2006-01-02", filter.DueBefore)
+		if err != nil {
+			return ErrInvalidInput
+		}
+		dueBefore = &t
+	}
+
+	if err := w.WriteRow("Admission number", "Student", "Class", "Fee item", "Due date",
+		"Amount", "Paid", "Waived", "Balance", "Status", "Academic year"); err != nil {
+		return err
+	}
+	if err := s.repo.ExportFeeDues(ctx, classID, filter, dueBefore, w.WriteRow); err != nil {
+		return err
+	}
+	s.auditExport(ctx, actorID, "fee_dues", filter, ipAddress, userAgent)
+	return nil
+}
+
+// ExportPayments writes the payments made between two dates, inclusive, to w
+func (s *Service) ExportPayments(ctx context.Context, actorID uuid.UUID, filter *PaymentExportFilter, w spreadsheet.Writer, ipAddress, userAgent string) error {
+	from, errFrom := time.Parse("2006-01-02", filter.From)
+	to, errTo := time.Parse("2006-01-02", filter.To)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		return ErrInvalidInput
+	}
+
+	if err := w.WriteRow("Receipt number", "Payment date", "Admission number", "Student", "Amount", "Payment method",
+		"Transaction ID", "Status", "Collected by", "Notes"); err != nil {
+		return err
+	}
+	if err := s.repo.ExportPayments(ctx, from, to.AddDate(0, 0, 1), filter.Method, w.WriteRow); err != nil {
+		return err
+	}
+	s.auditExport(ctx, actorID, "payments", filter, ipAddress, userAgent)
+	return nil
+}
+
+// ExportAttendanceRegister writes a class's register for one month to w: a row
+// per student with a column per day and the month's totals
+func (s *Service) ExportAttendanceRegister(ctx context.Context, actorID uuid.UUID, filter *AttendanceExportFilter, w spreadsheet.Writer, ipAddress, userAgent string) error {
+	classID, err := s.exportClass(ctx, filter.ClassID)
+	if err != nil {
+		return err
+	}
+	from, err := time.Parse("2006-01", filter.Month)
+	if err != nil {
+		return ErrInvalidInput
+	}
+	to := from.AddDate(0, 1, 0)
+	days := to.AddDate(0, 0, -1).Day()
+
+	header := []interface{}{"Admission number", "Roll number", "Student"}
+	for day := 1; day <= days; day++ {
+		header = append(header, strconv.Itoa(day))
+	}
+	header = append(header, "Present", "Absent", "Late", "Excused")
+	if err := w.WriteRow(header...); err != nil {
+		return err
+	}
+
+	// Marks arrive grouped by student; each student's row is written when the next begins
+	var current *attendanceMark
+	var row []interface{}
+	totals := map[string]int{}
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		row = append(row, totals["present"], totals["absent"], totals["late"], totals["excused"])
+		return w.WriteRow(row...)
+	}
+
+	err = s.repo.ExportAttendance(ctx, *classID, from, to, func(m attendanceMark) error {
+		if current == nil || current.StudentID != m.StudentID {
+			if err := flush(); err != nil {
+				return err
+			}
+			mark := m
+			current = &mark
+			row = make([]interface{}, 3+days)
+			row[0], row[1], row[2] = m.AdmissionNumber, m.RollNumber, m.FullName
+			totals = map[string]int{}
+		}
+		if m.Date != nil && m.Status != nil {
+			row[2+m.Date.Day()] = attendanceCodes[*m.Status]
+			totals[*m.Status]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	s.auditExport(ctx, actorID, "attendance", filter, ipAddress, userAgent)
+	return nil
+}
+
+// exportClass parses an optional class filter and checks the class is the school's
+func (s *Service) exportClass(ctx context.Context, classIDStr string) (*uuid.UUID, error) {
+	if classIDStr == "" {
+		return nil, nil
+	}
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	exists, err := s.repo.ClassExists(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrClassNotFound
+	}
+	return &classID, nil
+}
+
+// auditExport records who exported what; exports copy personal data out of the system
+func (s *Service) auditExport(ctx context.Context, actorID uuid.UUID, kind string, filter interface{}, ipAddress, userAgent string) {
+	details := map[string]interface{}{"kind": kind, "filter": filter}
+	if err := s.repo.LogAudit(ctx, &actorID, "export."+kind, "export", nil, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit %s export by %s: %v", kind, actorID, err)
+	}
+}
@@ -0,0 +1,293 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// feeYearPattern matches academic years such as 2025-2026 or 2025-26
+var feeYearPattern = regexp.MustCompile(`^(\d{4})-\d{2,4}$`)
+
+// feePeriodMonths is how many months one instalment of each frequency covers.
+// A one-time item is a single instalment for the whole year.
+var feePeriodMonths = map[string]int{
+	"monthly":   1,
+	"quarterly": 3,
+	"yearly":    12,
+	"one_time":  12,
+}
+
+// maxFeeInsertBatch caps how many instalments one insert statement stores
+const maxFeeInsertBatch = 5000
+
+// GenerateStudentFees creates the student_fees instalments of a school's active
+// fee structures for an academic year. Each item of a structure is expanded
+// into dated instalments for every active student of its grades; optional
+// items only for students opted into them. Students admitted during the year
+// are not charged for periods before they joined, and pay the period they
+// joined in pro rata. Instalments that already exist are left alone, so
+// generation can re-run after structures, students or opt-ins change.
+func (s *Service) GenerateStudentFees(ctx context.Context, actorID uuid.UUID, req *FeeGenerationRequest, ipAddress, userAgent string) (*FeeGenerationReport, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	academicYear := req.AcademicYear
+	if academicYear == "" {
+		academicYear = s.settings.CurrentAcademicYear(ctx)
+	}
+	match := feeYearPattern.FindStringSubmatch(academicYear)
+	if match == nil {
+		return nil, ErrInvalidInput
+	}
+	startYear, _ := strconv.Atoi(match[1])
+	yearStart := time.Date(startYear, s.settings.AcademicYearStartMonth(ctx), 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := yearStart.AddDate(1, 0, 0)
+
+	var structureID *uuid.UUID
+	if req.StructureID != "" {
+		id, err := uuid.Parse(req.StructureID)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		structureID = &id
+	}
+
+	structures, err := s.repo.GetGenerationFeeStructures(ctx, schoolID, academicYear, structureID)
+	if err != nil {
+		return nil, err
+	}
+	if structureID != nil && len(structures) == 0 {
+		return nil, ErrFeeStructureNotFound
+	}
+
+	var itemIDs []uuid.UUID
+	for _, fs := range structures {
+		for _, item := range fs.Items {
+			itemIDs = append(itemIDs, item.ID)
+		}
+	}
+	optIns, err := s.repo.GetOptedInStudents(ctx, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetInstalmentKeys(ctx, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FeeGenerationReport{
+		AcademicYear: academicYear,
+		DryRun:       req.DryRun,
+		Structures:   []FeeStructureGeneration{},
+	}
+	for _, fs := range structures {
+		students, err := s.repo.GetFeeStudents(ctx, schoolID, academicYear, fs.ApplicableGrades)
+		if err != nil {
+			return nil, err
+		}
+
+		generation := FeeStructureGeneration{StructureID: fs.ID, Name: fs.Name, Students: len(students)}
+		var pending []feeInstalment
+		for _, item := range fs.Items {
+			for _, student := range students {
+				if item.IsOptional && !optIns[item.ID][student.ID] {
+					continue
+				}
+				for _, instalment := range expandFeeItem(item, student, yearStart, yearEnd) {
+					key := feeInstalmentKey{StudentID: student.ID, FeeItemID: item.ID, PeriodStart: instalment.PeriodStart.Format("2006-01-02")}
+					if existing[key] {
+						generation.Existing++
+						continue
+					}
+					pending = append(pending, instalment)
+					generation.Amount += instalment.Amount
+				}
+			}
+		}
+
+		generation.Created = len(pending)
+		if !req.DryRun {
+			created := 0
+			for start := 0; start < len(pending); start += maxFeeInsertBatch {
+				end := min(start+maxFeeInsertBatch, len(pending))
+				n, err := s.repo.InsertInstalments(ctx, schoolID, academicYear, pending[start:end])
+				if err != nil {
+					return nil, err
+				}
+				created += n
+			}
+			// A concurrent run may have stored some of them first
+			generation.Existing += len(pending) - created
+			generation.Created = created
+		}
+		generation.Amount = roundAmount(generation.Amount)
+
+		report.Structures = append(report.Structures, generation)
+		report.Created += generation.Created
+		report.Existing += generation.Existing
+		report.Amount += generation.Amount
+	}
+	report.Amount = roundAmount(report.Amount)
+
+	if !req.DryRun {
+		newValues := map[string]interface{}{
+			"academic_year":        academicYear,
+			"structure_id":         structureID,
+			"instalments_created":  report.Created,
+			"instalments_existing": report.Existing,
+			"amount_created":       report.Amount,
+		}
+		if err := s.repo.LogAudit(ctx, &actorID, "fees.generated", "school", &schoolID, nil, newValues, ipAddress, userAgent); err != nil {
+			log.Printf("Failed to audit fee generation for school %s: %v", schoolID, err)
+		}
+	}
+	return report, nil
+}
+
+// expandFeeItem returns a student's instalments of a fee item for the academic
+// year [yearStart, yearEnd). Periods that ended before the student was admitted
+// are skipped; the period they were admitted in is charged for the days left
+// in it, except one-time fees which are charged in full. An instalment is never
+// due before the student was admitted.
+func expandFeeItem(item FeeItem, student feeStudent, yearStart, yearEnd time.Time) []feeInstalment {
+	months, ok := feePeriodMonths[item.Frequency]
+	if !ok {
+		months = 1
+	}
+	joined := time.Date(student.AdmissionDate.Year(), student.AdmissionDate.Month(), student.AdmissionDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	var instalments []feeInstalment
+	for start := yearStart; start.Before(yearEnd); start = start.AddDate(0, months, 0) {
+		end := start.AddDate(0, months, 0)
+		if end.After(yearEnd) {
+			end = yearEnd
+		}
+		if !end.After(joined) {
+			continue
+		}
+
+		amount := item.Amount
+		due := feeDueDate(start, item.DueDay)
+		if joined.After(start) {
+			if item.Frequency != "one_time" {
+				amount = roundAmount(amount * end.Sub(joined).Hours() / end.Sub(start).Hours())
+			}
+			if due.Before(joined) {
+				due = feeDueDate(time.Date(joined.Year(), joined.Month(), 1, 0, 0, 0, 0, time.UTC), item.DueDay)
+			}
+			if due.Before(joined) {
+				due = joined
+			}
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		instalments = append(instalments, feeInstalment{
+			StudentID:   student.ID,
+			FeeItemID:   item.ID,
+			Amount:      amount,
+			DueDate:     due,
+			PeriodStart: start,
+			PeriodEnd:   end,
+		})
+	}
+	return instalments
+}
+
+// feeDueDate returns the due day of the month starting at monthStart, moved
+// to the month's last day in months that are too short
+func feeDueDate(monthStart time.Time, dueDay int) time.Time {
+	lastDay := monthStart.AddDate(0, 1, -1).Day()
+	day := min(max(dueDay, 1), lastDay)
+	return monthStart.AddDate(0, 0, day-1)
+}
+
+// roundAmount rounds an amount to two decimal places
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// GetFeeOptIns returns the students opted into an optional fee item
+func (s *Service) GetFeeOptIns(ctx context.Context, itemID uuid.UUID) ([]FeeOptIn, error) {
+	item, err := s.repo.GetFeeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrFeeItemNotFound
+	}
+	return s.repo.GetFeeOptIns(ctx, itemID)
+}
+
+// AddFeeOptIns opts students into an optional fee item and returns how many
+// were newly opted in. Their instalments are created by the next generation run.
+func (s *Service) AddFeeOptIns(ctx context.Context, actorID, itemID uuid.UUID, req *FeeOptInRequest, ipAddress, userAgent string) (int, error) {
+	studentIDs := make([]uuid.UUID, 0, len(req.StudentIDs))
+	for _, raw := range req.StudentIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return 0, ErrInvalidInput
+		}
+		studentIDs = append(studentIDs, id)
+	}
+
+	item, err := s.repo.GetFeeItem(ctx, itemID)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, ErrFeeItemNotFound
+	}
+	if !item.IsOptional {
+		return 0, ErrFeeItemNotOptional
+	}
+
+	added, err := s.repo.AddFeeOptIns(ctx, actorID, itemID, studentIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	newValues := map[string]interface{}{"student_ids": studentIDs, "added": added}
+	if err := s.repo.LogAudit(ctx, &actorID, "fees.opted_in", "fee_item", &itemID, nil, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit opt-ins for fee item %s: %v", itemID, err)
+	}
+	return added, nil
+}
+
+// RemoveFeeOptIn opts a student out of an optional fee item. Their instalments
+// of it that are not yet due and have no payment or waiver are cancelled; the
+// number cancelled is returned.
+func (s *Service) RemoveFeeOptIn(ctx context.Context, actorID, itemID, studentID uuid.UUID, ipAddress, userAgent string) (int, error) {
+	item, err := s.repo.GetFeeItem(ctx, itemID)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, ErrFeeItemNotFound
+	}
+
+	ok, cancelled, err := s.repo.RemoveFeeOptIn(ctx, itemID, studentID)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrFeeOptInNotFound
+	}
+
+	oldValues := map[string]interface{}{"student_id": studentID}
+	newValues := map[string]interface{}{"instalments_cancelled": cancelled}
+	if err := s.repo.LogAudit(ctx, &actorID, "fees.opted_out", "fee_item", &itemID, oldValues, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit opt-out of fee item %s: %v", itemID, err)
+	}
+	return cancelled, nil
+}
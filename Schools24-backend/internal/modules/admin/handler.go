@@ -1,13 +1,21 @@
 package admin
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/spreadsheet"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Handler handles HTTP requests for admin module
@@ -78,18 +86,28 @@ func (h *Handler) GetUser(c *gin.Context) {
 // CreateUser creates a new user
 // POST /api/v1/admin/users
 func (h *Handler) CreateUser(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	userID, err := h.service.CreateUser(c.Request.Context(), &req)
+	userID, err := h.service.CreateUser(c.Request.Context(), actorID, &req)
 	if err != nil {
 		if errors.Is(err, ErrEmailExists) {
 			c.JSON(http.StatusConflict, gin.H{"error": "email_already_exists"})
 			return
 		}
+		if errors.Is(err, ErrPermissionDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": err.Error()})
+			return
+		}
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Code, "message": policyErr.Message})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,6 +121,8 @@ func (h *Handler) CreateUser(c *gin.Context) {
 // UpdateUser updates a user
 // PUT /api/v1/admin/users/:id
 func (h *Handler) UpdateUser(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
@@ -116,11 +136,15 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateUser(c.Request.Context(), userID, &req); err != nil {
+	if err := h.service.UpdateUser(c.Request.Context(), actorID, userID, &req); err != nil {
 		if errors.Is(err, ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found"})
 			return
 		}
+		if errors.Is(err, ErrPermissionDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -150,6 +174,78 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// ForceSignOut revokes all sessions of a user
+// POST /api/v1/admin/users/:id/sign-out
+func (h *Handler) ForceSignOut(c *gin.Context) {
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := h.service.ForceSignOut(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User signed out from all sessions"})
+}
+
+// GetUserSessions lists a user's active sessions
+// GET /api/v1/admin/users/:id/sessions
+func (h *Handler) GetUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	sessions, err := h.service.GetUserSessions(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeUserSession signs a user out of one session
+// DELETE /api/v1/admin/users/:id/sessions/:sessionId
+func (h *Handler) RevokeUserSession(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	if err := h.service.RevokeUserSession(c.Request.Context(), actorID, userID, sessionID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session signed out"})
+}
+
 // CreateStudent creates a student with profile
 // POST /api/v1/admin/students
 func (h *Handler) CreateStudent(c *gin.Context) {
@@ -161,6 +257,10 @@ func (h *Handler) CreateStudent(c *gin.Context) {
 
 	userID, err := h.service.CreateStudent(c.Request.Context(), &req)
 	if err != nil {
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Code, "message": policyErr.Message})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -171,6 +271,68 @@ func (h *Handler) CreateStudent(c *gin.Context) {
 	})
 }
 
+// TransferStudent moves a student to another class of the same academic year
+// POST /api/v1/admin/students/:id/transfer
+func (h *Handler) TransferStudent(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	var req TransferStudentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.service.TransferStudent(c.Request.Context(), actorID, studentID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondEnrollmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfer": transfer})
+}
+
+// GetStudentEnrollments returns the classes a student has been in and when
+// GET /api/v1/admin/students/:id/enrollments
+func (h *Handler) GetStudentEnrollments(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	enrollments, err := h.service.GetStudentEnrollments(c.Request.Context(), studentID)
+	if err != nil {
+		respondEnrollmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enrollments": enrollments})
+}
+
+// respondEnrollmentError maps transfer and enrollment errors to responses
+func respondEnrollmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrStudentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student_not_found"})
+	case errors.Is(err, ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "class_not_found"})
+	case errors.Is(err, ErrStudentNotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": "student_not_enrolled", "message": err.Error()})
+	case errors.Is(err, ErrSameClass):
+		c.JSON(http.StatusConflict, gin.H{"error": "same_class", "message": err.Error()})
+	case errors.Is(err, ErrClassYearMismatch), errors.Is(err, ErrInvalidEffectiveDate), errors.Is(err, ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_transfer", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // CreateTeacher creates a teacher with profile
 // POST /api/v1/admin/teachers
 func (h *Handler) CreateTeacher(c *gin.Context) {
@@ -182,6 +344,10 @@ func (h *Handler) CreateTeacher(c *gin.Context) {
 
 	userID, err := h.service.CreateTeacher(c.Request.Context(), &req)
 	if err != nil {
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Code, "message": policyErr.Message})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -227,6 +393,122 @@ func (h *Handler) CreateFeeStructure(c *gin.Context) {
 	})
 }
 
+// GenerateStudentFees creates the instalments students owe under the active
+// fee structures of an academic year; a dry run only reports them
+// POST /api/v1/admin/fees/generate
+func (h *Handler) GenerateStudentFees(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req FeeGenerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.GenerateStudentFees(c.Request.Context(), actorID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"generation": report})
+}
+
+// GetFeeOptIns returns the students opted into an optional fee item
+// GET /api/v1/admin/fees/items/:id/opt-ins
+func (h *Handler) GetFeeOptIns(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee item ID"})
+		return
+	}
+
+	optIns, err := h.service.GetFeeOptIns(c.Request.Context(), itemID)
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"opt_ins": optIns})
+}
+
+// AddFeeOptIns opts students into an optional fee item
+// POST /api/v1/admin/fees/items/:id/opt-ins
+func (h *Handler) AddFeeOptIns(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee item ID"})
+		return
+	}
+
+	var req FeeOptInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	added, err := h.service.AddFeeOptIns(c.Request.Context(), actorID, itemID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Students opted in successfully",
+		"added":   added,
+	})
+}
+
+// RemoveFeeOptIn opts a student out of an optional fee item
+// DELETE /api/v1/admin/fees/items/:id/opt-ins/:studentId
+func (h *Handler) RemoveFeeOptIn(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee item ID"})
+		return
+	}
+	studentID, err := uuid.Parse(c.Param("studentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	cancelled, err := h.service.RemoveFeeOptIn(c.Request.Context(), actorID, itemID, studentID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Student opted out successfully",
+		"instalments_cancelled": cancelled,
+	})
+}
+
+// respondFeeError maps fee generation and opt-in errors to responses
+func respondFeeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFeeStructureNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_structure_not_found"})
+	case errors.Is(err, ErrFeeItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_item_not_found"})
+	case errors.Is(err, ErrFeeOptInNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "opt_in_not_found", "message": err.Error()})
+	case errors.Is(err, ErrFeeItemNotOptional):
+		c.JSON(http.StatusConflict, gin.H{"error": "fee_item_not_optional", "message": err.Error()})
+	case errors.Is(err, ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_input", "message": "academic_year must look like 2025-2026"})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // RecordPayment records a payment
 // POST /api/v1/admin/payments
 func (h *Handler) RecordPayment(c *gin.Context) {
@@ -270,16 +552,438 @@ func (h *Handler) GetPayments(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"payments": payments})
 }
 
-// GetAuditLogs returns audit logs
-// GET /api/v1/admin/audit-logs
+// GetAuditLogs returns a page of audit logs, filtered by actor, entity,
+// action and date range. Pass next_cursor back as cursor for the next page.
+// GET /api/v1/admin/audit-logs?user_id=&entity_type=&entity_id=&action=payment.*&from=&to=&cursor=&limit=
 func (h *Handler) GetAuditLogs(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	var filter AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.GetAuditLogs(c.Request.Context(), &filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_cursor"})
+		case errors.Is(err, ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_filter"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// VerifyAuditLogs checks the audit log's hash chain and reports any break
+// GET /api/v1/admin/audit-logs/verify
+func (h *Handler) VerifyAuditLogs(c *gin.Context) {
+	report, err := h.service.VerifyAuditChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetLockouts returns accounts and IPs blocked after failed logins
+// GET /api/v1/admin/lockouts
+func (h *Handler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.service.GetLockouts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lockouts": lockouts})
+}
+
+// ClearLockout unblocks an account or IP
+// DELETE /api/v1/admin/lockouts?kind=account&key=someone@example.com
+func (h *Handler) ClearLockout(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	actorID, _ := uuid.Parse(userIDStr)
+
+	var req ClearLockoutRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ClearLockout(c.Request.Context(), actorID, &req, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if errors.Is(err, ErrLockoutNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "lockout_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
+
+// CreateInvitation invites someone to join the school
+// POST /api/v1/admin/invitations
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.service.CreateInvitation(c.Request.Context(), actorID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation": invitation})
+}
+
+// GetInvitations lists invitations
+// GET /api/v1/admin/invitations?status=pending
+func (h *Handler) GetInvitations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	invitations, err := h.service.GetInvitations(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// ResendInvitation emails a new invitation link
+// POST /api/v1/admin/invitations/:id/resend
+func (h *Handler) ResendInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+
+	invitation, err := h.service.ResendInvitation(c.Request.Context(), id)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitation": invitation})
+}
+
+// RevokeInvitation cancels a pending invitation
+// DELETE /api/v1/admin/invitations/:id
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Request.Context(), actorID, id, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// GetParentSignups returns the parent sign-up approval queue
+// GET /api/v1/admin/signups?status=pending|approved|rejected|all
+func (h *Handler) GetParentSignups(c *gin.Context) {
+	signups, err := h.service.GetParentSignups(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signups": signups})
+}
+
+// ApproveParentSignup activates a self-registered parent account
+// POST /api/v1/admin/signups/:id/approve
+func (h *Handler) ApproveParentSignup(c *gin.Context) {
+	h.reviewParentSignup(c, true)
+}
+
+// RejectParentSignup rejects a self-registered parent account
+// POST /api/v1/admin/signups/:id/reject
+func (h *Handler) RejectParentSignup(c *gin.Context) {
+	h.reviewParentSignup(c, false)
+}
+
+func (h *Handler) reviewParentSignup(c *gin.Context, approve bool) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	signupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign-up ID"})
+		return
+	}
+
+	if err := h.service.ReviewParentSignup(c.Request.Context(), actorID, signupID, approve, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+
+	if approve {
+		c.JSON(http.StatusOK, gin.H{"message": "Sign-up approved"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sign-up rejected"})
+}
+
+// respondOnboardingError maps invitation and sign-up errors to responses
+func respondOnboardingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation_not_found"})
+	case errors.Is(err, ErrSignupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "signup_not_found"})
+	case errors.Is(err, ErrStudentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student_not_found"})
+	case errors.Is(err, ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "class_not_found"})
+	case errors.Is(err, ErrInvitationNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "invitation_not_pending", "message": err.Error()})
+	case errors.Is(err, ErrEmailExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "email_already_exists"})
+	case errors.Is(err, ErrAdmissionNumberExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "admission_number_exists"})
+	case errors.Is(err, ErrInvitationFields), errors.Is(err, ErrStudentDetailsRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_invitation", "message": err.Error()})
+	case errors.Is(err, ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
 
-	logs, err := h.service.GetAuditLogs(c.Request.Context(), limit)
+// ImportStudents imports students from a CSV or XLSX upload ("file" field).
+// With dry_run=true only the validation report is returned.
+// POST /api/v1/admin/imports/students?dry_run=true
+func (h *Handler) ImportStudents(c *gin.Context) {
+	h.runImport(c, h.service.ImportStudents)
+}
+
+// ImportTeachers imports teachers from a CSV or XLSX upload ("file" field).
+// With dry_run=true only the validation report is returned.
+// POST /api/v1/admin/imports/teachers?dry_run=true
+func (h *Handler) ImportTeachers(c *gin.Context) {
+	h.runImport(c, h.service.ImportTeachers)
+}
+
+type importFunc func(ctx context.Context, actorID uuid.UUID, filename string, data []byte, dryRun bool, ipAddress, userAgent string) (*ImportReport, error)
+
+func (h *Handler) runImport(c *gin.Context, run importFunc) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBytes+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file_required", "message": "upload the import file in the \"file\" field"})
+		return
+	}
+	if file.Size > MaxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file_too_large"})
+		return
+	}
+	f, err := file.Open()
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := run(c.Request.Context(), actorID, file.Filename, data, dryRun, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+
+	switch {
+	case report.Committed:
+		c.JSON(http.StatusCreated, report)
+	case !dryRun:
+		// Nothing was created; the report says why
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// respondImportError maps import errors to responses
+func respondImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, spreadsheet.ErrUnsupportedFormat), errors.Is(err, spreadsheet.ErrInvalidFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_file", "message": err.Error()})
+	case errors.Is(err, ErrImportEmpty), errors.Is(err, ErrImportTooManyRows):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_file", "message": err.Error()})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	default:
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": policyErr.Code, "message": "generated passwords do not meet the password policy"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ExportUsers downloads users as CSV or XLSX, filtered by role and status
+// GET /api/v1/admin/exports/users?format=csv|xlsx&role=&status=active|inactive
+func (h *Handler) ExportUsers(c *gin.Context) {
+	var filter UserExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.runExport(c, "users", func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error {
+		return h.service.ExportUsers(ctx, actorID, &filter, w, c.ClientIP(), c.Request.UserAgent())
+	})
+}
+
+// ExportStudents downloads students as CSV or XLSX, optionally of one class
+// GET /api/v1/admin/exports/students?format=csv|xlsx&class_id=&academic_year=
+func (h *Handler) ExportStudents(c *gin.Context) {
+	var filter StudentExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.runExport(c, "students", func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error {
+		return h.service.ExportStudents(ctx, actorID, &filter, w, c.ClientIP(), c.Request.UserAgent())
+	})
+}
+
+// ExportFeeDues downloads outstanding fees as CSV or XLSX
+// GET /api/v1/admin/exports/fee-dues?format=csv|xlsx&class_id=&academic_year=&status=&due_before=
+func (h *Handler) ExportFeeDues(c *gin.Context) {
+	var filter FeeDueExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.runExport(c, "fee-dues", func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error {
+		return h.service.ExportFeeDues(ctx, actorID, &filter, w, c.ClientIP(), c.Request.UserAgent())
+	})
+}
+
+// ExportPayments downloads payments in a date range as CSV or XLSX
+// GET /api/v1/admin/exports/payments?format=csv|xlsx&from=2025-04-01&to=2025-04-30
+func (h *Handler) ExportPayments(c *gin.Context) {
+	var filter PaymentExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.runExport(c, "payments", func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error {
+		return h.service.ExportPayments(ctx, actorID, &filter, w, c.ClientIP(), c.Request.UserAgent())
+	})
+}
+
+// ExportAttendance downloads a class's monthly attendance register as CSV or XLSX
+// GET /api/v1/admin/exports/attendance?format=csv|xlsx&class_id=&month=2025-06
+func (h *Handler) ExportAttendance(c *gin.Context) {
+	var filter AttendanceExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.runExport(c, "attendance-"+filter.Month, func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error {
+		return h.service.ExportAttendanceRegister(ctx, actorID, &filter, w, c.ClientIP(), c.Request.UserAgent())
+	})
+}
+
+// runExport streams an export to the response. Errors found before the first
+// row are answered as JSON; later ones can only cut the download short.
+func (h *Handler) runExport(c *gin.Context, name string, export func(ctx context.Context, actorID uuid.UUID, w spreadsheet.Writer) error) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_format", "message": "format must be csv or xlsx"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+	w := &responseSheet{c: c, format: format, filename: name + "-" + time.Now().Format("20060102") + "." + format}
+	err := export(c.Request.Context(), actorID, w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !w.started() {
+		respondExportError(c, err)
+		return
+	}
+	log.Printf("Export %s by %s failed mid-stream: %v", name, actorID, err)
+	c.Abort()
+}
+
+// responseSheet starts the download when the first row is written
+type responseSheet struct {
+	c        *gin.Context
+	format   string
+	filename string
+	w        spreadsheet.Writer
+}
+
+func (r *responseSheet) started() bool {
+	return r.w != nil
+}
+
+func (r *responseSheet) WriteRow(cells ...interface{}) error {
+	if r.w == nil {
+		r.c.Header("Content-Type", spreadsheet.ContentType(r.format))
+		r.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.filename))
+		r.c.Header("Cache-Control", "no-store")
+		r.c.Status(http.StatusOK)
+
+		w, err := spreadsheet.NewWriter(r.format, r.c.Writer)
+		if err != nil {
+			return err
+		}
+		r.w = w
+	}
+	return r.w.WriteRow(cells...)
+}
+
+func (r *responseSheet) Close() error {
+	if r.w == nil {
+		return nil
+	}
+	return r.w.Close()
+}
+
+// respondExportError maps export errors to responses
+func respondExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "class_not_found"})
+	case errors.Is(err, ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_filter"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,624 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/spreadsheet"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+const (
+	// MaxImportBytes is the largest import file accepted
+	MaxImportBytes = 10 << 20
+	// maxImportRows caps the data rows of one import
+	maxImportRows = 5000
+	// importPasswordLength is the length of generated initial passwords
+	importPasswordLength = 12
+	// importHashWorkers bounds concurrent password hashing; each argon2id hash holds its memory cost
+	importHashWorkers = 4
+)
+
+// importPasswordAlphabet leaves out characters that are easily confused on paper (0/O, 1/l/I)
+const importPasswordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// importColumns describes the columns of an import file
+type importColumns struct {
+	required []string
+	optional []string
+}
+
+var studentImportColumns = importColumns{
+	required: []string{"full_name", "email", "admission_number", "class", "date_of_birth"},
+	optional: []string{"roll_number", "gender", "phone", "parent_name", "parent_email", "parent_phone",
+		"address", "admission_date", "academic_year"},
+}
+
+var teacherImportColumns = importColumns{
+	required: []string{"full_name", "email", "employee_id"},
+	optional: []string{"phone", "department", "qualification", "experience_years", "subjects", "hire_date"},
+}
+
+// importColumnAliases maps common header spellings to column names
+var importColumnAliases = map[string]string{
+	"name":         "full_name",
+	"student_name": "full_name",
+	"teacher_name": "full_name",
+	"email_id":     "email",
+	"admission_no": "admission_number",
+	"roll_no":      "roll_number",
+	"class_name":   "class",
+	"dob":          "date_of_birth",
+	"birth_date":   "date_of_birth",
+	"employee_no":  "employee_id",
+	"joining_date": "hire_date",
+}
+
+// importClass is a class that import rows can name
+type importClass struct {
+	ID           uuid.UUID
+	Name         string
+	Grade        int
+	Section      string
+	AcademicYear string
+}
+
+// importedStudent is a validated student row
+type importedStudent struct {
+	row             int
+	userID          uuid.UUID
+	password        string
+	passwordHash    string
+	fullName        string
+	email           string
+	phone           string
+	admissionNumber string
+	rollNumber      string
+	class           *importClass
+	dateOfBirth     time.Time
+	gender          string
+	address         string
+	parentName      string
+	parentEmail     string
+	parentPhone     string
+	admissionDate   time.Time
+}
+
+// importedTeacher is a validated teacher row
+type importedTeacher struct {
+	row             int
+	userID          uuid.UUID
+	password        string
+	passwordHash    string
+	fullName        string
+	email           string
+	phone           string
+	employeeID      string
+	department      string
+	qualification   string
+	experienceYears int
+	subjects        []string
+	hireDate        time.Time
+}
+
+// importSheet is a parsed import file: data rows keyed by column name
+type importSheet struct {
+	rows   []importRow
+	errors []ImportRowError
+}
+
+type importRow struct {
+	number int
+	values map[string]string
+}
+
+func (r importRow) get(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+// ImportStudents validates a file of students and, unless dryRun is set and
+// every row is valid, creates them all in one transaction. The report lists
+// every problem found; nothing is created if there is any.
+func (s *Service) ImportStudents(ctx context.Context, actorID uuid.UUID, filename string, data []byte, dryRun bool, ipAddress, userAgent string) (*ImportReport, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := parseImportSheet(filename, data, studentImportColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	students, errs, err := s.validateStudentRows(ctx, schoolID, sheet.rows)
+	if err != nil {
+		return nil, err
+	}
+	report := newImportReport("students", dryRun, sheet, errs, len(students))
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	if err := s.hashImportPasswords(len(students), func(i int) (*string, *string) {
+		return &students[i].password, &students[i].passwordHash
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ImportStudents(ctx, schoolID, students); err != nil {
+		return nil, err
+	}
+
+	for _, st := range students {
+		s.rememberPassword(ctx, st.userID, st.passwordHash)
+		if st.parentEmail != "" {
+			if err := s.repo.LinkParentByEmail(ctx, st.userID); err != nil {
+				log.Printf("Failed to link parent of student user %s: %v", st.userID, err)
+			}
+		}
+		report.Credentials = append(report.Credentials, ImportCredential{
+			Row:        st.row,
+			UserID:     st.userID,
+			FullName:   st.fullName,
+			Email:      st.email,
+			Password:   st.password,
+			Identifier: st.admissionNumber,
+			ClassName:  st.class.Name,
+		})
+	}
+	report.Committed = true
+	s.auditImport(ctx, actorID, report, filename, ipAddress, userAgent)
+	return report, nil
+}
+
+// ImportTeachers validates a file of teachers and, unless dryRun is set and
+// every row is valid, creates them all in one transaction
+func (s *Service) ImportTeachers(ctx context.Context, actorID uuid.UUID, filename string, data []byte, dryRun bool, ipAddress, userAgent string) (*ImportReport, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := parseImportSheet(filename, data, teacherImportColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	teachers, errs, err := s.validateTeacherRows(ctx, schoolID, sheet.rows)
+	if err != nil {
+		return nil, err
+	}
+	report := newImportReport("teachers", dryRun, sheet, errs, len(teachers))
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	if err := s.hashImportPasswords(len(teachers), func(i int) (*string, *string) {
+		return &teachers[i].password, &teachers[i].passwordHash
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ImportTeachers(ctx, schoolID, teachers); err != nil {
+		return nil, err
+	}
+
+	for _, t := range teachers {
+		s.rememberPassword(ctx, t.userID, t.passwordHash)
+		report.Credentials = append(report.Credentials, ImportCredential{
+			Row:        t.row,
+			UserID:     t.userID,
+			FullName:   t.fullName,
+			Email:      t.email,
+			Password:   t.password,
+			Identifier: t.employeeID,
+		})
+	}
+	report.Committed = true
+	s.auditImport(ctx, actorID, report, filename, ipAddress, userAgent)
+	return report, nil
+}
+
+func newImportReport(kind string, dryRun bool, sheet *importSheet, rowErrors []ImportRowError, valid int) *ImportReport {
+	errs := append(sheet.errors, rowErrors...)
+	if errs == nil {
+		errs = []ImportRowError{}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+	return &ImportReport{
+		Kind:      kind,
+		DryRun:    dryRun,
+		TotalRows: len(sheet.rows),
+		ValidRows: valid,
+		Errors:    errs,
+	}
+}
+
+func (s *Service) auditImport(ctx context.Context, actorID uuid.UUID, report *ImportReport, filename, ipAddress, userAgent string) {
+	details := map[string]interface{}{"kind": report.Kind, "file": filename, "created": len(report.Credentials)}
+	if err := s.repo.LogAudit(ctx, &actorID, "import."+report.Kind, "import", nil, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit %s import by %s: %v", report.Kind, actorID, err)
+	}
+}
+
+// parseImportSheet reads the file and maps each data row by its header.
+// Header problems are reported against row 1; blank rows are skipped.
+func parseImportSheet(filename string, data []byte, columns importColumns) (*importSheet, error) {
+	rows, err := spreadsheet.Read(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, ErrImportEmpty
+	}
+
+	sheet := &importSheet{}
+	known := map[string]bool{}
+	for _, c := range append(columns.required, columns.optional...) {
+		known[c] = true
+	}
+
+	header := make([]string, len(rows[0]))
+	seen := map[string]bool{}
+	for i, h := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(h))
+		name = strings.NewReplacer(" ", "_", "-", "_", ".", "").Replace(name)
+		if alias, ok := importColumnAliases[name]; ok {
+			name = alias
+		}
+		switch {
+		case name == "":
+			continue
+		case !known[name]:
+			sheet.errors = append(sheet.errors, ImportRowError{Row: 1, Column: h, Message: "unknown column"})
+			continue
+		case seen[name]:
+			sheet.errors = append(sheet.errors, ImportRowError{Row: 1, Column: h, Message: "duplicate column"})
+			continue
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	for _, c := range columns.required {
+		if !seen[c] {
+			sheet.errors = append(sheet.errors, ImportRowError{Row: 1, Column: c, Message: "required column is missing"})
+		}
+	}
+
+	for i, values := range rows[1:] {
+		row := importRow{number: i + 2, values: map[string]string{}}
+		blank := true
+		for j, v := range values {
+			if j < len(header) && header[j] != "" {
+				row.values[header[j]] = v
+			}
+			if strings.TrimSpace(v) != "" {
+				blank = false
+			}
+		}
+		if blank {
+			continue
+		}
+		if len(sheet.rows) == maxImportRows {
+			return nil, ErrImportTooManyRows
+		}
+		sheet.rows = append(sheet.rows, row)
+	}
+	if len(sheet.rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return sheet, nil
+}
+
+// rowChecker collects the problems of one row
+type rowChecker struct {
+	row    int
+	errors []ImportRowError
+}
+
+func (c *rowChecker) fail(column, format string, args ...interface{}) {
+	c.errors = append(c.errors, ImportRowError{Row: c.row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// text checks a value against a column's required flag and maximum length
+func (c *rowChecker) text(r importRow, column string, required bool, maxLen int) string {
+	v := r.get(column)
+	if v == "" && required {
+		c.fail(column, "is required")
+	}
+	if utf8.RuneCountInString(v) > maxLen {
+		c.fail(column, "must be at most %d characters", maxLen)
+	}
+	return v
+}
+
+// email checks and lower-cases an email column
+func (c *rowChecker) email(r importRow, column string, required bool) string {
+	v := strings.ToLower(c.text(r, column, required, 255))
+	if v == "" {
+		return ""
+	}
+	if addr, err := mail.ParseAddress(v); err != nil || addr.Address != v {
+		c.fail(column, "%q is not a valid email address", v)
+	}
+	return v
+}
+
+// date checks a date column; empty optional dates return the fallback
+func (c *rowChecker) date(r importRow, column string, required bool, fallback time.Time) time.Time {
+	v := r.get(column)
+	if v == "" {
+		if required {
+			c.fail(column, "is required")
+		}
+		return fallback
+	}
+	t, err := spreadsheet.ParseDate(v)
+	if err != nil {
+		c.fail(column, "%s", err.Error())
+		return fallback
+	}
+	return t
+}
+
+// uniqueness tracks the first row of each value of a column that must be unique
+type uniqueness map[string]int
+
+func (u uniqueness) check(c *rowChecker, column, value string) {
+	if value == "" {
+		return
+	}
+	if first, ok := u[value]; ok {
+		c.fail(column, "%q is already used in row %d", value, first)
+		return
+	}
+	u[value] = c.row
+}
+
+// validateStudentRows checks every student row, including against existing
+// users, students and classes, and returns the valid rows with all problems
+func (s *Service) validateStudentRows(ctx context.Context, schoolID uuid.UUID, rows []importRow) ([]importedStudent, []ImportRowError, error) {
+	classes, err := s.repo.GetImportClasses(ctx, schoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	classesByName := map[string][]*importClass{}
+	for i := range classes {
+		key := strings.ToLower(classes[i].Name)
+		classesByName[key] = append(classesByName[key], &classes[i])
+	}
+
+	emails, admissionNumbers := uniqueness{}, uniqueness{}
+	students := []importedStudent{}
+	var errs []ImportRowError
+	today := time.Now().Truncate(24 * time.Hour)
+
+	for _, r := range rows {
+		c := &rowChecker{row: r.number}
+		st := importedStudent{
+			row:             r.number,
+			fullName:        c.text(r, "full_name", true, 255),
+			email:           c.email(r, "email", true),
+			phone:           c.text(r, "phone", false, 20),
+			admissionNumber: c.text(r, "admission_number", true, 50),
+			rollNumber:      c.text(r, "roll_number", false, 50),
+			dateOfBirth:     c.date(r, "date_of_birth", true, time.Time{}),
+			address:         r.get("address"),
+			parentName:      c.text(r, "parent_name", false, 255),
+			parentEmail:     c.email(r, "parent_email", false),
+			parentPhone:     c.text(r, "parent_phone", false, 20),
+			admissionDate:   c.date(r, "admission_date", false, today),
+		}
+		if st.dateOfBirth.After(today) {
+			c.fail("date_of_birth", "cannot be in the future")
+		}
+		emails.check(c, "email", st.email)
+		admissionNumbers.check(c, "admission_number", st.admissionNumber)
+
+		switch gender := strings.ToLower(r.get("gender")); gender {
+		case "":
+		case "male", "m":
+			st.gender = "male"
+		case "female", "f":
+			st.gender = "female"
+		case "other":
+			st.gender = "other"
+		default:
+			c.fail("gender", "%q is not one of male, female, other", gender)
+		}
+
+		if name := r.get("class"); name != "" {
+			var matches []*importClass
+			year := r.get("academic_year")
+			for _, class := range classesByName[strings.ToLower(name)] {
+				if year == "" || class.AcademicYear == year {
+					matches = append(matches, class)
+				}
+			}
+			switch len(matches) {
+			case 0:
+				c.fail("class", "unknown class %q", name)
+			case 1:
+				st.class = matches[0]
+			default:
+				c.fail("class", "class %q exists in several academic years, add an academic_year column", name)
+			}
+		} else {
+			c.fail("class", "is required")
+		}
+
+		if len(c.errors) > 0 {
+			errs = append(errs, c.errors...)
+			continue
+		}
+		students = append(students, st)
+	}
+
+	taken, err := s.repo.GetTakenEmails(ctx, keysOf(emails))
+	if err != nil {
+		return nil, nil, err
+	}
+	takenNumbers, err := s.repo.GetTakenAdmissionNumbers(ctx, schoolID, keysOf(admissionNumbers))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valid := students[:0]
+	for _, st := range students {
+		c := &rowChecker{row: st.row}
+		if taken[st.email] {
+			c.fail("email", "%q already belongs to a user", st.email)
+		}
+		if takenNumbers[st.admissionNumber] {
+			c.fail("admission_number", "%q is already in use", st.admissionNumber)
+		}
+		if len(c.errors) > 0 {
+			errs = append(errs, c.errors...)
+			continue
+		}
+		valid = append(valid, st)
+	}
+	return valid, errs, nil
+}
+
+// validateTeacherRows checks every teacher row, including against existing
+// users and teachers, and returns the valid rows with all problems
+func (s *Service) validateTeacherRows(ctx context.Context, schoolID uuid.UUID, rows []importRow) ([]importedTeacher, []ImportRowError, error) {
+	emails, employeeIDs := uniqueness{}, uniqueness{}
+	teachers := []importedTeacher{}
+	var errs []ImportRowError
+	today := time.Now().Truncate(24 * time.Hour)
+
+	for _, r := range rows {
+		c := &rowChecker{row: r.number}
+		t := importedTeacher{
+			row:           r.number,
+			fullName:      c.text(r, "full_name", true, 255),
+			email:         c.email(r, "email", true),
+			phone:         c.text(r, "phone", false, 20),
+			employeeID:    c.text(r, "employee_id", true, 50),
+			department:    c.text(r, "department", false, 100),
+			qualification: c.text(r, "qualification", false, 255),
+			hireDate:      c.date(r, "hire_date", false, today),
+		}
+		emails.check(c, "email", t.email)
+		employeeIDs.check(c, "employee_id", t.employeeID)
+
+		if v := r.get("experience_years"); v != "" {
+			years, err := strconv.Atoi(v)
+			if err != nil || years < 0 || years > 60 {
+				c.fail("experience_years", "%q is not a number of years", v)
+			}
+			t.experienceYears = years
+		}
+		// Subjects are separated by semicolons or commas
+		for _, subject := range strings.FieldsFunc(r.get("subjects"), func(r rune) bool { return r == ';' || r == ',' }) {
+			if subject = strings.TrimSpace(subject); subject != "" {
+				t.subjects = append(t.subjects, subject)
+			}
+		}
+
+		if len(c.errors) > 0 {
+			errs = append(errs, c.errors...)
+			continue
+		}
+		teachers = append(teachers, t)
+	}
+
+	taken, err := s.repo.GetTakenEmails(ctx, keysOf(emails))
+	if err != nil {
+		return nil, nil, err
+	}
+	takenIDs, err := s.repo.GetTakenEmployeeIDs(ctx, schoolID, keysOf(employeeIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valid := teachers[:0]
+	for _, t := range teachers {
+		c := &rowChecker{row: t.row}
+		if taken[t.email] {
+			c.fail("email", "%q already belongs to a user", t.email)
+		}
+		if takenIDs[t.employeeID] {
+			c.fail("employee_id", "%q is already in use", t.employeeID)
+		}
+		if len(c.errors) > 0 {
+			errs = append(errs, c.errors...)
+			continue
+		}
+		valid = append(valid, t)
+	}
+	return valid, errs, nil
+}
+
+func keysOf(u uniqueness) []string {
+	keys := make([]string, 0, len(u))
+	for k := range u {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// hashImportPasswords generates and hashes an initial password for each of
+// n rows; field returns where row i keeps its password and hash
+func (s *Service) hashImportPasswords(n int, field func(i int) (*string, *string)) error {
+	for i := 0; i < n; i++ {
+		plain, _ := field(i)
+		p, err := generateImportPassword()
+		if err != nil {
+			return err
+		}
+		if err := s.passwords.Validate(p); err != nil {
+			return err
+		}
+		*plain = p
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < importHashWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				plain, hash := field(i)
+				h, err := s.passwords.Hash(*plain)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				*hash = h
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// generateImportPassword returns a random password that is easy to copy from a printout
+func generateImportPassword() (string, error) {
+	b := make([]byte, importPasswordLength)
+	max := big.NewInt(int64(len(importPasswordAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = importPasswordAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -116,18 +116,83 @@ type AuditLog struct {
 	UserAgent  *string     `json:"user_agent,omitempty" db:"user_agent"`
 	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
 
+	// Hash chain: each entry's hash covers its content and the previous entry's hash
+	Seq      *int64  `json:"seq,omitempty" db:"seq"`
+	PrevHash *string `json:"prev_hash,omitempty" db:"prev_hash"`
+	Hash     *string `json:"hash,omitempty" db:"hash"`
+
 	// Joined fields
 	UserName string `json:"user_name,omitempty"`
 }
 
+// AuditLogFilter narrows the audit log. Action may end in "*" to match a prefix
+// such as "payment.*"; dates are inclusive.
+type AuditLogFilter struct {
+	UserID     string `form:"user_id" binding:"omitempty,uuid"`
+	EntityType string `form:"entity_type" binding:"omitempty,max=100"`
+	EntityID   string `form:"entity_id" binding:"omitempty,uuid"`
+	Action     string `form:"action" binding:"omitempty,max=100"`
+	From       string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Cursor     string `form:"cursor"`
+	Limit      int    `form:"limit"`
+}
+
+// AuditLogPage is one page of audit log entries, newest first
+type AuditLogPage struct {
+	AuditLogs  []AuditLog `json:"audit_logs"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// AuditChainBreak is a point where the audit log's hash chain does not hold
+type AuditChainBreak struct {
+	Seq    int64     `json:"seq"`
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"` // missing_entries, broken_link, hash_mismatch
+}
+
+// AuditChainReport is the result of verifying a school's audit log chain.
+// Recording HeadHash elsewhere lets a later verification prove nothing before it changed.
+type AuditChainReport struct {
+	Valid      bool              `json:"valid"`
+	Checked    int64             `json:"checked"`
+	HeadSeq    int64             `json:"head_seq"`
+	HeadHash   string            `json:"head_hash,omitempty"`
+	Breaks     []AuditChainBreak `json:"breaks"`
+	Truncated  bool              `json:"truncated"` // more breaks than listed
+	VerifiedAt time.Time         `json:"verified_at"`
+}
+
+// LoginLockout is an account or IP currently delayed or locked after failed logins
+type LoginLockout struct {
+	Kind         string     `json:"kind"` // account, ip
+	Key          string     `json:"key"`  // normalized email or IP address
+	Failures     int        `json:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  time.Time  `json:"locked_until"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	UserName     *string    `json:"user_name,omitempty"`
+}
+
+// UserSession is an active login of a user on one device
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
 // Request types
 
 // CreateUserRequest for admin creating users
 type CreateUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	FullName string `json:"full_name" binding:"required"`
-	Role     string `json:"role" binding:"required"` // student, teacher, admin, staff, parent
+	Role     string `json:"role" binding:"required,oneof=admin teacher student staff parent"`
 	Phone    string `json:"phone,omitempty"`
 }
 
@@ -135,7 +200,7 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
 	Email    string `json:"email,omitempty"`
 	FullName string `json:"full_name,omitempty"`
-	Role     string `json:"role,omitempty"`
+	Role     string `json:"role,omitempty" binding:"omitempty,oneof=admin teacher student staff parent"`
 	Phone    string `json:"phone,omitempty"`
 	IsActive *bool  `json:"is_active,omitempty"`
 }
@@ -143,13 +208,14 @@ type UpdateUserRequest struct {
 // CreateStudentRequest for creating student with profile
 type CreateStudentRequest struct {
 	Email       string `json:"email" binding:"required,email"`
-	Password    string `json:"password" binding:"required,min=6"`
+	Password    string `json:"password" binding:"required"`
 	FullName    string `json:"full_name" binding:"required"`
 	Phone       string `json:"phone,omitempty"`
 	ClassID     string `json:"class_id" binding:"required"`
 	RollNumber  string `json:"roll_number" binding:"required"`
 	DateOfBirth string `json:"date_of_birth,omitempty"` // YYYY-MM-DD
 	ParentName  string `json:"parent_name,omitempty"`
+	ParentEmail string `json:"parent_email,omitempty" binding:"omitempty,email"`
 	ParentPhone string `json:"parent_phone,omitempty"`
 	Address     string `json:"address,omitempty"`
 }
@@ -157,7 +223,7 @@ type CreateStudentRequest struct {
 // CreateTeacherRequest for creating teacher with profile
 type CreateTeacherRequest struct {
 	Email          string   `json:"email" binding:"required,email"`
-	Password       string   `json:"password" binding:"required,min=6"`
+	Password       string   `json:"password" binding:"required"`
 	FullName       string   `json:"full_name" binding:"required"`
 	Phone          string   `json:"phone,omitempty"`
 	EmployeeID     string   `json:"employee_id" binding:"required"`
@@ -206,3 +272,235 @@ type UserListItem struct {
 	CreatedAt time.Time  `json:"created_at"`
 	LastLogin *time.Time `json:"last_login,omitempty"`
 }
+
+// ClearLockoutRequest identifies a lockout to clear
+type ClearLockoutRequest struct {
+	Kind string `form:"kind" binding:"required,oneof=account ip"`
+	Key  string `form:"key" binding:"required"`
+}
+
+// Invitation is an onboarding invitation issued by an admin
+type Invitation struct {
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	FullName        *string    `json:"full_name,omitempty"`
+	StudentID       *uuid.UUID `json:"student_id,omitempty"`
+	Relationship    *string    `json:"relationship,omitempty"`
+	ClassID         *uuid.UUID `json:"class_id,omitempty"`
+	AdmissionNumber *string    `json:"admission_number,omitempty"`
+	RollNumber      *string    `json:"roll_number,omitempty"`
+	Status          string     `json:"status"` // pending, accepted, revoked, expired
+	InvitedBy       *uuid.UUID `json:"invited_by,omitempty"`
+	InvitedByName   *string    `json:"invited_by_name,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	SentAt          *time.Time `json:"sent_at,omitempty"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID  *uuid.UUID `json:"accepted_user_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// CreateInvitationRequest invites someone to join the school with a role.
+// Parent invitations may name a child to link; student invitations carry
+// the details needed for the student profile.
+type CreateInvitationRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Role     string `json:"role" binding:"required,oneof=admin teacher student staff parent"`
+	FullName string `json:"full_name,omitempty" binding:"omitempty,max=255"`
+
+	StudentID    *uuid.UUID `json:"student_id,omitempty"`
+	Relationship string     `json:"relationship,omitempty" binding:"omitempty,oneof=mother father guardian other"`
+
+	ClassID         *uuid.UUID `json:"class_id,omitempty"`
+	AdmissionNumber string     `json:"admission_number,omitempty" binding:"omitempty,max=50"`
+	RollNumber      string     `json:"roll_number,omitempty" binding:"omitempty,max=50"`
+	DateOfBirth     string     `json:"date_of_birth,omitempty" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ParentSignup is a parent self-registration awaiting review
+type ParentSignup struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Email        string     `json:"email"`
+	FullName     string     `json:"full_name"`
+	Phone        *string    `json:"phone,omitempty"`
+	ChildDetails *string    `json:"child_details,omitempty"`
+	Status       string     `json:"status"` // pending, approved, rejected
+	ReviewedBy   *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ImportRowError is a problem with one row of an import file.
+// Row is the spreadsheet row number, counting the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportCredential is the login of an imported user, returned once for printing
+type ImportCredential struct {
+	Row        int       `json:"row"`
+	UserID     uuid.UUID `json:"user_id"`
+	FullName   string    `json:"full_name"`
+	Email      string    `json:"email"`
+	Password   string    `json:"password"`
+	Identifier string    `json:"identifier"` // admission number or employee ID
+	ClassName  string    `json:"class_name,omitempty"`
+}
+
+// ImportReport is the outcome of validating, and unless DryRun committing, an import file
+type ImportReport struct {
+	Kind        string             `json:"kind"` // students, teachers
+	DryRun      bool               `json:"dry_run"`
+	Committed   bool               `json:"committed"`
+	TotalRows   int                `json:"total_rows"`
+	ValidRows   int                `json:"valid_rows"`
+	Errors      []ImportRowError   `json:"errors"`
+	Credentials []ImportCredential `json:"credentials,omitempty"`
+}
+
+// UserExportFilter selects the users to export; role filters like the user list
+type UserExportFilter struct {
+	Role   string `form:"role" binding:"omitempty,oneof=admin teacher student staff parent service"`
+	Status string `form:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// StudentExportFilter selects the students to export, all by default
+type StudentExportFilter struct {
+	ClassID      string `form:"class_id" binding:"omitempty,uuid"`
+	AcademicYear string `form:"academic_year" binding:"omitempty,max=20"`
+}
+
+// FeeDueExportFilter selects the outstanding fees to export
+type FeeDueExportFilter struct {
+	ClassID      string `form:"class_id" binding:"omitempty,uuid"`
+	AcademicYear string `form:"academic_year" binding:"omitempty,max=20"`
+	Status       string `form:"status" binding:"omitempty,oneof=pending partial overdue"`
+	DueBefore    string `form:"due_before" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// PaymentExportFilter selects payments made between two dates, inclusive
+type PaymentExportFilter struct {
+	From   string `form:"from" binding:"required,datetime=2006-01-02"`
+	To     string `form:"to" binding:"required,datetime=2006-01-02"`
+	Method string `form:"payment_method" binding:"omitempty,oneof=cash card upi bank_transfer cheque online"`
+}
+
+// AttendanceExportFilter selects a class's attendance register for one month
+type AttendanceExportFilter struct {
+	ClassID string `form:"class_id" binding:"required,uuid"`
+	Month   string `form:"month" binding:"required,datetime=2006-01"`
+}
+
+// TransferStudentRequest moves a student to another class of the same academic
+// year. EffectiveDate defaults to today and may be in the past.
+type TransferStudentRequest struct {
+	ClassID       string `json:"class_id" binding:"required,uuid"`
+	EffectiveDate string `json:"effective_date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// Enrollment is a period a student spent in a class. EndDate is the first day
+// in the next class, nil for the current enrollment.
+type Enrollment struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ClassID      uuid.UUID  `json:"class_id" db:"class_id"`
+	ClassName    string     `json:"class_name"`
+	Section      *string    `json:"section,omitempty"`
+	AcademicYear string     `json:"academic_year"`
+	StartDate    time.Time  `json:"start_date" db:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty" db:"end_date"`
+	Reason       string     `json:"reason" db:"reason"` // admission, transfer, rollover, class_change, backfill
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// StudentTransfer is the outcome of a transfer
+type StudentTransfer struct {
+	StudentID       uuid.UUID    `json:"student_id"`
+	FromClassID     uuid.UUID    `json:"from_class_id"`
+	ToClassID       uuid.UUID    `json:"to_class_id"`
+	EffectiveDate   string       `json:"effective_date"`
+	AttendanceMoved int          `json:"attendance_moved"` // marks from the effective date on, moved to the new class
+	Enrollments     []Enrollment `json:"enrollments"`
+}
+
+// enrollmentClass is a class a student is in or moves to
+type enrollmentClass struct {
+	ID           uuid.UUID
+	Section      *string
+	AcademicYear string
+}
+
+// studentPlacement is a student's class and the day their current enrollment started
+type studentPlacement struct {
+	Status        string
+	Class         *enrollmentClass
+	EnrolledSince *time.Time
+}
+
+// FeeGenerationRequest selects the fee structures to generate instalments for:
+// every active structure of the academic year, or one of them
+type FeeGenerationRequest struct {
+	AcademicYear string `json:"academic_year" binding:"omitempty,max=20"` // defaults to the current year
+	StructureID  string `json:"structure_id" binding:"omitempty,uuid"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+// FeeGenerationReport is what a generation run created, or on a dry run would create
+type FeeGenerationReport struct {
+	AcademicYear string                   `json:"academic_year"`
+	DryRun       bool                     `json:"dry_run"`
+	Structures   []FeeStructureGeneration `json:"structures"`
+	Created      int                      `json:"instalments_created"`
+	Existing     int                      `json:"instalments_existing"`
+	Amount       float64                  `json:"amount_created"`
+}
+
+// FeeStructureGeneration is the part of a generation run for one structure
+type FeeStructureGeneration struct {
+	StructureID uuid.UUID `json:"structure_id"`
+	Name        string    `json:"name"`
+	Students    int       `json:"students"`
+	Created     int       `json:"instalments_created"`
+	Existing    int       `json:"instalments_existing"`
+	Amount      float64   `json:"amount_created"`
+}
+
+// FeeOptInRequest opts students into an optional fee item
+type FeeOptInRequest struct {
+	StudentIDs []string `json:"student_ids" binding:"required,min=1,max=1000,dive,uuid"`
+}
+
+// FeeOptIn is a student charged for an optional fee item
+type FeeOptIn struct {
+	StudentID       uuid.UUID  `json:"student_id"`
+	FullName        string     `json:"full_name"`
+	AdmissionNumber string     `json:"admission_number"`
+	CreatedBy       *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// feeStudent is a student a fee structure applies to
+type feeStudent struct {
+	ID            uuid.UUID
+	AdmissionDate time.Time
+}
+
+// feeInstalment is one generated student_fees row
+type feeInstalment struct {
+	StudentID   uuid.UUID
+	FeeItemID   uuid.UUID
+	Amount      float64
+	DueDate     time.Time
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+// feeInstalmentKey identifies an instalment; a student is charged once per key
+type feeInstalmentKey struct {
+	StudentID   uuid.UUID
+	FeeItemID   uuid.UUID
+	PeriodStart string
+}
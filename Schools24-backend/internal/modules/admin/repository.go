@@ -9,7 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/schools24/backend/internal/shared/database"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Repository handles database operations for admin module
@@ -25,6 +25,7 @@ func NewRepository(db *database.PostgresDB) *Repository {
 // GetDashboardStats retrieves admin dashboard statistics
 func (r *Repository) GetDashboardStats(ctx context.Context) (*AdminDashboard, error) {
 	dashboard := &AdminDashboard{}
+	schoolID := tenant.SchoolID(ctx)
 
 	// Total users by role
 	query := `
@@ -32,16 +33,16 @@ func (r *Repository) GetDashboardStats(ctx context.Context) (*AdminDashboard, er
 			COUNT(*) as total,
 			COUNT(*) FILTER (WHERE role = 'student') as students,
 			COUNT(*) FILTER (WHERE role = 'teacher') as teachers
-		FROM users WHERE is_active = true
+		FROM users WHERE is_active = true AND ($1::uuid IS NULL OR school_id = $1)
 	`
-	err := r.db.QueryRow(ctx, query).Scan(&dashboard.TotalUsers, &dashboard.TotalStudents, &dashboard.TotalTeachers)
+	err := r.db.QueryRow(ctx, query, schoolID).Scan(&dashboard.TotalUsers, &dashboard.TotalStudents, &dashboard.TotalTeachers)
 	if err != nil {
 		return nil, err
 	}
 
 	// Total classes
-	query = `SELECT COUNT(*) FROM classes WHERE is_active = true`
-	err = r.db.QueryRow(ctx, query).Scan(&dashboard.TotalClasses)
+	query = `SELECT COUNT(*) FROM classes WHERE is_active = true AND ($1::uuid IS NULL OR school_id = $1)`
+	err = r.db.QueryRow(ctx, query, schoolID).Scan(&dashboard.TotalClasses)
 	if err != nil {
 		dashboard.TotalClasses = 0
 	}
@@ -55,8 +56,9 @@ func (r *Repository) GetDashboardStats(ctx context.Context) (*AdminDashboard, er
 			COALESCE(SUM(amount - paid_amount - waiver_amount) FILTER (WHERE status = 'pending'), 0) as pending,
 			COALESCE(SUM(amount - paid_amount - waiver_amount) FILTER (WHERE status = 'overdue'), 0) as overdue
 		FROM student_fees
+		WHERE ($1::uuid IS NULL OR school_id = $1)
 	`
-	err = r.db.QueryRow(ctx, query).Scan(
+	err = r.db.QueryRow(ctx, query, schoolID).Scan(
 		&dashboard.FeeCollection.TotalDue,
 		&dashboard.FeeCollection.TotalCollected,
 		&dashboard.FeeCollection.TotalPending,
@@ -76,10 +78,10 @@ func (r *Repository) GetDashboardStats(ctx context.Context) (*AdminDashboard, er
 
 // GetAllUsers retrieves all users with filters
 func (r *Repository) GetAllUsers(ctx context.Context, role string, limit, offset int) ([]UserListItem, int, error) {
-	var args []interface{}
-	argNum := 1
+	args := []interface{}{tenant.SchoolID(ctx)}
+	argNum := 2
 
-	whereClause := "WHERE 1=1"
+	whereClause := "WHERE ($1::uuid IS NULL OR school_id = $1)"
 	if role != "" {
 		whereClause += fmt.Sprintf(" AND role = $%d", argNum)
 		args = append(args, role)
@@ -126,10 +128,10 @@ func (r *Repository) GetAllUsers(ctx context.Context, role string, limit, offset
 func (r *Repository) GetUserByID(ctx context.Context, userID uuid.UUID) (*UserListItem, error) {
 	query := `
 		SELECT id, email, full_name, role, phone, is_active, created_at, last_login_at
-		FROM users WHERE id = $1
+		FROM users WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)
 	`
 	var u UserListItem
-	err := r.db.QueryRow(ctx, query, userID).Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.Phone, &u.IsActive, &u.CreatedAt, &u.LastLogin)
+	err := r.db.QueryRow(ctx, query, userID, tenant.SchoolID(ctx)).Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.Phone, &u.IsActive, &u.CreatedAt, &u.LastLogin)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -139,56 +141,150 @@ func (r *Repository) GetUserByID(ctx context.Context, userID uuid.UUID) (*UserLi
 	return &u, nil
 }
 
-// CreateUser creates a new user
-func (r *Repository) CreateUser(ctx context.Context, req *CreateUserRequest) (uuid.UUID, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+// CreateUser creates a new user in the request's school with an already hashed password
+func (r *Repository) CreateUser(ctx context.Context, req *CreateUserRequest, passwordHash string) (uuid.UUID, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
 	query := `
-		INSERT INTO users (email, password_hash, full_name, role, phone, is_active)
-		VALUES ($1, $2, $3, $4, $5, true)
+		INSERT INTO users (email, password_hash, full_name, role, phone, is_active, school_id)
+		VALUES ($1, $2, $3, $4, $5, true, $6)
 		RETURNING id
 	`
 
 	var id uuid.UUID
-	err = r.db.QueryRow(ctx, query, req.Email, string(hashedPassword), req.FullName, req.Role, req.Phone).Scan(&id)
+	err = r.db.QueryRow(ctx, query, req.Email, passwordHash, req.FullName, req.Role, req.Phone, schoolID).Scan(&id)
 	return id, err
 }
 
-// UpdateUser updates a user
+// UpdateUser updates a user; a changed email must be verified again
 func (r *Repository) UpdateUser(ctx context.Context, userID uuid.UUID, req *UpdateUserRequest) error {
 	query := `
 		UPDATE users SET
+			email_verified = CASE WHEN NULLIF($2, '') IS NOT NULL AND $2 <> email THEN false ELSE email_verified END,
 			email = COALESCE(NULLIF($2, ''), email),
 			full_name = COALESCE(NULLIF($3, ''), full_name),
 			role = COALESCE(NULLIF($4, ''), role),
 			phone = COALESCE(NULLIF($5, ''), phone),
 			is_active = COALESCE($6, is_active),
 			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		WHERE id = $1 AND ($7::uuid IS NULL OR school_id = $7)
 	`
-	return r.db.Exec(ctx, query, userID, req.Email, req.FullName, req.Role, req.Phone, req.IsActive)
+	return r.db.Exec(ctx, query, userID, req.Email, req.FullName, req.Role, req.Phone, req.IsActive, tenant.SchoolID(ctx))
+}
+
+// GetSystemRolePermissions returns the permissions of the built-in role matching a users.role value
+func (r *Repository) GetSystemRolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	query := `
+		SELECT rp.permission_key
+		FROM role_permissions rp
+		JOIN roles ro ON ro.id = rp.role_id
+		WHERE ro.is_system AND ro.school_id IS NULL AND ro.name = $1
+		ORDER BY rp.permission_key
+	`
+	rows, err := r.db.Query(ctx, query, roleName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, key)
+	}
+	return permissions, rows.Err()
 }
 
 // DeleteUser soft deletes a user
 func (r *Repository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
-	query := `UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	query := `UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)`
+	return r.db.Exec(ctx, query, userID, tenant.SchoolID(ctx))
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user and ends all their sessions
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		WITH ended AS (
+			UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL
+		)
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL
+	`
 	return r.db.Exec(ctx, query, userID)
 }
 
+// GetUserSessions retrieves a user's active sessions
+func (r *Repository) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error) {
+	query := `
+		SELECT s.id, COALESCE(s.device, ''), COALESCE(s.user_agent, ''), COALESCE(s.ip_address, ''),
+		       s.created_at, s.last_seen_at, s.expires_at
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP
+		  AND ($2::uuid IS NULL OR u.school_id = $2)
+		ORDER BY s.last_seen_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []UserSession{}
+	for rows.Next() {
+		var s UserSession
+		if err := rows.Scan(&s.ID, &s.Device, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeUserSession ends one active session of a user and revokes its refresh tokens.
+// Returns false if the user has no such active session.
+func (r *Repository) RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_sessions s SET revoked_at = CURRENT_TIMESTAMP
+		FROM users u
+		WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL
+		  AND u.id = s.user_id AND ($3::uuid IS NULL OR u.school_id = $3)
+	`, sessionID, userID, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`, sessionID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
 // CreateStudentWithProfile creates a user and student profile
-func (r *Repository) CreateStudentWithProfile(ctx context.Context, req *CreateStudentRequest) (uuid.UUID, error) {
+func (r *Repository) CreateStudentWithProfile(ctx context.Context, req *CreateStudentRequest, passwordHash string) (uuid.UUID, error) {
 	// Create user first
 	userReq := &CreateUserRequest{
 		Email:    req.Email,
-		Password: req.Password,
 		FullName: req.FullName,
 		Role:     "student",
 		Phone:    req.Phone,
 	}
-	userID, err := r.CreateUser(ctx, userReq)
+	userID, err := r.CreateUser(ctx, userReq, passwordHash)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -202,12 +298,12 @@ func (r *Repository) CreateStudentWithProfile(ctx context.Context, req *CreateSt
 	}
 
 	query := `
-		INSERT INTO students (user_id, class_id, roll_number, date_of_birth, parent_name, parent_phone, address, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, true)
+		INSERT INTO students (user_id, class_id, roll_number, date_of_birth, parent_name, parent_email, parent_phone, address, is_active, school_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, true, (SELECT school_id FROM users WHERE id = $1))
 		RETURNING id
 	`
 	var studentID uuid.UUID
-	err = r.db.QueryRow(ctx, query, userID, classID, req.RollNumber, dob, req.ParentName, req.ParentPhone, req.Address).Scan(&studentID)
+	err = r.db.QueryRow(ctx, query, userID, classID, req.RollNumber, dob, req.ParentName, req.ParentEmail, req.ParentPhone, req.Address).Scan(&studentID)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -215,25 +311,39 @@ func (r *Repository) CreateStudentWithProfile(ctx context.Context, req *CreateSt
 	return userID, nil
 }
 
+// LinkParentByEmail links a student to the parent account whose email matches
+// the student's parent email, if one exists in the same school
+func (r *Repository) LinkParentByEmail(ctx context.Context, studentUserID uuid.UUID) error {
+	query := `
+		INSERT INTO parent_students (parent_user_id, student_id, relationship, school_id)
+		SELECT p.id, s.id, 'guardian', s.school_id
+		FROM students s
+		JOIN users p ON LOWER(p.email) = LOWER(s.parent_email)
+		  AND p.role = 'parent' AND p.school_id = s.school_id
+		WHERE s.user_id = $1
+		ON CONFLICT (parent_user_id, student_id) DO NOTHING
+	`
+	return r.db.Exec(ctx, query, studentUserID)
+}
+
 // CreateTeacherWithProfile creates a user and teacher profile
-func (r *Repository) CreateTeacherWithProfile(ctx context.Context, req *CreateTeacherRequest) (uuid.UUID, error) {
+func (r *Repository) CreateTeacherWithProfile(ctx context.Context, req *CreateTeacherRequest, passwordHash string) (uuid.UUID, error) {
 	// Create user first
 	userReq := &CreateUserRequest{
 		Email:    req.Email,
-		Password: req.Password,
 		FullName: req.FullName,
 		Role:     "teacher",
 		Phone:    req.Phone,
 	}
-	userID, err := r.CreateUser(ctx, userReq)
+	userID, err := r.CreateUser(ctx, userReq, passwordHash)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
 	// Create teacher profile
 	query := `
-		INSERT INTO teachers (user_id, employee_id, department, designation, qualifications, subjects_taught, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, true)
+		INSERT INTO teachers (user_id, employee_id, department, designation, qualifications, subjects_taught, is_active, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, true, (SELECT school_id FROM users WHERE id = $1))
 		RETURNING id
 	`
 	var teacherID uuid.UUID
@@ -251,10 +361,11 @@ func (r *Repository) GetFeeStructures(ctx context.Context, academicYear string)
 		SELECT id, name, description, applicable_grades, academic_year, is_active, created_at, updated_at
 		FROM fee_structures
 		WHERE ($1 = '' OR academic_year = $1)
+		  AND ($2::uuid IS NULL OR school_id = $2)
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, query, academicYear)
+	rows, err := r.db.Query(ctx, query, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -275,14 +386,19 @@ func (r *Repository) GetFeeStructures(ctx context.Context, academicYear string)
 
 // CreateFeeStructure creates a fee structure with items
 func (r *Repository) CreateFeeStructure(ctx context.Context, req *CreateFeeStructureRequest) (uuid.UUID, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	// Create fee structure
 	query := `
-		INSERT INTO fee_structures (name, description, applicable_grades, academic_year, is_active)
-		VALUES ($1, $2, $3, $4, true)
+		INSERT INTO fee_structures (name, description, applicable_grades, academic_year, is_active, school_id)
+		VALUES ($1, $2, $3, $4, true, $5)
 		RETURNING id
 	`
 	var structureID uuid.UUID
-	err := r.db.QueryRow(ctx, query, req.Name, req.Description, req.ApplicableGrades, req.AcademicYear).Scan(&structureID)
+	err = r.db.QueryRow(ctx, query, req.Name, req.Description, req.ApplicableGrades, req.AcademicYear, schoolID).Scan(&structureID)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -299,10 +415,10 @@ func (r *Repository) CreateFeeStructure(ctx context.Context, req *CreateFeeStruc
 		}
 
 		itemQuery := `
-			INSERT INTO fee_items (fee_structure_id, name, amount, frequency, is_optional, due_day)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO fee_items (fee_structure_id, name, amount, frequency, is_optional, due_day, school_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 		`
-		if err := r.db.Exec(ctx, itemQuery, structureID, item.Name, item.Amount, frequency, item.IsOptional, dueDay); err != nil {
+		if err := r.db.Exec(ctx, itemQuery, structureID, item.Name, item.Amount, frequency, item.IsOptional, dueDay, schoolID); err != nil {
 			return uuid.Nil, err
 		}
 	}
@@ -323,15 +439,16 @@ func (r *Repository) RecordPayment(ctx context.Context, collectorID uuid.UUID, r
 	receiptNumber := fmt.Sprintf("RCP-%s-%d", time.Now().Format("20060102"), time.Now().UnixNano()%100000)
 
 	query := `
-		INSERT INTO payments (student_id, student_fee_id, amount, payment_method, transaction_id, receipt_number, status, notes, collected_by)
-		VALUES ($1, $2, $3, $4, $5, $6, 'completed', $7, $8)
+		INSERT INTO payments (student_id, student_fee_id, amount, payment_method, transaction_id, receipt_number, status, notes, collected_by, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, 'completed', $7, $8,
+		        (SELECT school_id FROM students WHERE id = $1 AND ($9::uuid IS NULL OR school_id = $9)))
 		RETURNING id
 	`
 
 	var paymentID uuid.UUID
 	err := r.db.QueryRow(ctx, query,
 		studentID, studentFeeID, req.Amount, req.PaymentMethod, req.TransactionID,
-		receiptNumber, req.Notes, collectorID,
+		receiptNumber, req.Notes, collectorID, tenant.SchoolID(ctx),
 	).Scan(&paymentID)
 	if err != nil {
 		return uuid.Nil, "", err
@@ -348,9 +465,9 @@ func (r *Repository) RecordPayment(ctx context.Context, collectorID uuid.UUID, r
 			        ELSE status
 			    END,
 			    updated_at = CURRENT_TIMESTAMP
-			WHERE id = $1
+			WHERE id = $1 AND ($3::uuid IS NULL OR school_id = $3)
 		`
-		r.db.Exec(ctx, updateQuery, studentFeeID, req.Amount)
+		r.db.Exec(ctx, updateQuery, studentFeeID, req.Amount, tenant.SchoolID(ctx))
 	}
 
 	return paymentID, receiptNumber, nil
@@ -368,11 +485,12 @@ func (r *Repository) GetRecentPayments(ctx context.Context, limit int) ([]Paymen
 		JOIN students s ON p.student_id = s.id
 		JOIN users u ON s.user_id = u.id
 		LEFT JOIN users c ON p.collected_by = c.id
+		WHERE ($2::uuid IS NULL OR p.school_id = $2)
 		ORDER BY p.payment_date DESC
 		LIMIT $1
 	`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, limit, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -396,13 +514,60 @@ func (r *Repository) GetRecentPayments(ctx context.Context, limit int) ([]Paymen
 	return payments, nil
 }
 
+// GetActiveLockouts lists accounts and IPs that are currently blocked.
+// School-scoped callers only see accounts of their own school.
+func (r *Repository) GetActiveLockouts(ctx context.Context) ([]LoginLockout, error) {
+	query := `
+		SELECT lf.kind, lf.key, lf.failures, lf.last_failed_at, lf.locked_until, u.id, u.full_name
+		FROM login_failures lf
+		LEFT JOIN users u ON lf.kind = 'account' AND LOWER(u.email) = lf.key
+		WHERE lf.locked_until > CURRENT_TIMESTAMP
+		  AND ($1::uuid IS NULL OR u.school_id = $1)
+		ORDER BY lf.locked_until DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lockouts []LoginLockout
+	for rows.Next() {
+		var l LoginLockout
+		if err := rows.Scan(&l.Kind, &l.Key, &l.Failures, &l.LastFailedAt, &l.LockedUntil, &l.UserID, &l.UserName); err != nil {
+			return nil, err
+		}
+		lockouts = append(lockouts, l)
+	}
+
+	return lockouts, nil
+}
+
+// ClearLoginFailures removes a lockout and its failure count.
+// School-scoped callers can only clear accounts of their own school.
+func (r *Repository) ClearLoginFailures(ctx context.Context, kind, key string) (bool, error) {
+	query := `
+		DELETE FROM login_failures lf
+		WHERE lf.kind = $1 AND lf.key = $2
+		  AND ($3::uuid IS NULL OR (lf.kind = 'account' AND EXISTS (
+				SELECT 1 FROM users u WHERE LOWER(u.email) = lf.key AND u.school_id = $3
+		  )))
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, kind, key, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // LogAudit creates an audit log entry
 func (r *Repository) LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error {
 	query := `
-		INSERT INTO audit_logs (user_id, action, entity_type, entity_id, old_values, new_values, ip_address, user_agent)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO audit_logs (user_id, action, entity_type, entity_id, old_values, new_values, ip_address, user_agent, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	return r.db.Exec(ctx, query, userID, action, entityType, entityID, oldValues, newValues, ipAddress, userAgent)
+	return r.db.Exec(ctx, query, userID, action, entityType, entityID, oldValues, newValues, ipAddress, userAgent, tenant.SchoolID(ctx))
 }
 
 // GetRecentAuditLogs retrieves recent audit logs
@@ -412,11 +577,12 @@ func (r *Repository) GetRecentAuditLogs(ctx context.Context, limit int) ([]Audit
 		       COALESCE(u.full_name, 'System') as user_name
 		FROM audit_logs a
 		LEFT JOIN users u ON a.user_id = u.id
+		WHERE ($2::uuid IS NULL OR a.school_id = $2)
 		ORDER BY a.created_at DESC
 		LIMIT $1
 	`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, limit, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -434,3 +600,1030 @@ func (r *Repository) GetRecentAuditLogs(ctx context.Context, limit int) ([]Audit
 
 	return logs, nil
 }
+
+// auditLogQuery holds the parsed conditions of an audit log search
+type auditLogQuery struct {
+	userID, entityID     *uuid.UUID
+	entityType           string
+	action, actionPrefix string
+	from, to             *time.Time
+	afterTime            *time.Time
+	afterID              *uuid.UUID
+	limit                int
+}
+
+// GetAuditLogs retrieves audit logs matching q, newest first, starting after
+// the keyset cursor if one is given
+func (r *Repository) GetAuditLogs(ctx context.Context, q *auditLogQuery) ([]AuditLog, error) {
+	query := `
+		SELECT a.id, a.user_id, a.action, a.entity_type, a.entity_id, a.old_values, a.new_values,
+		       a.ip_address, a.user_agent, a.created_at, a.seq, a.prev_hash, a.hash,
+		       COALESCE(u.full_name, 'System') as user_name
+		FROM audit_logs a
+		LEFT JOIN users u ON a.user_id = u.id
+		WHERE ($1::uuid IS NULL OR a.school_id = $1)
+		  AND ($2::uuid IS NULL OR a.user_id = $2)
+		  AND ($3 = '' OR a.entity_type = $3)
+		  AND ($4::uuid IS NULL OR a.entity_id = $4)
+		  AND ($5 = '' OR a.action = $5)
+		  AND ($6 = '' OR starts_with(a.action, $6))
+		  AND ($7::timestamp IS NULL OR a.created_at >= $7)
+		  AND ($8::timestamp IS NULL OR a.created_at < $8)
+		  AND ($9::timestamp IS NULL OR (a.created_at, a.id) < ($9, $10::uuid))
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $11
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), q.userID, q.entityType, q.entityID,
+		q.action, q.actionPrefix, q.from, q.to, q.afterTime, q.afterID, q.limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []AuditLog{}
+	for rows.Next() {
+		var l AuditLog
+		err := rows.Scan(&l.ID, &l.UserID, &l.Action, &l.EntityType, &l.EntityID, &l.OldValues, &l.NewValues,
+			&l.IPAddress, &l.UserAgent, &l.CreatedAt, &l.Seq, &l.PrevHash, &l.Hash, &l.UserName)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// auditChainEntry is an audit log entry's chain fields and the hash its content should have
+type auditChainEntry struct {
+	ID           uuid.UUID
+	Seq          *int64
+	PrevHash     *string
+	Hash         *string
+	ExpectedHash string
+}
+
+// StreamAuditChain passes the entries of a school's audit chain (the platform
+// chain for a nil school) to fn in chain order, recomputing each entry's hash
+func (r *Repository) StreamAuditChain(ctx context.Context, schoolID *uuid.UUID, fn func(e *auditChainEntry) error) error {
+	query := `
+		SELECT a.id, a.seq, a.prev_hash, a.hash, COALESCE(audit_log_hash(a.prev_hash, a), '')
+		FROM audit_logs a
+		WHERE COALESCE(a.school_id, '00000000-0000-0000-0000-000000000000') = COALESCE($1::uuid, '00000000-0000-0000-0000-000000000000')
+		ORDER BY a.seq NULLS FIRST, a.created_at
+	`
+	rows, err := r.db.Query(ctx, query, schoolID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e auditChainEntry
+		if err := rows.Scan(&e.ID, &e.Seq, &e.PrevHash, &e.Hash, &e.ExpectedHash); err != nil {
+			return err
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// invitationSelect reads invitations with their derived status
+const invitationSelect = `
+	SELECT i.id, i.email, i.role, i.full_name, i.student_id, i.relationship, i.class_id,
+	       i.admission_number, i.roll_number, i.status, i.invited_by, u.full_name,
+	       i.expires_at, i.sent_at, i.accepted_at, i.accepted_user_id, i.created_at
+	FROM (
+		SELECT *, CASE
+			WHEN accepted_at IS NOT NULL THEN 'accepted'
+			WHEN revoked_at IS NOT NULL THEN 'revoked'
+			WHEN expires_at <= CURRENT_TIMESTAMP THEN 'expired'
+			ELSE 'pending'
+		END AS status
+		FROM invitations
+	) i
+	LEFT JOIN users u ON u.id = i.invited_by
+`
+
+func scanInvitation(row pgx.Row) (*Invitation, error) {
+	var inv Invitation
+	err := row.Scan(
+		&inv.ID, &inv.Email, &inv.Role, &inv.FullName, &inv.StudentID, &inv.Relationship, &inv.ClassID,
+		&inv.AdmissionNumber, &inv.RollNumber, &inv.Status, &inv.InvitedBy, &inv.InvitedByName,
+		&inv.ExpiresAt, &inv.SentAt, &inv.AcceptedAt, &inv.AcceptedUserID, &inv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// CreateInvitation stores an invitation in the request's school. Earlier pending
+// invitations for the same email are revoked so only the newest link works.
+func (r *Repository) CreateInvitation(ctx context.Context, req *CreateInvitationRequest, invitedBy uuid.UUID, expiresAt time.Time) (uuid.UUID, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE school_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND revoked_at IS NULL
+	`, schoolID, req.Email); err != nil {
+		return uuid.Nil, err
+	}
+
+	var dob *time.Time
+	if req.DateOfBirth != "" {
+		t, err := time.Parse("2006-01-02", req.DateOfBirth)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		dob = &t
+	}
+
+	query := `
+		INSERT INTO invitations (school_id, email, role, full_name, student_id, relationship, class_id,
+		                         admission_number, roll_number, date_of_birth, invited_by, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12)
+		RETURNING id
+	`
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, query,
+		schoolID, req.Email, req.Role, req.FullName, req.StudentID, req.Relationship, req.ClassID,
+		req.AdmissionNumber, req.RollNumber, dob, invitedBy, expiresAt,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// GetInvitations lists invitations, newest first, optionally filtered by status
+func (r *Repository) GetInvitations(ctx context.Context, status string, limit int) ([]Invitation, error) {
+	query := invitationSelect + `
+		WHERE ($1::uuid IS NULL OR i.school_id = $1) AND ($2 = '' OR i.status = $2)
+		ORDER BY i.created_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+// GetInvitation retrieves an invitation by ID
+func (r *Repository) GetInvitation(ctx context.Context, id uuid.UUID) (*Invitation, error) {
+	query := invitationSelect + `WHERE i.id = $1 AND ($2::uuid IS NULL OR i.school_id = $2)`
+	inv, err := scanInvitation(r.db.QueryRow(ctx, query, id, tenant.SchoolID(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+// RevokeInvitation cancels a pending invitation. Returns false if it was not pending.
+func (r *Repository) RevokeInvitation(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		  AND ($2::uuid IS NULL OR school_id = $2)
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, id, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EmailExists checks if a user email is taken
+func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))`, email).Scan(&exists)
+	return exists, err
+}
+
+// StudentExists checks that a student belongs to the request's school
+func (r *Repository) StudentExists(ctx context.Context, studentID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM students WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2))`,
+		studentID, tenant.SchoolID(ctx),
+	).Scan(&exists)
+	return exists, err
+}
+
+// ClassExists checks that a class belongs to the request's school
+func (r *Repository) ClassExists(ctx context.Context, classID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM classes WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2))`,
+		classID, tenant.SchoolID(ctx),
+	).Scan(&exists)
+	return exists, err
+}
+
+// AdmissionNumberExists checks if an admission number is taken in the request's school,
+// by a student or by a pending invitation
+func (r *Repository) AdmissionNumberExists(ctx context.Context, admissionNumber string) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM students WHERE admission_number = $1 AND ($2::uuid IS NULL OR school_id = $2))
+		    OR EXISTS(SELECT 1 FROM invitations
+		              WHERE admission_number = $1 AND ($2::uuid IS NULL OR school_id = $2)
+		                AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP)
+	`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, admissionNumber, tenant.SchoolID(ctx)).Scan(&exists)
+	return exists, err
+}
+
+// GetParentSignups lists parent self-registrations, oldest first so the queue is worked in order
+func (r *Repository) GetParentSignups(ctx context.Context, status string) ([]ParentSignup, error) {
+	query := `
+		SELECT ps.id, ps.user_id, u.email, u.full_name, u.phone, ps.child_details, ps.status,
+		       ps.reviewed_by, ps.reviewed_at, ps.created_at
+		FROM parent_signups ps
+		JOIN users u ON u.id = ps.user_id
+		WHERE ($1::uuid IS NULL OR ps.school_id = $1) AND ($2 = '' OR ps.status = $2)
+		ORDER BY ps.created_at
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signups := []ParentSignup{}
+	for rows.Next() {
+		var s ParentSignup
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Email, &s.FullName, &s.Phone, &s.ChildDetails, &s.Status,
+			&s.ReviewedBy, &s.ReviewedAt, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		signups = append(signups, s)
+	}
+	return signups, rows.Err()
+}
+
+// ReviewParentSignup approves or rejects a pending sign-up. Approval activates the
+// parent account. Returns the parent's user ID, or uuid.Nil if no such sign-up is pending.
+func (r *Repository) ReviewParentSignup(ctx context.Context, signupID, reviewerID uuid.UUID, approve bool) (uuid.UUID, error) {
+	status := "rejected"
+	if approve {
+		status = "approved"
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		UPDATE parent_signups SET status = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending' AND ($4::uuid IS NULL OR school_id = $4)
+		RETURNING user_id
+	`, signupID, status, reviewerID, tenant.SchoolID(ctx)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, err
+	}
+
+	if approve {
+		if _, err := tx.Exec(ctx, `UPDATE users SET is_active = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, userID); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return userID, tx.Commit(ctx)
+}
+
+// GetImportClasses lists the classes of a school for resolving import rows
+func (r *Repository) GetImportClasses(ctx context.Context, schoolID uuid.UUID) ([]importClass, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, name, grade, COALESCE(section, ''), academic_year
+		FROM classes WHERE school_id = $1
+	`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := []importClass{}
+	for rows.Next() {
+		var c importClass
+		if err := rows.Scan(&c.ID, &c.Name, &c.Grade, &c.Section, &c.AcademicYear); err != nil {
+			return nil, err
+		}
+		classes = append(classes, c)
+	}
+	return classes, rows.Err()
+}
+
+// GetTakenEmails returns which of the given lower-cased emails belong to existing users
+func (r *Repository) GetTakenEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	return r.takenValues(ctx, `SELECT LOWER(email) FROM users WHERE LOWER(email) = ANY($1)`, emails)
+}
+
+// GetTakenAdmissionNumbers returns which admission numbers are used in a school,
+// by a student or by a pending invitation
+func (r *Repository) GetTakenAdmissionNumbers(ctx context.Context, schoolID uuid.UUID, numbers []string) (map[string]bool, error) {
+	query := `
+		SELECT admission_number FROM students WHERE school_id = $2 AND admission_number = ANY($1)
+		UNION
+		SELECT admission_number FROM invitations
+		WHERE school_id = $2 AND admission_number = ANY($1)
+		  AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`
+	return r.takenValues(ctx, query, numbers, schoolID)
+}
+
+// GetTakenEmployeeIDs returns which employee IDs are used in a school
+func (r *Repository) GetTakenEmployeeIDs(ctx context.Context, schoolID uuid.UUID, ids []string) (map[string]bool, error) {
+	return r.takenValues(ctx, `SELECT employee_id FROM teachers WHERE school_id = $2 AND employee_id = ANY($1)`, ids, schoolID)
+}
+
+func (r *Repository) takenValues(ctx context.Context, query string, values []string, args ...interface{}) (map[string]bool, error) {
+	taken := map[string]bool{}
+	if len(values) == 0 {
+		return taken, nil
+	}
+
+	rows, err := r.db.Query(ctx, query, append([]interface{}{values}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		taken[v] = true
+	}
+	return taken, rows.Err()
+}
+
+// ImportStudents creates the users and profiles of imported students in one
+// transaction, setting each student's userID. Nothing is created if any insert fails.
+func (r *Repository) ImportStudents(ctx context.Context, schoolID uuid.UUID, students []importedStudent) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range students {
+		s := &students[i]
+		err := tx.QueryRow(ctx, `
+			INSERT INTO users (email, password_hash, full_name, role, phone, is_active, school_id)
+			VALUES ($1, $2, $3, 'student', NULLIF($4, ''), true, $5)
+			RETURNING id
+		`, s.email, s.passwordHash, s.fullName, s.phone, schoolID).Scan(&s.userID)
+		if err != nil {
+			return fmt.Errorf("failed to create user for row %d: %w", s.row, err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO students (user_id, admission_number, roll_number, class_id, section, date_of_birth, gender,
+			                      address, parent_name, parent_email, parent_phone, admission_date, academic_year, school_id)
+			VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''),
+			        NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, $13, $14)
+		`, s.userID, s.admissionNumber, s.rollNumber, s.class.ID, s.class.Section, s.dateOfBirth, s.gender,
+			s.address, s.parentName, s.parentEmail, s.parentPhone, s.admissionDate, s.class.AcademicYear, schoolID); err != nil {
+			return fmt.Errorf("failed to create student profile for row %d: %w", s.row, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ImportTeachers creates the users and profiles of imported teachers in one
+// transaction, setting each teacher's userID. Nothing is created if any insert fails.
+func (r *Repository) ImportTeachers(ctx context.Context, schoolID uuid.UUID, teachers []importedTeacher) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range teachers {
+		t := &teachers[i]
+		err := tx.QueryRow(ctx, `
+			INSERT INTO users (email, password_hash, full_name, role, phone, is_active, school_id)
+			VALUES ($1, $2, $3, 'teacher', NULLIF($4, ''), true, $5)
+			RETURNING id
+		`, t.email, t.passwordHash, t.fullName, t.phone, schoolID).Scan(&t.userID)
+		if err != nil {
+			return fmt.Errorf("failed to create user for row %d: %w", t.row, err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO teachers (user_id, employee_id, department, qualification, experience_years, subjects, hire_date, school_id)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8)
+		`, t.userID, t.employeeID, t.department, t.qualification, t.experienceYears, t.subjects, t.hireDate, schoolID); err != nil {
+			return fmt.Errorf("failed to create teacher profile for row %d: %w", t.row, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// exportRowFunc receives one exported row at a time
+type exportRowFunc func(cells ...interface{}) error
+
+// ExportUsers streams the request school's users, newest first
+func (r *Repository) ExportUsers(ctx context.Context, filter *UserExportFilter, fn exportRowFunc) error {
+	query := `
+		SELECT id, email, full_name, role, phone, is_active, COALESCE(email_verified, false), created_at, last_login_at
+		FROM users
+		WHERE ($1::uuid IS NULL OR school_id = $1)
+		  AND ($2 = '' OR role = $2)
+		  AND ($3 = '' OR is_active = ($3 = 'active'))
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), filter.Role, filter.Status)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id                uuid.UUID
+			email, name, role string
+			phone             *string
+			active, verified  bool
+			createdAt         time.Time
+			lastLogin         *time.Time
+		)
+		if err := rows.Scan(&id, &email, &name, &role, &phone, &active, &verified, &createdAt, &lastLogin); err != nil {
+			return err
+		}
+		if err := fn(id, email, name, role, phone, active, verified, createdAt, lastLogin); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportStudents streams students ordered by class and roll number
+func (r *Repository) ExportStudents(ctx context.Context, classID *uuid.UUID, academicYear string, fn exportRowFunc) error {
+	query := `
+		SELECT s.admission_number, COALESCE(s.roll_number, ''), u.full_name, u.email,
+		       COALESCE(c.name, ''), COALESCE(c.section, s.section, ''), COALESCE(s.academic_year, c.academic_year, ''),
+		       s.date_of_birth, COALESCE(s.gender, ''), s.parent_name, s.parent_email, s.parent_phone,
+		       s.address, s.admission_date, u.is_active
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN classes c ON c.id = s.class_id
+		WHERE ($1::uuid IS NULL OR s.school_id = $1)
+		  AND ($2::uuid IS NULL OR s.class_id = $2)
+		  AND ($3 = '' OR COALESCE(s.academic_year, c.academic_year) = $3)
+		ORDER BY c.grade NULLS LAST, c.name, s.roll_number, u.full_name
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), classID, academicYear)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			admissionNumber, rollNumber, name, email string
+			className, section, year, gender         string
+			dob, admissionDate                       time.Time
+			parentName, parentEmail, parentPhone     *string
+			address                                  *string
+			active                                   bool
+		)
+		if err := rows.Scan(&admissionNumber, &rollNumber, &name, &email, &className, &section, &year,
+			&dob, &gender, &parentName, &parentEmail, &parentPhone, &address, &admissionDate, &active); err != nil {
+			return err
+		}
+		if err := fn(admissionNumber, rollNumber, name, email, className, section, year, dob, gender,
+			parentName, parentEmail, parentPhone, address, admissionDate, active); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportFeeDues streams fees with an outstanding balance, earliest due first
+func (r *Repository) ExportFeeDues(ctx context.Context, classID *uuid.UUID, filter *FeeDueExportFilter, dueBefore *time.Time, fn exportRowFunc) error {
+	query := `
+		SELECT s.admission_number, u.full_name, COALESCE(c.name, ''), fi.name, sf.due_date,
+		       sf.amount, COALESCE(sf.paid_amount, 0), COALESCE(sf.waiver_amount, 0),
+		       sf.amount - COALESCE(sf.paid_amount, 0) - COALESCE(sf.waiver_amount, 0), sf.status, sf.academic_year
+		FROM student_fees sf
+		JOIN students s ON s.id = sf.student_id
+		JOIN users u ON u.id = s.user_id
+		JOIN fee_items fi ON fi.id = sf.fee_item_id
+		LEFT JOIN classes c ON c.id = s.class_id
+		WHERE ($1::uuid IS NULL OR sf.school_id = $1)
+		  AND sf.status IN ('pending', 'partial', 'overdue')
+		  AND sf.amount - COALESCE(sf.paid_amount, 0) - COALESCE(sf.waiver_amount, 0) > 0
+		  AND ($2::uuid IS NULL OR s.class_id = $2)
+		  AND ($3 = '' OR sf.academic_year = $3)
+		  AND ($4 = '' OR sf.status = $4)
+		  AND ($5::date IS NULL OR sf.due_date < $5)
+		ORDER BY sf.due_date, c.name, u.full_name
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), classID, filter.AcademicYear, filter.Status, dueBefore)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			admissionNumber, name, className, item, status, year string
+			dueDate                                              time.Time
+			amount, paid, waived, balance                        float64
+		)
+		if err := rows.Scan(&admissionNumber, &name, &className, &item, &dueDate,
+			&amount, &paid, &waived, &balance, &status, &year); err != nil {
+			return err
+		}
+		if err := fn(admissionNumber, name, className, item, dueDate, amount, paid, waived, balance, status, year); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportPayments streams payments made in [from, to), oldest first
+func (r *Repository) ExportPayments(ctx context.Context, from, to time.Time, method string, fn exportRowFunc) error {
+	query := `
+		SELECT p.receipt_number, p.payment_date, s.admission_number, u.full_name, p.amount, p.payment_method,
+		       p.transaction_id, p.status, cu.full_name, p.notes
+		FROM payments p
+		JOIN students s ON s.id = p.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN users cu ON cu.id = p.collected_by
+		WHERE ($1::uuid IS NULL OR p.school_id = $1)
+		  AND p.payment_date >= $2 AND p.payment_date < $3
+		  AND ($4 = '' OR p.payment_method = $4)
+		ORDER BY p.payment_date
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), from, to, method)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			receipt, transactionID, collector, notes *string
+			paymentDate                              time.Time
+			admissionNumber, name, paymentMethod     string
+			status                                   string
+			amount                                   float64
+		)
+		if err := rows.Scan(&receipt, &paymentDate, &admissionNumber, &name, &amount, &paymentMethod,
+			&transactionID, &status, &collector, &notes); err != nil {
+			return err
+		}
+		if err := fn(receipt, paymentDate, admissionNumber, name, amount, paymentMethod, transactionID, status, collector, notes); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// attendanceMark is one student's attendance on one day; Date is nil for
+// students with no marks in the period
+type attendanceMark struct {
+	StudentID       uuid.UUID
+	AdmissionNumber string
+	RollNumber      string
+	FullName        string
+	Date            *time.Time
+	Status          *string
+}
+
+// ExportAttendance streams a class's attendance marks in [from, to), grouped by
+// student. The roster is everyone enrolled in the class at some point during the
+// period plus anyone marked in it.
+func (r *Repository) ExportAttendance(ctx context.Context, classID uuid.UUID, from, to time.Time, fn func(attendanceMark) error) error {
+	query := `
+		WITH roster AS (
+			SELECT student_id FROM student_enrollments
+			WHERE class_id = $1 AND start_date < $3 AND (end_date IS NULL OR end_date > $2)
+			UNION
+			SELECT student_id FROM attendance WHERE class_id = $1 AND date >= $2 AND date < $3
+		)
+		SELECT s.id, s.admission_number, COALESCE(s.roll_number, ''), u.full_name, a.date, a.status
+		FROM roster
+		JOIN students s ON s.id = roster.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN attendance a ON a.student_id = s.id AND a.class_id = $1 AND a.date >= $2 AND a.date < $3
+		WHERE ($4::uuid IS NULL OR s.school_id = $4)
+		ORDER BY s.roll_number, u.full_name, s.id, a.date
+	`
+	rows, err := r.db.Query(ctx, query, classID, from, to, tenant.SchoolID(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m attendanceMark
+		if err := rows.Scan(&m.StudentID, &m.AdmissionNumber, &m.RollNumber, &m.FullName, &m.Date, &m.Status); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetEnrollmentClass retrieves a class of the request's school, or nil
+func (r *Repository) GetEnrollmentClass(ctx context.Context, classID uuid.UUID) (*enrollmentClass, error) {
+	var c enrollmentClass
+	err := r.db.QueryRow(ctx, `
+		SELECT id, section, academic_year FROM classes
+		WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)
+	`, classID, tenant.SchoolID(ctx)).Scan(&c.ID, &c.Section, &c.AcademicYear)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetStudentPlacement retrieves a student's status, class and current
+// enrollment start, or nil if the student is not the request's school's
+func (r *Repository) GetStudentPlacement(ctx context.Context, studentID uuid.UUID) (*studentPlacement, error) {
+	var p studentPlacement
+	var classID *uuid.UUID
+	var section, academicYear *string
+	err := r.db.QueryRow(ctx, `
+		SELECT s.status, c.id, c.section, c.academic_year, e.start_date
+		FROM students s
+		LEFT JOIN classes c ON c.id = s.class_id
+		LEFT JOIN student_enrollments e ON e.student_id = s.id AND e.end_date IS NULL
+		WHERE s.id = $1 AND ($2::uuid IS NULL OR s.school_id = $2)
+	`, studentID, tenant.SchoolID(ctx)).Scan(&p.Status, &classID, &section, &academicYear, &p.EnrolledSince)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if classID != nil {
+		p.Class = &enrollmentClass{ID: *classID, Section: section, AcademicYear: *academicYear}
+	}
+	return &p, nil
+}
+
+// TransferStudent moves an active student from one class to another as of a
+// date. The enrollment trigger records the move in the student's history and
+// recounts both classes; attendance marked in the old class from that date on
+// moves with the student. Returns false if the student is no longer active in
+// the old class.
+func (r *Repository) TransferStudent(ctx context.Context, studentID, fromClassID uuid.UUID, to *enrollmentClass, effective time.Time) (int, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		SELECT set_config('app.enrollment_date', $1, true), set_config('app.enrollment_reason', 'transfer', true)
+	`, effective.Format("2006-01-02")); err != nil {
+		return 0, false, err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE students SET class_id = $3, section = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND class_id = $2 AND status = 'active'
+	`, studentID, fromClassID, to.ID, to.Section)
+	if err != nil {
+		return 0, false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, false, nil
+	}
+
+	tag, err = tx.Exec(ctx, `
+		UPDATE attendance SET class_id = $3
+		WHERE student_id = $1 AND class_id = $2 AND date >= $4
+	`, studentID, fromClassID, to.ID, effective)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int(tag.RowsAffected()), true, tx.Commit(ctx)
+}
+
+// GetStudentEnrollments retrieves a student's enrollment history, oldest first
+func (r *Repository) GetStudentEnrollments(ctx context.Context, studentID uuid.UUID) ([]Enrollment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT e.id, e.class_id, c.name, c.section, c.academic_year, e.start_date, e.end_date,
+		       e.reason, e.created_by, e.created_at
+		FROM student_enrollments e
+		JOIN classes c ON c.id = e.class_id
+		WHERE e.student_id = $1 AND ($2::uuid IS NULL OR e.school_id = $2)
+		ORDER BY e.start_date, e.created_at
+	`, studentID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []Enrollment{}
+	for rows.Next() {
+		var e Enrollment
+		if err := rows.Scan(&e.ID, &e.ClassID, &e.ClassName, &e.Section, &e.AcademicYear, &e.StartDate, &e.EndDate,
+			&e.Reason, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+// GetGenerationFeeStructures retrieves a school's active fee structures of an
+// academic year with their items, or just one of them
+func (r *Repository) GetGenerationFeeStructures(ctx context.Context, schoolID uuid.UUID, academicYear string, structureID *uuid.UUID) ([]FeeStructure, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, name, description, applicable_grades, academic_year, is_active, created_at, updated_at
+		FROM fee_structures
+		WHERE school_id = $1 AND academic_year = $2 AND is_active = true
+		  AND ($3::uuid IS NULL OR id = $3)
+		ORDER BY created_at
+	`, schoolID, academicYear, structureID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var structures []FeeStructure
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var fs FeeStructure
+		if err := rows.Scan(&fs.ID, &fs.Name, &fs.Description, &fs.ApplicableGrades, &fs.AcademicYear, &fs.IsActive, &fs.CreatedAt, &fs.UpdatedAt); err != nil {
+			return nil, err
+		}
+		index[fs.ID] = len(structures)
+		structures = append(structures, fs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(structures) == 0 {
+		return structures, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(structures))
+	for _, fs := range structures {
+		ids = append(ids, fs.ID)
+	}
+	itemRows, err := r.db.Query(ctx, `
+		SELECT id, fee_structure_id, name, amount, COALESCE(frequency, 'monthly'), COALESCE(is_optional, false), COALESCE(due_day, 10), created_at
+		FROM fee_items
+		WHERE fee_structure_id = ANY($1)
+		ORDER BY created_at, name
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item FeeItem
+		if err := itemRows.Scan(&item.ID, &item.FeeStructureID, &item.Name, &item.Amount, &item.Frequency, &item.IsOptional, &item.DueDay, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		fs := &structures[index[item.FeeStructureID]]
+		fs.Items = append(fs.Items, item)
+	}
+	return structures, itemRows.Err()
+}
+
+// GetFeeStudents retrieves the active students of a school's classes of an
+// academic year, of the given grades or of every grade if there are none
+func (r *Repository) GetFeeStudents(ctx context.Context, schoolID uuid.UUID, academicYear string, grades []int) ([]feeStudent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT s.id, s.admission_date
+		FROM students s
+		JOIN classes c ON c.id = s.class_id
+		WHERE s.school_id = $1 AND c.academic_year = $2 AND s.status = 'active'
+		  AND ($3::int[] IS NULL OR cardinality($3::int[]) = 0 OR c.grade = ANY($3::int[]))
+	`, schoolID, academicYear, grades)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []feeStudent
+	for rows.Next() {
+		var s feeStudent
+		if err := rows.Scan(&s.ID, &s.AdmissionDate); err != nil {
+			return nil, err
+		}
+		students = append(students, s)
+	}
+	return students, rows.Err()
+}
+
+// GetOptedInStudents retrieves the students opted into each of the fee items
+func (r *Repository) GetOptedInStudents(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]map[uuid.UUID]bool, error) {
+	rows, err := r.db.Query(ctx, `SELECT fee_item_id, student_id FROM fee_item_opt_ins WHERE fee_item_id = ANY($1)`, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	optIns := map[uuid.UUID]map[uuid.UUID]bool{}
+	for rows.Next() {
+		var itemID, studentID uuid.UUID
+		if err := rows.Scan(&itemID, &studentID); err != nil {
+			return nil, err
+		}
+		if optIns[itemID] == nil {
+			optIns[itemID] = map[uuid.UUID]bool{}
+		}
+		optIns[itemID][studentID] = true
+	}
+	return optIns, rows.Err()
+}
+
+// GetInstalmentKeys retrieves the instalments already generated for the fee items
+func (r *Repository) GetInstalmentKeys(ctx context.Context, itemIDs []uuid.UUID) (map[feeInstalmentKey]bool, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT student_id, fee_item_id, period_start
+		FROM student_fees
+		WHERE fee_item_id = ANY($1) AND period_start IS NOT NULL
+	`, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[feeInstalmentKey]bool{}
+	for rows.Next() {
+		var key feeInstalmentKey
+		var periodStart time.Time
+		if err := rows.Scan(&key.StudentID, &key.FeeItemID, &periodStart); err != nil {
+			return nil, err
+		}
+		key.PeriodStart = periodStart.Format("2006-01-02")
+		keys[key] = true
+	}
+	return keys, rows.Err()
+}
+
+// InsertInstalments stores generated instalments, skipping any that already
+// exist, and returns how many were stored
+func (r *Repository) InsertInstalments(ctx context.Context, schoolID uuid.UUID, academicYear string, instalments []feeInstalment) (int, error) {
+	studentIDs := make([]uuid.UUID, len(instalments))
+	itemIDs := make([]uuid.UUID, len(instalments))
+	amounts := make([]float64, len(instalments))
+	dueDates := make([]time.Time, len(instalments))
+	periodStarts := make([]time.Time, len(instalments))
+	periodEnds := make([]time.Time, len(instalments))
+	for i, in := range instalments {
+		studentIDs[i] = in.StudentID
+		itemIDs[i] = in.FeeItemID
+		amounts[i] = in.Amount
+		dueDates[i] = in.DueDate
+		periodStarts[i] = in.PeriodStart
+		periodEnds[i] = in.PeriodEnd
+	}
+
+	tag, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO student_fees (student_id, fee_item_id, amount, due_date, period_start, period_end, status, academic_year, school_id)
+		SELECT s, f, a, d, ps, pe, 'pending', $7, $8
+		FROM unnest($1::uuid[], $2::uuid[], $3::numeric[], $4::date[], $5::date[], $6::date[]) AS i(s, f, a, d, ps, pe)
+		ON CONFLICT (student_id, fee_item_id, period_start) DO NOTHING
+	`, studentIDs, itemIDs, amounts, dueDates, periodStarts, periodEnds, academicYear, schoolID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// GetFeeItem retrieves a fee item of the request's school, or nil
+func (r *Repository) GetFeeItem(ctx context.Context, itemID uuid.UUID) (*FeeItem, error) {
+	var item FeeItem
+	err := r.db.QueryRow(ctx, `
+		SELECT id, fee_structure_id, name, amount, COALESCE(frequency, 'monthly'), COALESCE(is_optional, false), COALESCE(due_day, 10), created_at
+		FROM fee_items
+		WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)
+	`, itemID, tenant.SchoolID(ctx)).Scan(&item.ID, &item.FeeStructureID, &item.Name, &item.Amount, &item.Frequency, &item.IsOptional, &item.DueDay, &item.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetFeeOptIns lists the students opted into a fee item
+func (r *Repository) GetFeeOptIns(ctx context.Context, itemID uuid.UUID) ([]FeeOptIn, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT o.student_id, u.full_name, s.admission_number, o.created_by, o.created_at
+		FROM fee_item_opt_ins o
+		JOIN students s ON s.id = o.student_id
+		JOIN users u ON u.id = s.user_id
+		WHERE o.fee_item_id = $1 AND ($2::uuid IS NULL OR o.school_id = $2)
+		ORDER BY u.full_name
+	`, itemID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	optIns := []FeeOptIn{}
+	for rows.Next() {
+		var o FeeOptIn
+		if err := rows.Scan(&o.StudentID, &o.FullName, &o.AdmissionNumber, &o.CreatedBy, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		optIns = append(optIns, o)
+	}
+	return optIns, rows.Err()
+}
+
+// AddFeeOptIns opts students of the item's school into a fee item and returns
+// how many were not opted in before
+func (r *Repository) AddFeeOptIns(ctx context.Context, actorID, itemID uuid.UUID, studentIDs []uuid.UUID) (int, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO fee_item_opt_ins (fee_item_id, student_id, school_id, created_by)
+		SELECT fi.id, s.id, s.school_id, $3
+		FROM fee_items fi
+		JOIN students s ON s.school_id = fi.school_id AND s.id = ANY($2)
+		WHERE fi.id = $1
+		ON CONFLICT (fee_item_id, student_id) DO NOTHING
+	`, itemID, studentIDs, actorID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// RemoveFeeOptIn opts a student out of a fee item and cancels their untouched
+// instalments of it that are not yet due. Returns false if the student was not
+// opted in.
+func (r *Repository) RemoveFeeOptIn(ctx context.Context, itemID, studentID uuid.UUID) (bool, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM fee_item_opt_ins
+		WHERE fee_item_id = $1 AND student_id = $2 AND ($3::uuid IS NULL OR school_id = $3)
+	`, itemID, studentID, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, 0, nil
+	}
+
+	tag, err = tx.Exec(ctx, `
+		DELETE FROM student_fees sf
+		WHERE sf.fee_item_id = $1 AND sf.student_id = $2
+		  AND sf.status = 'pending' AND sf.paid_amount = 0 AND sf.waiver_amount = 0
+		  AND sf.due_date >= CURRENT_DATE
+		  AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.student_fee_id = sf.id)
+	`, itemID, studentID)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return true, int(tag.RowsAffected()), tx.Commit(ctx)
+}
@@ -3,30 +3,88 @@ package admin
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/settings"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/permission"
 )
 
+// VerificationSender emails an email verification link to a user
+type VerificationSender interface {
+	SendVerificationEmail(ctx context.Context, userID uuid.UUID) error
+}
+
+// InvitationSender emails a fresh invitation link for an invitation
+type InvitationSender interface {
+	SendInvitation(ctx context.Context, invitationID uuid.UUID) error
+}
+
 // Service handles admin business logic
 type Service struct {
-	repo   *Repository
-	config *config.Config
+	repo      *Repository
+	passwords *password.Manager
+	denylist  *middleware.TokenDenylist
+	authz     *middleware.Authorizer
+	verifier  VerificationSender
+	inviter   InvitationSender
+	settings  *settings.Service
+	config    *config.Config
 }
 
 // Common errors
 var (
-	ErrUserNotFound  = errors.New("user not found")
-	ErrEmailExists   = errors.New("email already exists")
-	ErrNotAuthorized = errors.New("not authorized for this action")
-	ErrInvalidInput  = errors.New("invalid input")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrEmailExists      = errors.New("email already exists")
+	ErrNotAuthorized    = errors.New("not authorized for this action")
+	ErrPermissionDenied = errors.New("cannot grant a role with permissions you do not hold")
+	ErrInvalidInput     = errors.New("invalid input")
+
+	ErrLockoutNotFound = errors.New("lockout not found")
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrInvitationNotFound     = errors.New("invitation not found")
+	ErrInvitationNotPending   = errors.New("invitation has already been accepted or revoked")
+	ErrInvitationFields       = errors.New("student_id is only for parent invitations; class and profile fields only for student invitations")
+	ErrStudentDetailsRequired = errors.New("student invitations need class_id, admission_number and date_of_birth")
+	ErrStudentNotFound        = errors.New("student not found")
+	ErrClassNotFound          = errors.New("class not found")
+	ErrAdmissionNumberExists  = errors.New("admission number already in use")
+	ErrSignupNotFound         = errors.New("pending sign-up not found")
+
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	ErrStudentNotEnrolled   = errors.New("student is not enrolled in a class")
+	ErrSameClass            = errors.New("student is already in this class")
+	ErrClassYearMismatch    = errors.New("students can only be transferred between classes of the same academic year")
+	ErrInvalidEffectiveDate = errors.New("effective date cannot be in the future or before the student joined their current class")
+
+	ErrFeeStructureNotFound = errors.New("fee structure not found")
+	ErrFeeItemNotFound      = errors.New("fee item not found")
+	ErrFeeItemNotOptional   = errors.New("only optional fee items take opt-ins")
+	ErrFeeOptInNotFound     = errors.New("student is not opted into this fee item")
+
+	ErrImportEmpty       = errors.New("import file has no data rows")
+	ErrImportTooManyRows = fmt.Errorf("import file has more than %d rows, split it into smaller files", maxImportRows)
 )
 
 // NewService creates a new admin service
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, passwords *password.Manager, denylist *middleware.TokenDenylist, authz *middleware.Authorizer, verifier VerificationSender, inviter InvitationSender, settingsService *settings.Service, cfg *config.Config) *Service {
 	return &Service{
-		repo:   repo,
-		config: cfg,
+		repo:      repo,
+		passwords: passwords,
+		denylist:  denylist,
+		authz:     authz,
+		verifier:  verifier,
+		inviter:   inviter,
+		settings:  settingsService,
+		config:    cfg,
 	}
 }
 
@@ -60,15 +118,28 @@ func (s *Service) GetUserByID(ctx context.Context, userID uuid.UUID) (*UserListI
 }
 
 // CreateUser creates a new user
-func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest) (uuid.UUID, error) {
+func (s *Service) CreateUser(ctx context.Context, actorID uuid.UUID, req *CreateUserRequest) (uuid.UUID, error) {
 	if req.Role != "student" && req.Role != "teacher" && req.Role != "admin" && req.Role != "staff" && req.Role != "parent" {
 		return uuid.Nil, ErrInvalidInput
 	}
-	return s.repo.CreateUser(ctx, req)
+	if err := s.checkRoleGrantable(ctx, actorID, req.Role); err != nil {
+		return uuid.Nil, err
+	}
+	hashedPassword, err := s.hashNewPassword(req.Password)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := s.repo.CreateUser(ctx, req, hashedPassword)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.rememberPassword(ctx, userID, hashedPassword)
+	s.sendVerification(ctx, userID)
+	return userID, nil
 }
 
 // UpdateUser updates a user
-func (s *Service) UpdateUser(ctx context.Context, userID uuid.UUID, req *UpdateUserRequest) error {
+func (s *Service) UpdateUser(ctx context.Context, actorID, userID uuid.UUID, req *UpdateUserRequest) error {
 	existing, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return err
@@ -76,7 +147,34 @@ func (s *Service) UpdateUser(ctx context.Context, userID uuid.UUID, req *UpdateU
 	if existing == nil {
 		return ErrUserNotFound
 	}
-	return s.repo.UpdateUser(ctx, userID, req)
+	roleChanged := req.Role != "" && req.Role != existing.Role
+	if roleChanged {
+		if err := s.checkRoleGrantable(ctx, actorID, req.Role); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.UpdateUser(ctx, userID, req); err != nil {
+		return err
+	}
+
+	if req.Email != "" && req.Email != existing.Email {
+		s.sendVerification(ctx, userID)
+	}
+
+	// Deactivated users lose access immediately
+	if req.IsActive != nil && !*req.IsActive {
+		return s.signOut(ctx, userID)
+	}
+
+	// Access tokens carry the primary role, which RequireMFA checks; revoking
+	// them makes the next refresh issue tokens with the new role
+	if roleChanged {
+		if err := s.authz.Invalidate(ctx, userID.String()); err != nil {
+			log.Printf("Failed to invalidate permissions for user %s: %v", userID, err)
+		}
+		return s.denylist.RevokeUser(ctx, userID.String())
+	}
+	return nil
 }
 
 // DeleteUser soft deletes a user
@@ -88,17 +186,143 @@ func (s *Service) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	if existing == nil {
 		return ErrUserNotFound
 	}
-	return s.repo.DeleteUser(ctx, userID)
+	if err := s.repo.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.signOut(ctx, userID)
+}
+
+// ForceSignOut revokes every token of a user without deactivating them
+func (s *Service) ForceSignOut(ctx context.Context, userID uuid.UUID) error {
+	existing, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrUserNotFound
+	}
+	return s.signOut(ctx, userID)
+}
+
+// GetUserSessions returns a user's active sessions
+func (s *Service) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error) {
+	existing, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.GetUserSessions(ctx, userID)
+}
+
+// RevokeUserSession signs a user out of one session and records who did it
+func (s *Service) RevokeUserSession(ctx context.Context, actorID, userID, sessionID uuid.UUID, ipAddress, userAgent string) error {
+	revoked, err := s.repo.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	if err := s.denylist.RevokeSession(ctx, sessionID.String()); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"session_id": sessionID}
+	if err := s.repo.LogAudit(ctx, &actorID, "session.revoked", "user", &userID, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit session revocation for user %s: %v", userID, err)
+	}
+	return nil
+}
+
+// signOut revokes refresh tokens and denylists outstanding access tokens
+func (s *Service) signOut(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.denylist.RevokeUser(ctx, userID.String())
+}
+
+// checkRoleGrantable rejects giving someone a built-in role with a permission
+// the actor does not hold, the same rule that applies to custom roles
+func (s *Service) checkRoleGrantable(ctx context.Context, actorID uuid.UUID, roleName string) error {
+	required, err := s.repo.GetSystemRolePermissions(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	held, err := s.authz.Permissions(ctx, actorID.String())
+	if err != nil {
+		return err
+	}
+	for _, key := range required {
+		if def, ok := permission.Lookup(key); ok && def.SelfScoped {
+			continue
+		}
+		if !held[key] {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// sendVerification emails a verification link; failures never block the admin action
+func (s *Service) sendVerification(ctx context.Context, userID uuid.UUID) {
+	if err := s.verifier.SendVerificationEmail(ctx, userID); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", userID, err)
+	}
+}
+
+// hashNewPassword checks an initial password against the policy and hashes it
+func (s *Service) hashNewPassword(plain string) (string, error) {
+	if err := s.passwords.Validate(plain); err != nil {
+		return "", err
+	}
+	return s.passwords.Hash(plain)
+}
+
+// rememberPassword starts the password history of a new user
+func (s *Service) rememberPassword(ctx context.Context, userID uuid.UUID, hashedPassword string) {
+	if err := s.passwords.Remember(ctx, userID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for user %s: %v", userID, err)
+	}
 }
 
 // CreateStudent creates a student with profile
 func (s *Service) CreateStudent(ctx context.Context, req *CreateStudentRequest) (uuid.UUID, error) {
-	return s.repo.CreateStudentWithProfile(ctx, req)
+	hashedPassword, err := s.hashNewPassword(req.Password)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := s.repo.CreateStudentWithProfile(ctx, req, hashedPassword)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.rememberPassword(ctx, userID, hashedPassword)
+	s.sendVerification(ctx, userID)
+
+	// Link the parent's existing account; otherwise an admin links it later
+	if req.ParentEmail != "" {
+		if err := s.repo.LinkParentByEmail(ctx, userID); err != nil {
+			log.Printf("Failed to link parent of student user %s: %v", userID, err)
+		}
+	}
+	return userID, nil
 }
 
 // CreateTeacher creates a teacher with profile
 func (s *Service) CreateTeacher(ctx context.Context, req *CreateTeacherRequest) (uuid.UUID, error) {
-	return s.repo.CreateTeacherWithProfile(ctx, req)
+	hashedPassword, err := s.hashNewPassword(req.Password)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := s.repo.CreateTeacherWithProfile(ctx, req, hashedPassword)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.rememberPassword(ctx, userID, hashedPassword)
+	s.sendVerification(ctx, userID)
+	return userID, nil
 }
 
 // GetFeeStructures returns fee structures
@@ -124,12 +348,195 @@ func (s *Service) GetRecentPayments(ctx context.Context, limit int) ([]Payment,
 	return s.repo.GetRecentPayments(ctx, limit)
 }
 
-// GetAuditLogs returns audit logs
-func (s *Service) GetAuditLogs(ctx context.Context, limit int) ([]AuditLog, error) {
-	if limit <= 0 {
+// GetLockouts returns active login lockouts
+func (s *Service) GetLockouts(ctx context.Context) ([]LoginLockout, error) {
+	return s.repo.GetActiveLockouts(ctx)
+}
+
+// ClearLockout unblocks an account or IP and records who did it
+func (s *Service) ClearLockout(ctx context.Context, actorID uuid.UUID, req *ClearLockoutRequest, ipAddress, userAgent string) error {
+	key := req.Key
+	if req.Kind == "account" {
+		key = strings.ToLower(strings.TrimSpace(key))
+	}
+
+	cleared, err := s.repo.ClearLoginFailures(ctx, req.Kind, key)
+	if err != nil {
+		return err
+	}
+	if !cleared {
+		return ErrLockoutNotFound
+	}
+
+	details := map[string]interface{}{"kind": req.Kind, "key": key}
+	if err := s.repo.LogAudit(ctx, &actorID, "login.lockout_cleared", "login_lockout", nil, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit lockout clear for %s %s: %v", req.Kind, key, err)
+	}
+	return nil
+}
+
+// CreateInvitation invites someone to join the school and emails them the link.
+// A failed email is logged; the invitation can be resent.
+func (s *Service) CreateInvitation(ctx context.Context, actorID uuid.UUID, req *CreateInvitationRequest, ipAddress, userAgent string) (*Invitation, error) {
+	if err := s.validateInvitation(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkRoleGrantable(ctx, actorID, req.Role); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.EmailExists(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailExists
+	}
+
+	ttl := time.Duration(s.config.Auth.InvitationTTLHours) * time.Hour
+	id, err := s.repo.CreateInvitation(ctx, req, actorID, time.Now().Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"email": req.Email, "role": req.Role}
+	if err := s.repo.LogAudit(ctx, &actorID, "invitation.created", "invitation", &id, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit invitation %s: %v", id, err)
+	}
+
+	if err := s.inviter.SendInvitation(ctx, id); err != nil {
+		log.Printf("Failed to send invitation %s: %v", id, err)
+	}
+	return s.repo.GetInvitation(ctx, id)
+}
+
+// validateInvitation checks the role-specific fields of an invitation
+func (s *Service) validateInvitation(ctx context.Context, req *CreateInvitationRequest) error {
+	hasStudentFields := req.ClassID != nil || req.AdmissionNumber != "" || req.RollNumber != "" || req.DateOfBirth != ""
+	if (req.StudentID != nil || req.Relationship != "") && req.Role != "parent" {
+		return ErrInvitationFields
+	}
+	if hasStudentFields && req.Role != "student" {
+		return ErrInvitationFields
+	}
+
+	if req.StudentID != nil {
+		exists, err := s.repo.StudentExists(ctx, *req.StudentID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrStudentNotFound
+		}
+	}
+
+	if req.Role == "student" {
+		if req.ClassID == nil || req.AdmissionNumber == "" || req.DateOfBirth == "" {
+			return ErrStudentDetailsRequired
+		}
+		exists, err := s.repo.ClassExists(ctx, *req.ClassID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrClassNotFound
+		}
+		taken, err := s.repo.AdmissionNumberExists(ctx, req.AdmissionNumber)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrAdmissionNumberExists
+		}
+	}
+	return nil
+}
+
+// GetInvitations lists invitations, optionally filtered by status
+func (s *Service) GetInvitations(ctx context.Context, status string, limit int) ([]Invitation, error) {
+	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
-	return s.repo.GetRecentAuditLogs(ctx, limit)
+	return s.repo.GetInvitations(ctx, status, limit)
+}
+
+// ResendInvitation emails a new link, which also restarts the expiry.
+// Earlier links for the invitation stop working.
+func (s *Service) ResendInvitation(ctx context.Context, id uuid.UUID) (*Invitation, error) {
+	inv, err := s.repo.GetInvitation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, ErrInvitationNotFound
+	}
+	if inv.Status == "accepted" || inv.Status == "revoked" {
+		return nil, ErrInvitationNotPending
+	}
+
+	if err := s.inviter.SendInvitation(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetInvitation(ctx, id)
+}
+
+// RevokeInvitation cancels a pending invitation and records who did it
+func (s *Service) RevokeInvitation(ctx context.Context, actorID, id uuid.UUID, ipAddress, userAgent string) error {
+	inv, err := s.repo.GetInvitation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		return ErrInvitationNotFound
+	}
+
+	revoked, err := s.repo.RevokeInvitation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotPending
+	}
+
+	details := map[string]interface{}{"email": inv.Email, "role": inv.Role}
+	if err := s.repo.LogAudit(ctx, &actorID, "invitation.revoked", "invitation", &id, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit invitation revocation %s: %v", id, err)
+	}
+	return nil
+}
+
+// GetParentSignups returns parent self-registrations, pending ones by default
+func (s *Service) GetParentSignups(ctx context.Context, status string) ([]ParentSignup, error) {
+	if status == "" {
+		status = "pending"
+	}
+	if status == "all" {
+		status = ""
+	}
+	return s.repo.GetParentSignups(ctx, status)
+}
+
+// ReviewParentSignup approves or rejects a pending parent sign-up.
+// Approved parents can sign in; children are linked separately.
+func (s *Service) ReviewParentSignup(ctx context.Context, actorID, signupID uuid.UUID, approve bool, ipAddress, userAgent string) error {
+	userID, err := s.repo.ReviewParentSignup(ctx, signupID, actorID, approve)
+	if err != nil {
+		return err
+	}
+	if userID == uuid.Nil {
+		return ErrSignupNotFound
+	}
+
+	action := "signup.rejected"
+	if approve {
+		action = "signup.approved"
+		s.sendVerification(ctx, userID)
+	}
+	details := map[string]interface{}{"signup_id": signupID}
+	if err := s.repo.LogAudit(ctx, &actorID, action, "user", &userID, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit parent sign-up review %s: %v", signupID, err)
+	}
+	return nil
 }
 
 // LogActivity logs an activity
@@ -0,0 +1,156 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Handler handles HTTP requests for service accounts and API keys
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new API key handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetServiceAccounts lists the school's service accounts
+// GET /api/v1/admin/api-keys/service-accounts
+func (h *Handler) GetServiceAccounts(c *gin.Context) {
+	accounts, err := h.service.GetServiceAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"service_accounts": accounts})
+}
+
+// CreateServiceAccount creates a service account
+// POST /api/v1/admin/api-keys/service-accounts
+func (h *Handler) CreateServiceAccount(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(c.Request.Context(), actorID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"service_account": account})
+}
+
+// DeactivateServiceAccount disables a service account and revokes its keys
+// DELETE /api/v1/admin/api-keys/service-accounts/:id
+func (h *Handler) DeactivateServiceAccount(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+		return
+	}
+
+	if err := h.service.DeactivateServiceAccount(c.Request.Context(), actorID, accountID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service account deactivated"})
+}
+
+// GetAPIKeys lists API keys
+// GET /api/v1/admin/api-keys?service_account_id=
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	var serviceAccountID *uuid.UUID
+	if idStr := c.Query("service_account_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+			return
+		}
+		serviceAccountID = &id
+	}
+
+	keys, err := h.service.GetAPIKeys(c.Request.Context(), serviceAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey issues an API key. The key is only shown in this response.
+// POST /api/v1/admin/api-keys
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(c.Request.Context(), actorID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": key,
+		"message": "Store this key now, it will not be shown again",
+	})
+}
+
+// RevokeAPIKey revokes an API key
+// DELETE /api/v1/admin/api-keys/:id
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(c.Request.Context(), actorID, keyID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// respondError maps API key errors to responses
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrServiceAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "service_account_not_found"})
+	case errors.Is(err, ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "api_key_not_found"})
+	case errors.Is(err, ErrServiceAccountInactive), errors.Is(err, ErrAPIKeyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": "conflict", "message": err.Error()})
+	case errors.Is(err, ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown_permission"})
+	case errors.Is(err, ErrInvalidExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_expiry", "message": err.Error()})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	case errors.Is(err, ErrPermissionDenied), errors.Is(err, ErrPlatformPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Keys look like s24_<12 hex chars>_<secret>. The part before the second
+// underscore is the prefix, stored in clear to find the key and to tell keys
+// apart in listings.
+const (
+	keyMarker      = "s24_"
+	keyIDBytes     = 6
+	keySecretBytes = 32
+	keyPrefixLen   = len(keyMarker) + 2*keyIDBytes
+)
+
+// generateKey returns a new API key, its prefix and the SHA-256 hash to store
+func generateKey() (string, string, string, error) {
+	id := make([]byte, keyIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, keySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix := keyMarker + hex.EncodeToString(id)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, hashKey(key), nil
+}
+
+// keyPrefix extracts the prefix of a presented key
+func keyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyMarker) || len(key) <= keyPrefixLen+1 || key[keyPrefixLen] != '_' {
+		return "", false
+	}
+	return key[:keyPrefixLen], true
+}
+
+// hashKey returns the hex encoded SHA-256 hash of a key. Keys carry 256 bits
+// of randomness, so a fast hash is enough.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
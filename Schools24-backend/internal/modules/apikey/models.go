@@ -0,0 +1,63 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccount is a non-human user that integrations act as
+type ServiceAccount struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	SchoolID       uuid.UUID `json:"school_id" db:"school_id"`
+	Name           string    `json:"name" db:"full_name"`
+	IsActive       bool      `json:"is_active" db:"is_active"`
+	ActiveKeyCount int       `json:"active_key_count"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// APIKey is a credential of a service account. Only its prefix is ever shown again.
+type APIKey struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	ServiceAccountID   uuid.UUID  `json:"service_account_id" db:"service_account_id"`
+	ServiceAccountName string     `json:"service_account_name"`
+	Name               string     `json:"name" db:"name"`
+	Prefix             string     `json:"prefix" db:"prefix"`
+	Scopes             []string   `json:"scopes" db:"scopes"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP         *string    `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	CreatedBy          *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Status             string     `json:"status"` // active, expired or revoked
+}
+
+// CreatedAPIKey is returned once, when the key is created
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// storedKey is the part of an API key needed to authenticate a request
+type storedKey struct {
+	ID               uuid.UUID
+	SchoolID         uuid.UUID
+	ServiceAccountID uuid.UUID
+	KeyHash          string
+	Scopes           []string
+}
+
+// CreateServiceAccountRequest for creating a service account
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// CreateAPIKeyRequest for issuing a key to a service account.
+// Keys without expires_at stay valid until revoked.
+type CreateAPIKeyRequest struct {
+	ServiceAccountID uuid.UUID  `json:"service_account_id" binding:"required"`
+	Name             string     `json:"name" binding:"required,max=100"`
+	Scopes           []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,236 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Repository handles database operations for service accounts and API keys
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new API key repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// serviceAccountSelect selects service accounts with their number of usable keys
+const serviceAccountSelect = `
+	SELECT u.id, u.school_id, u.full_name, u.is_active, u.created_at,
+	       (SELECT COUNT(*) FROM api_keys k
+	        WHERE k.service_account_id = u.id AND k.revoked_at IS NULL
+	          AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP))
+	FROM users u
+`
+
+// apiKeySelect selects API keys with a derived status
+const apiKeySelect = `
+	SELECT k.id, k.service_account_id, u.full_name, k.name, k.prefix, k.scopes, k.expires_at,
+	       k.last_used_at, k.last_used_ip, k.created_by, k.created_at, k.revoked_at,
+	       CASE
+	           WHEN k.revoked_at IS NOT NULL THEN 'revoked'
+	           WHEN k.expires_at IS NOT NULL AND k.expires_at <= CURRENT_TIMESTAMP THEN 'expired'
+	           ELSE 'active'
+	       END
+	FROM api_keys k
+	JOIN users u ON u.id = k.service_account_id
+`
+
+// CreateServiceAccount creates a service account user in the request's school
+func (r *Repository) CreateServiceAccount(ctx context.Context, id uuid.UUID, name, email, passwordHash string) error {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO users (id, email, password_hash, role, full_name, is_active, email_verified, school_id)
+		VALUES ($1, $2, $3, 'service', $4, true, false, $5)
+	`
+	return r.db.Exec(ctx, query, id, email, passwordHash, name, schoolID)
+}
+
+// GetServiceAccounts lists the request's school's service accounts
+func (r *Repository) GetServiceAccounts(ctx context.Context) ([]ServiceAccount, error) {
+	query := serviceAccountSelect + `
+		WHERE u.role = 'service' AND ($1::uuid IS NULL OR u.school_id = $1)
+		ORDER BY u.is_active DESC, u.full_name
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []ServiceAccount{}
+	for rows.Next() {
+		var a ServiceAccount
+		if err := rows.Scan(&a.ID, &a.SchoolID, &a.Name, &a.IsActive, &a.CreatedAt, &a.ActiveKeyCount); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// GetServiceAccount retrieves a service account of the request's school
+func (r *Repository) GetServiceAccount(ctx context.Context, id uuid.UUID) (*ServiceAccount, error) {
+	query := serviceAccountSelect + `
+		WHERE u.id = $1 AND u.role = 'service' AND ($2::uuid IS NULL OR u.school_id = $2)
+	`
+	var a ServiceAccount
+	err := r.db.QueryRow(ctx, query, id, tenant.SchoolID(ctx)).Scan(
+		&a.ID, &a.SchoolID, &a.Name, &a.IsActive, &a.CreatedAt, &a.ActiveKeyCount,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeactivateServiceAccount disables a service account and revokes all its keys
+func (r *Repository) DeactivateServiceAccount(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND role = 'service'
+	`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE service_account_id = $1 AND revoked_at IS NULL
+	`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// CreateAPIKey stores a new key for a service account of the request's school
+func (r *Repository) CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest, prefix, keyHash string, createdBy uuid.UUID) (uuid.UUID, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	query := `
+		INSERT INTO api_keys (school_id, service_account_id, name, prefix, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	var id uuid.UUID
+	err = r.db.QueryRow(ctx, query,
+		schoolID, req.ServiceAccountID, req.Name, prefix, keyHash, req.Scopes, req.ExpiresAt, createdBy,
+	).Scan(&id)
+	return id, err
+}
+
+// GetAPIKeys lists keys of the request's school, optionally of one service account
+func (r *Repository) GetAPIKeys(ctx context.Context, serviceAccountID *uuid.UUID) ([]APIKey, error) {
+	query := apiKeySelect + `
+		WHERE ($1::uuid IS NULL OR k.school_id = $1) AND ($2::uuid IS NULL OR k.service_account_id = $2)
+		ORDER BY k.created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, tenant.SchoolID(ctx), serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKey retrieves a key of the request's school
+func (r *Repository) GetAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error) {
+	query := apiKeySelect + `WHERE k.id = $1 AND ($2::uuid IS NULL OR k.school_id = $2)`
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, id, tenant.SchoolID(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// RevokeAPIKey revokes a key. Returns false if it was already revoked.
+func (r *Repository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL AND ($2::uuid IS NULL OR school_id = $2)
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, id, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetUsableKeyByPrefix finds an unexpired, unrevoked key whose service account
+// and school are active. Not tenant scoped: it runs before the request has a school.
+func (r *Repository) GetUsableKeyByPrefix(ctx context.Context, prefix string) (*storedKey, error) {
+	query := `
+		SELECT k.id, k.school_id, k.service_account_id, k.key_hash, k.scopes
+		FROM api_keys k
+		JOIN users u ON u.id = k.service_account_id
+		JOIN schools sc ON sc.id = k.school_id
+		WHERE k.prefix = $1 AND k.revoked_at IS NULL
+		  AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP)
+		  AND u.is_active = true AND sc.is_active = true
+	`
+	var k storedKey
+	err := r.db.QueryRow(ctx, query, prefix).Scan(
+		&k.ID, &k.SchoolID, &k.ServiceAccountID, &k.KeyHash, &k.Scopes,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+// TouchAPIKey records when and from where a key was last used, at most once a minute
+func (r *Repository) TouchAPIKey(ctx context.Context, id uuid.UUID, ipAddress string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute'
+		       OR last_used_ip IS DISTINCT FROM $2)
+	`
+	return r.db.Exec(ctx, query, id, ipAddress)
+}
+
+// scanAPIKey scans a key selected with apiKeySelect
+func scanAPIKey(row pgx.Row) (*APIKey, error) {
+	var k APIKey
+	err := row.Scan(
+		&k.ID, &k.ServiceAccountID, &k.ServiceAccountName, &k.Name, &k.Prefix, &k.Scopes, &k.ExpiresAt,
+		&k.LastUsedAt, &k.LastUsedIP, &k.CreatedBy, &k.CreatedAt, &k.RevokedAt, &k.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -0,0 +1,254 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/permission"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// AuditLogger records security events (implemented by admin.Repository)
+type AuditLogger interface {
+	LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error
+}
+
+// Service handles service accounts and API key business logic
+type Service struct {
+	repo      *Repository
+	passwords *password.Manager
+	authz     *middleware.Authorizer
+	audit     AuditLogger
+	config    *config.Config
+}
+
+// Common errors
+var (
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrServiceAccountInactive = errors.New("service account is deactivated")
+	ErrAPIKeyNotFound         = errors.New("API key not found")
+	ErrAPIKeyRevoked          = errors.New("API key is already revoked")
+	ErrInvalidExpiry          = errors.New("expires_at must be in the future")
+	ErrUnknownPermission      = errors.New("unknown permission")
+	ErrPermissionDenied       = errors.New("cannot grant a permission you do not hold")
+	ErrPlatformPermission     = errors.New("platform permissions cannot be granted to API keys")
+)
+
+// NewService creates a new API key service
+func NewService(repo *Repository, passwords *password.Manager, authz *middleware.Authorizer, audit AuditLogger, cfg *config.Config) *Service {
+	return &Service{
+		repo:      repo,
+		passwords: passwords,
+		authz:     authz,
+		audit:     audit,
+		config:    cfg,
+	}
+}
+
+// ValidateAPIKey resolves a presented key to its service account and records
+// its use. Implements middleware.APIKeyValidator.
+func (s *Service) ValidateAPIKey(ctx context.Context, key, ipAddress string) (*middleware.APIKeyPrincipal, error) {
+	prefix, ok := keyPrefix(key)
+	if !ok {
+		return nil, nil
+	}
+
+	stored, err := s.repo.GetUsableKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(stored.KeyHash)) != 1 {
+		return nil, nil
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, stored.ID, ipAddress); err != nil {
+		log.Printf("Failed to record use of API key %s: %v", stored.ID, err)
+	}
+
+	return &middleware.APIKeyPrincipal{
+		KeyID:    stored.ID.String(),
+		UserID:   stored.ServiceAccountID.String(),
+		SchoolID: stored.SchoolID.String(),
+		Scopes:   stored.Scopes,
+	}, nil
+}
+
+// GetServiceAccounts lists the school's service accounts
+func (s *Service) GetServiceAccounts(ctx context.Context) ([]ServiceAccount, error) {
+	return s.repo.GetServiceAccounts(ctx)
+}
+
+// CreateServiceAccount creates a service account in the current school.
+// Service accounts have an unknown random password, so they can only use API keys.
+func (s *Service) CreateServiceAccount(ctx context.Context, actorID uuid.UUID, req *CreateServiceAccountRequest, ipAddress, userAgent string) (*ServiceAccount, error) {
+	if _, err := tenant.RequireSchoolID(ctx); err != nil {
+		return nil, err
+	}
+
+	secret, _, _, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := s.passwords.Hash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	email := fmt.Sprintf("service-%s@accounts.invalid", id)
+	if err := s.repo.CreateServiceAccount(ctx, id, req.Name, email, passwordHash); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"name": req.Name}
+	if err := s.audit.LogAudit(ctx, &actorID, "service_account.created", "user", &id, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit service account %s: %v", id, err)
+	}
+	return s.repo.GetServiceAccount(ctx, id)
+}
+
+// DeactivateServiceAccount disables a service account and revokes its keys
+func (s *Service) DeactivateServiceAccount(ctx context.Context, actorID, accountID uuid.UUID, ipAddress, userAgent string) error {
+	account, err := s.repo.GetServiceAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return ErrServiceAccountNotFound
+	}
+
+	if err := s.repo.DeactivateServiceAccount(ctx, accountID); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"name": account.Name, "revoked_keys": account.ActiveKeyCount}
+	if err := s.audit.LogAudit(ctx, &actorID, "service_account.deactivated", "user", &accountID, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit service account %s: %v", accountID, err)
+	}
+	return nil
+}
+
+// GetAPIKeys lists the school's API keys, optionally of one service account
+func (s *Service) GetAPIKeys(ctx context.Context, serviceAccountID *uuid.UUID) ([]APIKey, error) {
+	return s.repo.GetAPIKeys(ctx, serviceAccountID)
+}
+
+// CreateAPIKey issues a key to a service account. The plaintext key is only
+// returned here; afterwards only its prefix is known.
+func (s *Service) CreateAPIKey(ctx context.Context, actorID uuid.UUID, req *CreateAPIKeyRequest, ipAddress, userAgent string) (*CreatedAPIKey, error) {
+	account, err := s.repo.GetServiceAccount(ctx, req.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrServiceAccountNotFound
+	}
+	if !account.IsActive {
+		return nil, ErrServiceAccountInactive
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	req.Scopes = uniqueScopes(req.Scopes)
+	if err := s.checkGrantable(ctx, actorID, req.Scopes); err != nil {
+		return nil, err
+	}
+
+	key, prefix, keyHash, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.repo.CreateAPIKey(ctx, req, prefix, keyHash, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{
+		"name":            req.Name,
+		"prefix":          prefix,
+		"service_account": req.ServiceAccountID,
+		"scopes":          req.Scopes,
+		"expires_at":      req.ExpiresAt,
+	}
+	if err := s.audit.LogAudit(ctx, &actorID, "api_key.created", "api_key", &id, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit API key %s: %v", id, err)
+	}
+
+	created, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &CreatedAPIKey{APIKey: *created, Key: key}, nil
+}
+
+// RevokeAPIKey revokes a key; requests using it fail immediately
+func (s *Service) RevokeAPIKey(ctx context.Context, actorID, keyID uuid.UUID, ipAddress, userAgent string) error {
+	key, err := s.repo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrAPIKeyNotFound
+	}
+
+	revoked, err := s.repo.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyRevoked
+	}
+
+	details := map[string]interface{}{"name": key.Name, "prefix": key.Prefix}
+	if err := s.audit.LogAudit(ctx, &actorID, "api_key.revoked", "api_key", &keyID, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit API key %s: %v", keyID, err)
+	}
+	return nil
+}
+
+// checkGrantable rejects unknown and platform permissions, and permissions the
+// actor does not hold, so a key can never do more than its creator
+func (s *Service) checkGrantable(ctx context.Context, actorID uuid.UUID, scopes []string) error {
+	held, err := s.authz.Permissions(ctx, actorID.String())
+	if err != nil {
+		return err
+	}
+	for _, key := range scopes {
+		def, ok := permission.Lookup(key)
+		if !ok {
+			return ErrUnknownPermission
+		}
+		if def.Platform {
+			return ErrPlatformPermission
+		}
+		if !held[key] {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// uniqueScopes drops duplicate scopes, keeping their order
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
@@ -2,11 +2,15 @@ package auth
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Handler handles HTTP requests for auth
@@ -19,7 +23,7 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// Register handles user registration
+// Register handles parent self-registration (pending admin approval)
 // POST /api/v1/auth/register
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -31,8 +35,18 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	resp, err := h.service.Register(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, ErrRegistrationClosed) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "registration_closed",
+				"message": err.Error(),
+			})
+			return
+		}
 		if errors.Is(err, ErrEmailExists) {
 			c.JSON(http.StatusConflict, gin.H{
 				"error":   "email_exists",
@@ -40,6 +54,20 @@ func (h *Handler) Register(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, ErrSchoolNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "school_not_found",
+				"message": "Unknown school code",
+			})
+			return
+		}
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   policyErr.Code,
+				"message": policyErr.Message,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "registration_failed",
 			"message": err.Error(),
@@ -62,8 +90,15 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	resp, err := h.service.Login(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			tooManyAttempts(c, err)
+			return
+		}
 		if errors.Is(err, ErrInvalidCredentials) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "invalid_credentials",
@@ -71,6 +106,13 @@ func (h *Handler) Login(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "email_not_verified",
+				"message": "Please confirm your email address before logging in",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "login_failed",
 			"message": err.Error(),
@@ -81,6 +123,216 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// Refresh exchanges a refresh token for a new token pair
+// POST /api/v1/auth/refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.service.Refresh(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "refresh_token_reused",
+				"message": "This refresh token was already used. Please log in again.",
+			})
+			return
+		}
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_refresh_token",
+				"message": "Invalid or expired refresh token",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "refresh_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ForgotPassword starts the password reset flow
+// POST /api/v1/auth/forgot-password
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "forgot_password_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account exists for this email, a reset link has been sent",
+	})
+}
+
+// AcceptInvitation creates an invited account and signs it in
+// POST /api/v1/auth/invitations/accept
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.service.AcceptInvitation(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInvitation) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_invitation",
+				"message": "Invalid or expired invitation",
+			})
+			return
+		}
+		if errors.Is(err, ErrEmailExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "email_exists",
+				"message": "This email is already registered",
+			})
+			return
+		}
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   policyErr.Code,
+				"message": policyErr.Message,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "accept_invitation_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ResetPassword completes the password reset flow
+// POST /api/v1/auth/reset-password
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), &req); err != nil {
+		if errors.Is(err, ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_reset_token",
+				"message": "Invalid or expired password reset token",
+			})
+			return
+		}
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   policyErr.Code,
+				"message": policyErr.Message,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "reset_password_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password has been reset. Please log in again.",
+	})
+}
+
+// VerifyEmail confirms an email address
+// POST /api/v1/auth/verify-email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), &req); err != nil {
+		if errors.Is(err, ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_verification_token",
+				"message": "Invalid or expired email verification token",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "verify_email_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email address verified",
+	})
+}
+
+// ResendVerification sends a new verification email
+// POST /api/v1/auth/verify-email/resend
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "resend_verification_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an unverified account exists for this email, a verification link has been sent",
+	})
+}
+
 // GetMe returns the current user's profile
 // GET /api/v1/auth/me
 func (h *Handler) GetMe(c *gin.Context) {
@@ -163,12 +415,619 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
-// Logout handles user logout (client-side token removal)
+// Logout ends the current session (for older tokens: the access token and refresh token, if sent)
 // POST /api/v1/auth/logout
 func (h *Handler) Logout(c *gin.Context) {
-	// For JWT-based auth, logout is handled client-side
-	// Here we can add token blacklisting if needed
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	// Body is optional
+	var req LogoutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.Logout(c.Request.Context(), claims, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "logout_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
+
+// Impersonate starts a read-only "view as user" session for support staff
+// POST /api/v1/admin/users/:id/impersonate
+func (h *Handler) Impersonate(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_user_id",
+			"message": "Invalid user ID format",
+		})
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	response, err := h.service.Impersonate(c.Request.Context(), claims, targetID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "user_not_found",
+				"message": "User not found",
+			})
+		case errors.Is(err, ErrImpersonationNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "impersonation_not_allowed",
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "impersonation_failed",
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLoginSSOProviders lists the single sign-on buttons for a school's login page
+// GET /api/v1/auth/sso/providers?school=<code>
+func (h *Handler) GetLoginSSOProviders(c *gin.Context) {
+	code := c.Query("school")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": "school is required",
+		})
+		return
+	}
+
+	providers, err := h.service.GetLoginSSOProviders(c.Request.Context(), code)
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// StartSSO returns the identity provider URL to send the browser to
+// POST /api/v1/auth/sso/start
+func (h *Handler) StartSSO(c *gin.Context) {
+	var req SSOStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.StartSSO(c.Request.Context(), &req)
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CompleteSSO signs in with the code and state the identity provider returned
+// POST /api/v1/auth/sso/callback
+func (h *Handler) CompleteSSO(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.service.CompleteSSO(c.Request.Context(), &req)
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSSOProviders lists the school's identity providers
+// GET /api/v1/admin/sso-providers
+func (h *Handler) GetSSOProviders(c *gin.Context) {
+	providers, err := h.service.GetSSOProviders(c.Request.Context())
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// SaveSSOProvider creates or updates an identity provider
+// PUT /api/v1/admin/sso-providers/:slug
+func (h *Handler) SaveSSOProvider(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SaveSSOProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	provider, err := h.service.SaveSSOProvider(c.Request.Context(), actorID, c.Param("slug"), &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"provider": provider})
+}
+
+// DeleteSSOProvider removes an identity provider
+// DELETE /api/v1/admin/sso-providers/:slug
+func (h *Handler) DeleteSSOProvider(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSSOProvider(c.Request.Context(), actorID, c.Param("slug"), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sign-in provider removed",
+	})
+}
+
+// respondSSOError maps single sign-on errors to responses
+func respondSSOError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrSchoolNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "school_not_found", "message": err.Error()})
+	case errors.Is(err, ErrSSOProviderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "sso_provider_not_found", "message": err.Error()})
+	case errors.Is(err, ErrSSODomainsRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sso_domains_required", "message": err.Error()})
+	case errors.Is(err, ErrInvalidSSOProvider):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_sso_provider", "message": "Check the slug and that the issuer is a public https URL serving an OpenID discovery document"})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	case errors.Is(err, ErrInvalidSSOState):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_sso_state", "message": err.Error()})
+	case errors.Is(err, ErrSSOFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "sso_failed", "message": err.Error()})
+	case errors.Is(err, ErrSSOEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "sso_email_not_verified", "message": err.Error()})
+	case errors.Is(err, ErrSSOEmailNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "sso_email_not_allowed", "message": err.Error()})
+	case errors.Is(err, ErrSSONoAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": "sso_no_account", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sso_error", "message": err.Error()})
+	}
+}
+
+// LoginMFA completes a two-step login
+// POST /api/v1/auth/login/mfa
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.service.LoginMFA(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			tooManyAttempts(c, err)
+			return
+		}
+		if errors.Is(err, ErrInvalidMFAToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_mfa_token",
+				"message": "Invalid or expired login challenge. Please log in again.",
+			})
+			return
+		}
+		if errors.Is(err, ErrInvalidMFACode) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_mfa_code",
+				"message": "Invalid two-factor code",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "login_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RequestLoginOTP texts a login code to a parent's phone
+// POST /api/v1/auth/otp/request
+func (h *Handler) RequestLoginOTP(c *gin.Context) {
+	var req OTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+
+	if err := h.service.RequestLoginOTP(c.Request.Context(), &req); err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			tooManyAttempts(c, err)
+			return
+		}
+		if errors.Is(err, ErrInvalidPhone) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_phone",
+				"message": "Enter a valid phone number",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "otp_request_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If a parent account uses this number, a login code has been sent",
+	})
+}
+
+// LoginOTP logs a parent in with the code received by SMS
+// POST /api/v1/auth/otp/verify
+func (h *Handler) LoginOTP(c *gin.Context) {
+	var req OTPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.service.LoginOTP(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			tooManyAttempts(c, err)
+			return
+		}
+		if errors.Is(err, ErrInvalidPhone) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_phone",
+				"message": "Enter a valid phone number",
+			})
+			return
+		}
+		if errors.Is(err, ErrInvalidOTP) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_otp",
+				"message": "Invalid or expired login code",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "login_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSessions lists the current user's active sessions
+// GET /api/v1/auth/sessions
+func (h *Handler) GetSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var currentSessionID string
+	if claims := middleware.GetClaims(c); claims != nil {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions, err := h.service.GetSessions(c.Request.Context(), userID, currentSessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs the current user out of one session
+// DELETE /api/v1/auth/sessions/:id
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_session_id",
+			"message": "Invalid session ID format",
+		})
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "session_not_found",
+				"message": "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "revoke_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session signed out",
+	})
+}
+
+// GetMFAStatus returns the current user's 2FA state
+// GET /api/v1/auth/mfa
+func (h *Handler) GetMFAStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetMFAStatus(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollMFA starts TOTP enrollment
+// POST /api/v1/auth/mfa/enroll
+func (h *Handler) EnrollMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.EnrollMFA(c.Request.Context(), userID)
+	if err != nil {
+		h.mfaError(c, err, "mfa_enroll_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ConfirmMFA activates 2FA and returns recovery codes
+// POST /api/v1/auth/mfa/confirm
+func (h *Handler) ConfirmMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.ConfirmMFA(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.mfaError(c, err, "mfa_confirm_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+// POST /api/v1/auth/mfa/recovery-codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.mfaError(c, err, "recovery_codes_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DisableMFA turns 2FA off for the current user
+// POST /api/v1/auth/mfa/disable
+func (h *Handler) DisableMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.DisableMFA(c.Request.Context(), userID, &req); err != nil {
+		h.mfaError(c, err, "mfa_disable_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// mfaError maps 2FA management errors to responses
+func (h *Handler) mfaError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_mfa_code",
+			"message": "Invalid two-factor code",
+		})
+	case errors.Is(err, ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_credentials",
+			"message": "Invalid password",
+		})
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "mfa_already_enabled",
+			"message": "Two-factor authentication is already enabled",
+		})
+	case errors.Is(err, ErrMFANotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "mfa_not_enrolled",
+			"message": "Two-factor authentication is not set up",
+		})
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "user_not_found",
+			"message": "User not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   fallback,
+			"message": err.Error(),
+		})
+	}
+}
+
+// tooManyAttempts responds 429 with a Retry-After header
+func tooManyAttempts(c *gin.Context, err error) {
+	retryAfter := 1
+	var throttle *ThrottleError
+	if errors.As(err, &throttle) {
+		if secs := int(math.Ceil(throttle.RetryAfter.Seconds())); secs > retryAfter {
+			retryAfter = secs
+		}
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "too_many_attempts",
+		"message":     "Too many failed login attempts. Please try again later.",
+		"retry_after": retryAfter,
+	})
+}
+
+// currentUserID reads the authenticated user's ID, writing an error response if absent
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_user_id",
+			"message": "Invalid user ID format",
+		})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
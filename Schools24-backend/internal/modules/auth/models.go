@@ -9,6 +9,7 @@ import (
 // User represents a user in the system
 type User struct {
 	ID                uuid.UUID  `json:"id" db:"id"`
+	SchoolID          *uuid.UUID `json:"school_id,omitempty" db:"school_id"` // Nil for super admins
 	Email             string     `json:"email" db:"email"`
 	PasswordHash      string     `json:"-" db:"password_hash"`
 	Role              string     `json:"role" db:"role"`
@@ -24,34 +25,194 @@ type User struct {
 
 // UserRole constants
 const (
-	RoleAdmin   = "admin"
-	RoleTeacher = "teacher"
-	RoleStudent = "student"
-	RoleStaff   = "staff"
-	RoleParent  = "parent"
+	RoleSuperAdmin = "super_admin"
+	RoleAdmin      = "admin"
+	RoleTeacher    = "teacher"
+	RoleStudent    = "student"
+	RoleStaff      = "staff"
+	RoleParent     = "parent"
 )
 
 // LoginRequest represents login credentials
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+
+	// DeviceName labels the session, e.g. "Staff room PC"; derived from the user agent if empty
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+
+	// Filled in by the handler for throttling, audit and session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
-// RegisterRequest represents registration data
+// RegisterRequest is a parent self-registration; other roles join by invitation
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Length and strength checked by the password policy
 	FullName string `json:"full_name" binding:"required,min=2"`
-	Role     string `json:"role" binding:"required,oneof=admin teacher student staff parent"`
 	Phone    string `json:"phone,omitempty"`
+
+	// ChildDetails helps the school match the parent to their children, e.g. "Asha, class 5B"
+	ChildDetails string `json:"child_details,omitempty" binding:"omitempty,max=500"`
+
+	// SchoolCode selects the school to join; defaults to the default school
+	SchoolCode string `json:"school_code,omitempty"`
+
+	// Filled in by the handler for session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // AuthResponse represents successful auth response
 type AuthResponse struct {
 	User         *User  `json:"user"`
-	AccessToken  string `json:"access_token"`
+	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
-	ExpiresIn    int    `json:"expires_in"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	// Set when the account must confirm its email before logging in
+	VerificationRequired bool `json:"verification_required,omitempty"`
+	// Set after a parent sign-up; the account is inactive until an admin approves it
+	ApprovalPending bool `json:"approval_pending,omitempty"`
+	// Set when the password was accepted and a second factor is needed
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// UserMFA holds a user's TOTP enrollment
+type UserMFA struct {
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	TOTPSecret   string     `json:"-" db:"totp_secret"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// MFAStatus summarizes a user's 2FA state
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFALoginRequest completes a login with a second factor.
+// Exactly one of Code or RecoveryCode is expected.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+	DeviceName   string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+
+	// Filled in by the handler for throttling, audit and session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// MFAEnrollResponse is returned when TOTP enrollment starts
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFACodeRequest carries a TOTP code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisableRequest turns 2FA off; both factors are re-checked
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCodesResponse returns freshly generated recovery codes (shown once)
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RefreshToken represents a stored, single-use refresh token.
+// Tokens issued from the same login share a FamilyID.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty" db:"replaced_by"`
+	// MFAVerified carries the second factor of the original login across rotations
+	MFAVerified bool      `json:"mfa_verified" db:"mfa_verified"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RefreshRequest represents a token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+
+	// Filled in by the handler for session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// LoginOTP is a one-time code sent by SMS for phone login
+type LoginOTP struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	Attempts  int        `json:"attempts" db:"attempts"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OTPRequest asks for a login code by SMS
+type OTPRequest struct {
+	Phone string `json:"phone" binding:"required,min=6,max=20"`
+
+	// Filled in by the handler for throttling
+	IPAddress string `json:"-"`
+}
+
+// OTPLoginRequest completes a phone login with the code received by SMS
+type OTPLoginRequest struct {
+	Phone      string `json:"phone" binding:"required,min=6,max=20"`
+	Code       string `json:"code" binding:"required,len=6,numeric"`
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+
+	// Filled in by the handler for throttling, audit and session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// Session is a login on one device; its ID is the refresh token family ID
+type Session struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"-" db:"user_id"`
+	Device     string     `json:"device" db:"device"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	Current    bool       `json:"current"`
+}
+
+// LogoutRequest optionally carries the refresh token to revoke with the session
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// PasswordReset represents a stored password reset token
+type PasswordReset struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	TokenHash string    `json:"-" db:"token"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Used      bool      `json:"used" db:"used"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 // PasswordResetRequest represents password reset request
@@ -59,6 +220,150 @@ type PasswordResetRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// ResetPasswordRequest completes a password reset
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"` // Checked by the password policy
+}
+
+// EmailVerification represents a stored email verification token
+type EmailVerification struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// VerifyEmailRequest confirms an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest asks for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Invitation is an admin-issued invitation to join a school
+type Invitation struct {
+	ID              uuid.UUID  `db:"id"`
+	SchoolID        uuid.UUID  `db:"school_id"`
+	SchoolName      string     `db:"school_name"`
+	Email           string     `db:"email"`
+	Role            string     `db:"role"`
+	FullName        *string    `db:"full_name"`
+	StudentID       *uuid.UUID `db:"student_id"`
+	Relationship    *string    `db:"relationship"`
+	ClassID         *uuid.UUID `db:"class_id"`
+	AdmissionNumber *string    `db:"admission_number"`
+	RollNumber      *string    `db:"roll_number"`
+	DateOfBirth     *time.Time `db:"date_of_birth"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	AcceptedAt      *time.Time `db:"accepted_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+}
+
+// AcceptInvitationRequest creates the invited account
+type AcceptInvitationRequest struct {
+	Token      string `json:"token" binding:"required"`
+	Password   string `json:"password" binding:"required"` // Checked by the password policy
+	FullName   string `json:"full_name" binding:"required,min=2"`
+	Phone      string `json:"phone,omitempty"`
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+
+	// Filled in by the handler for audit and session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ImpersonateRequest starts a read-only "view as user" session
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // Support ticket or explanation, kept in the audit log
+
+	// Filled in by the handler for the audit log
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ImpersonationResponse carries a short-lived access token acting as the target user.
+// There is no refresh token; the admin starts a new impersonation when it expires.
+type ImpersonationResponse struct {
+	User           *User  `json:"user"`
+	AccessToken    string `json:"access_token"`
+	ExpiresIn      int    `json:"expires_in"`
+	ImpersonatorID string `json:"impersonator_id"`
+	ReadOnly       bool   `json:"read_only"`
+}
+
+// SSOProvider is an OpenID Connect identity provider configured by a school
+type SSOProvider struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	SchoolID        uuid.UUID `json:"school_id" db:"school_id"`
+	Slug            string    `json:"slug" db:"slug"`
+	DisplayName     string    `json:"display_name" db:"display_name"`
+	Issuer          string    `json:"issuer" db:"issuer"`
+	ClientID        string    `json:"client_id" db:"client_id"`
+	ClientSecret    string    `json:"-" db:"client_secret"`
+	HasClientSecret bool      `json:"has_client_secret"`
+	Scopes          string    `json:"scopes" db:"scopes"`
+	AllowedDomains  []string  `json:"allowed_domains" db:"allowed_domains"` // Email domains that may sign in
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SSOProviderSummary is what the login page needs to show a provider button
+type SSOProviderSummary struct {
+	Slug        string `json:"slug"`
+	DisplayName string `json:"display_name"`
+}
+
+// SaveSSOProviderRequest creates or updates a school's provider.
+// A nil client_secret keeps the stored one; an empty string clears it.
+type SaveSSOProviderRequest struct {
+	DisplayName    string   `json:"display_name" binding:"required,max=100"`
+	Issuer         string   `json:"issuer" binding:"required,url,startswith=https://"`
+	ClientID       string   `json:"client_id" binding:"required"`
+	ClientSecret   *string  `json:"client_secret,omitempty"`
+	Scopes         string   `json:"scopes,omitempty" binding:"omitempty,max=255"`
+	AllowedDomains []string `json:"allowed_domains" binding:"required,min=1"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+}
+
+// SSOStartRequest begins a sign-in with a school's provider
+type SSOStartRequest struct {
+	SchoolCode string `json:"school_code" binding:"required"`
+	Provider   string `json:"provider" binding:"required"`
+}
+
+// SSOStartResponse carries the URL to send the browser to
+type SSOStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	ExpiresIn        int    `json:"expires_in"`
+}
+
+// SSOCallbackRequest completes a sign-in with the parameters the provider
+// returned to the redirect page
+type SSOCallbackRequest struct {
+	State      string `json:"state" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+
+	// Filled in by the handler for audit and session tracking
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ssoLoginState is a pending authorization request
+type ssoLoginState struct {
+	ID           uuid.UUID
+	ProviderID   uuid.UUID
+	Nonce        string
+	CodeVerifier string
+}
+
 // UpdateProfileRequest represents profile update
 type UpdateProfileRequest struct {
 	FullName          *string `json:"full_name,omitempty"`
@@ -0,0 +1,468 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcHTTPTimeout bounds every call to an identity provider
+	oidcHTTPTimeout = 10 * time.Second
+	// oidcCacheTTL is how long discovery documents and signing keys are reused
+	oidcCacheTTL = time.Hour
+	// oidcMaxResponseBytes caps provider responses
+	oidcMaxResponseBytes = 1 << 20
+)
+
+// oidcMetadata is the part of a provider's discovery document we use
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the ID token claims used for sign-in
+type idTokenClaims struct {
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	// Microsoft Entra: the email's domain is verified by the tenant
+	EmailDomainVerified flexibleBool `json:"xms_edov"`
+	Nonce               string       `json:"nonce"`
+	AuthorizedParty     string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexibleBool accepts true and "true"; some providers send booleans as strings
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// errOIDCHostNotAllowed rejects provider URLs that are not public https endpoints
+var errOIDCHostNotAllowed = errors.New("oidc: provider URLs must be https on a public host")
+
+// oidcBlockedNetworks are ranges outside net.IP's loopback, private and
+// link-local checks that still reach internal infrastructure
+var oidcBlockedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"), // Carrier-grade NAT, also used by cloud metadata services
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+// oidcClient talks to OpenID providers, caching discovery documents and keys.
+// Schools choose the issuer, so every provider URL must be https on a public
+// host and connections to internal addresses are refused at dial time.
+// The HTTP client is injectable so a mock provider can stand in for a real one.
+type oidcClient struct {
+	http *http.Client
+
+	// allowPrivateHosts lifts the public-host rule for in-process mock providers
+	allowPrivateHosts bool
+
+	mu       sync.Mutex
+	metadata map[string]cachedMetadata
+	keys     map[string]cachedKeys
+}
+
+type cachedMetadata struct {
+	metadata  *oidcMetadata
+	fetchedAt time.Time
+}
+
+type cachedKeys struct {
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func newOIDCClient(httpClient *http.Client) *oidcClient {
+	if httpClient == nil {
+		httpClient = newPublicHTTPClient()
+	}
+	return &oidcClient{
+		http:     httpClient,
+		metadata: map[string]cachedMetadata{},
+		keys:     map[string]cachedKeys{},
+	}
+}
+
+// newPublicHTTPClient returns a client that only connects to public addresses,
+// checked on the resolved IP so DNS cannot point it at internal hosts.
+// Environment proxies are not used, since the proxy would make the connection.
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: oidcHTTPTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errOIDCHostNotAllowed
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: oidcHTTPTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: oidcHTTPTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("oidc: too many redirects")
+			}
+			if req.URL.Scheme != "https" {
+				return errOIDCHostNotAllowed
+			}
+			return nil
+		},
+	}
+}
+
+// checkURL rejects provider URLs that are not https, carry credentials, or
+// name a loopback, private or link-local host
+func (o *oidcClient) checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return errOIDCHostNotAllowed
+	}
+	if o.allowPrivateHosts {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errOIDCHostNotAllowed
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return errOIDCHostNotAllowed
+	}
+	return nil
+}
+
+// publicIP reports whether an address is routable on the public internet
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range oidcBlockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// discover fetches the provider's discovery document. The issuer it reports
+// must match the configured one exactly, and the endpoints it lists must pass
+// the same URL checks as the issuer.
+func (o *oidcClient) discover(ctx context.Context, issuer string) (*oidcMetadata, error) {
+	if err := o.checkURL(issuer); err != nil {
+		return nil, fmt.Errorf("oidc discovery: issuer: %w", err)
+	}
+
+	o.mu.Lock()
+	cached, ok := o.metadata[issuer]
+	o.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < oidcCacheTTL {
+		return cached.metadata, nil
+	}
+
+	var metadata oidcMetadata
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := o.getJSON(ctx, wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: incomplete provider metadata")
+	}
+	for _, endpoint := range []string{metadata.AuthorizationEndpoint, metadata.TokenEndpoint, metadata.JWKSURI} {
+		if err := o.checkURL(endpoint); err != nil {
+			return nil, fmt.Errorf("oidc discovery: endpoint %q: %w", endpoint, err)
+		}
+	}
+
+	o.mu.Lock()
+	o.metadata[issuer] = cachedMetadata{metadata: &metadata, fetchedAt: time.Now()}
+	o.mu.Unlock()
+	return &metadata, nil
+}
+
+// authorizationURL builds the authorization code request with PKCE (S256)
+func (o *oidcClient) authorizationURL(metadata *oidcMetadata, provider *SSOProvider, redirectURI, state, nonce, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {provider.Scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// exchangeCode redeems an authorization code and returns the raw ID token
+func (o *oidcClient) exchangeCode(ctx context.Context, metadata *oidcMetadata, provider *SSOProvider, redirectURI, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {provider.ClientID},
+		"code_verifier": {verifier},
+	}
+	if provider.ClientSecret != "" {
+		form.Set("client_secret", provider.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := o.doJSON(req, &tokens)
+	if err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return "", fmt.Errorf("oidc token exchange: status %d: %s %s", status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return "", errors.New("oidc token exchange: no id_token in response")
+	}
+	return tokens.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and nonce
+func (o *oidcClient) verifyIDToken(ctx context.Context, metadata *oidcMetadata, provider *SSOProvider, rawIDToken, nonce string) (*idTokenClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+
+	var claims idTokenClaims
+	_, err := parser.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return o.signingKey(ctx, metadata.JWKSURI, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc id token: %w", err)
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("oidc id token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("oidc id token: missing subject")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != provider.ClientID {
+		return nil, errors.New("oidc id token: unexpected authorized party")
+	}
+	return &claims, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key
+// set once when the ID is unknown (providers rotate keys)
+func (o *oidcClient) signingKey(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	o.mu.Lock()
+	cached, ok := o.keys[jwksURI]
+	o.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < oidcCacheTTL {
+		if key := pickKey(cached.keys, kid); key != nil {
+			return key, nil
+		}
+	}
+
+	keys, err := o.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	o.mu.Lock()
+	o.keys[jwksURI] = cachedKeys{keys: keys, fetchedAt: time.Now()}
+	o.mu.Unlock()
+
+	if key := pickKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// pickKey selects a key by ID. Tokens without a kid are accepted only when
+// the provider publishes a single key.
+func pickKey(keys map[string]interface{}, kid string) interface{} {
+	if kid != "" {
+		return keys[kid]
+	}
+	if len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// fetchKeys downloads a JWK set, keeping the RSA and EC signature keys
+func (o *oidcClient) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil || len(e) > 4 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("oidc keys: no usable signing keys")
+	}
+	return keys, nil
+}
+
+// getJSON fetches a JSON document
+func (o *oidcClient) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	status, err := o.doJSON(req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", target, status)
+	}
+	return nil
+}
+
+// doJSON sends a request and decodes its JSON body, returning the status code
+func (o *oidcClient) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(v); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// emailDomain returns the lower-cased domain of an email address
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
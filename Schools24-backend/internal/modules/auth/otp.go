@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// otpDigits is the length of SMS login codes
+const otpDigits = 6
+
+// generateOTPCode returns a uniformly random numeric code
+func generateOTPCode() (string, error) {
+	max := big.NewInt(1_000_000)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
+
+// hashOTP hashes a code together with its user so equal codes never share a hash
+func hashOTP(userID uuid.UUID, code string) string {
+	return hashToken(userID.String() + ":" + code)
+}
+
+// normalizePhone converts user input into E.164 using the default country code
+// for national numbers. It also returns the forms a stored number may take
+// (E.164 or national), after stripping spaces and punctuation.
+func normalizePhone(phone, countryCode string) (string, []string, bool) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", nil, false
+		}
+	}
+	digits := b.String()
+
+	var e164, national string
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		e164 = digits
+		if countryCode != "" && strings.HasPrefix(digits, countryCode) {
+			national = strings.TrimPrefix(digits, countryCode)
+		}
+	default:
+		national = strings.TrimPrefix(digits, "0")
+		e164 = countryCode + national
+	}
+
+	// E.164 allows at most 15 digits after the plus sign
+	if !strings.HasPrefix(e164, "+") || len(e164) < 8 || len(e164) > 16 {
+		return "", nil, false
+	}
+
+	candidates := []string{e164}
+	if national != "" {
+		candidates = append(candidates, national, "0"+national)
+	}
+	return e164, candidates, true
+}
+
+// phoneAccountKey is the login throttling key for phone logins
+func phoneAccountKey(e164 string) string {
+	return "phone:" + e164
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/modules/parent"
 	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Repository handles database operations for auth
@@ -21,53 +23,21 @@ func NewRepository(db *database.PostgresDB) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateUser creates a new user in the database
-func (r *Repository) CreateUser(ctx context.Context, user *User) error {
-	query := `
-		INSERT INTO users (id, email, password_hash, role, full_name, phone, is_active, email_verified, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, created_at, updated_at
-	`
-
-	now := time.Now()
-	user.ID = uuid.New()
-	user.IsActive = true
-	user.EmailVerified = false
-	user.CreatedAt = now
-	user.UpdatedAt = now
-
-	err := r.db.QueryRow(ctx, query,
-		user.ID,
-		user.Email,
-		user.PasswordHash,
-		user.Role,
-		user.FullName,
-		user.Phone,
-		user.IsActive,
-		user.EmailVerified,
-		user.CreatedAt,
-		user.UpdatedAt,
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
-
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
-	}
-
-	return nil
-}
-
 // GetUserByEmail retrieves a user by email
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, role, full_name, phone, profile_picture_url, 
-		       is_active, email_verified, last_login_at, created_at, updated_at
-		FROM users
-		WHERE email = $1 AND is_active = true
+		SELECT u.id, u.school_id, u.email, u.password_hash, u.role, u.full_name, u.phone, u.profile_picture_url, 
+		       u.is_active, u.email_verified, u.last_login_at, u.created_at, u.updated_at
+		FROM users u
+		LEFT JOIN schools sc ON u.school_id = sc.id
+		WHERE u.email = $1 AND u.is_active = true
+		  AND (u.school_id IS NULL OR sc.is_active = true)
 	`
 
 	var user User
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID,
+		&user.SchoolID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
@@ -94,15 +64,19 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, e
 // GetUserByID retrieves a user by ID
 func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, role, full_name, phone, profile_picture_url, 
-		       is_active, email_verified, last_login_at, created_at, updated_at
-		FROM users
-		WHERE id = $1 AND is_active = true
+		SELECT u.id, u.school_id, u.email, u.password_hash, u.role, u.full_name, u.phone, u.profile_picture_url, 
+		       u.is_active, u.email_verified, u.last_login_at, u.created_at, u.updated_at
+		FROM users u
+		LEFT JOIN schools sc ON u.school_id = sc.id
+		WHERE u.id = $1 AND u.is_active = true
+		  AND (u.school_id IS NULL OR sc.is_active = true)
+		  AND ($2::uuid IS NULL OR u.school_id = $2)
 	`
 
 	var user User
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, id, tenant.SchoolID(ctx)).Scan(
 		&user.ID,
+		&user.SchoolID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
@@ -140,8 +114,8 @@ func (r *Repository) UpdateProfile(ctx context.Context, userID uuid.UUID, req *U
 		    phone = COALESCE($2, phone),
 		    profile_picture_url = COALESCE($3, profile_picture_url),
 		    updated_at = $4
-		WHERE id = $5
-		RETURNING id, email, password_hash, role, full_name, phone, profile_picture_url, 
+		WHERE id = $5 AND ($6::uuid IS NULL OR school_id = $6)
+		RETURNING id, school_id, email, password_hash, role, full_name, phone, profile_picture_url, 
 		          is_active, email_verified, last_login_at, created_at, updated_at
 	`
 
@@ -152,8 +126,10 @@ func (r *Repository) UpdateProfile(ctx context.Context, userID uuid.UUID, req *U
 		req.ProfilePictureURL,
 		time.Now(),
 		userID,
+		tenant.SchoolID(ctx),
 	).Scan(
 		&user.ID,
+		&user.SchoolID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
@@ -181,3 +157,1087 @@ func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error
 	err := r.db.QueryRow(ctx, query, email).Scan(&exists)
 	return exists, err
 }
+
+// GetSchoolIDByCode resolves an active school by its code
+func (r *Repository) GetSchoolIDByCode(ctx context.Context, code string) (*uuid.UUID, error) {
+	query := `SELECT id FROM schools WHERE code = $1 AND is_active = true`
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, query, code).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get school: %w", err)
+	}
+	return &id, nil
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *Repository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, mfa_verified, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	if err := r.db.Exec(ctx, query,
+		token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.MFAVerified, token.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, mfa_verified, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	var t RefreshToken
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.MFAVerified, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken revokes the old token and stores its replacement in one transaction.
+// It returns false if the old token had already been used or revoked.
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *RefreshToken) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	next.ID = uuid.New()
+	next.CreatedAt = time.Now()
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, mfa_verified, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(ctx, insertQuery,
+		next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.MFAVerified, next.CreatedAt,
+	); err != nil {
+		return false, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	revokeQuery := `
+		UPDATE refresh_tokens
+		SET revoked_at = $2, replaced_by = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, revokeQuery, oldID, next.CreatedAt, next.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily revokes every active token in a family and ends its session
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		WITH ended AS (
+			UPDATE user_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL
+		)
+		UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL
+	`
+	return r.db.Exec(ctx, query, time.Now(), familyID)
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user and ends all their sessions
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		WITH ended AS (
+			UPDATE user_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL
+		)
+		UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL
+	`
+	return r.db.Exec(ctx, query, time.Now(), userID)
+}
+
+// CreateSession records a new login session
+func (r *Repository) CreateSession(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO user_sessions (id, user_id, device, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+	`
+
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+
+	if err := r.db.Exec(ctx, query,
+		session.ID, session.UserID, session.Device, session.UserAgent, session.IPAddress, session.CreatedAt, session.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records activity on a session after a token refresh.
+// Families created before session tracking get their session row here.
+func (r *Repository) TouchSession(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO user_sessions (id, user_id, device, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			user_agent = EXCLUDED.user_agent,
+			ip_address = EXCLUDED.ip_address,
+			last_seen_at = EXCLUDED.last_seen_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE user_sessions.revoked_at IS NULL
+	`
+	return r.db.Exec(ctx, query,
+		session.ID, session.UserID, session.Device, session.UserAgent, session.IPAddress, time.Now(), session.ExpiresAt,
+	)
+}
+
+// GetActiveSessions retrieves a user's sessions that are neither revoked nor expired
+func (r *Repository) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	query := `
+		SELECT id, user_id, COALESCE(device, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       created_at, last_seen_at, expires_at, revoked_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Device, &s.UserAgent, &s.IPAddress,
+			&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetSession retrieves a session by ID
+func (r *Repository) GetSession(ctx context.Context, sessionID uuid.UUID) (*Session, error) {
+	query := `
+		SELECT id, user_id, COALESCE(device, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       created_at, last_seen_at, expires_at, revoked_at
+		FROM user_sessions
+		WHERE id = $1
+	`
+	var s Session
+	err := r.db.QueryRow(ctx, query, sessionID).Scan(&s.ID, &s.UserID, &s.Device, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &s, nil
+}
+
+// CreatePasswordReset stores a password reset token hash and
+// invalidates any earlier unused tokens for the same user
+func (r *Repository) CreatePasswordReset(ctx context.Context, reset *PasswordReset) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE password_resets SET used = true WHERE user_id = $1 AND used = false`, reset.UserID); err != nil {
+		return fmt.Errorf("failed to invalidate password resets: %w", err)
+	}
+
+	query := `
+		INSERT INTO password_resets (id, user_id, token, expires_at, used, created_at)
+		VALUES ($1, $2, $3, $4, false, $5)
+	`
+
+	reset.ID = uuid.New()
+	reset.CreatedAt = time.Now()
+
+	if _, err := tx.Exec(ctx, query, reset.ID, reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetPasswordResetByToken retrieves a password reset by its token hash
+func (r *Repository) GetPasswordResetByToken(ctx context.Context, tokenHash string) (*PasswordReset, error) {
+	query := `
+		SELECT id, user_id, token, expires_at, used, created_at
+		FROM password_resets
+		WHERE token = $1
+	`
+
+	var reset PasswordReset
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &reset.Used, &reset.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get password reset: %w", err)
+	}
+	return &reset, nil
+}
+
+// FindParentIDsByPhone returns active parent accounts whose own phone, or the
+// parent phone on a linked child's record, matches one of the given forms.
+// At most two IDs are returned; callers only need to know whether the match is unique.
+func (r *Repository) FindParentIDsByPhone(ctx context.Context, phones []string) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT u.id
+		FROM users u
+		LEFT JOIN schools sc ON u.school_id = sc.id
+		WHERE u.role = 'parent' AND u.is_active = true
+		  AND (u.school_id IS NULL OR sc.is_active = true)
+		  AND (
+			regexp_replace(COALESCE(u.phone, ''), '[^0-9+]', '', 'g') = ANY($1)
+			OR EXISTS (
+				SELECT 1 FROM parent_students ps
+				JOIN students s ON s.id = ps.student_id
+				WHERE ps.parent_user_id = u.id
+				  AND regexp_replace(COALESCE(s.parent_phone, ''), '[^0-9+]', '', 'g') = ANY($1)
+			)
+		  )
+		LIMIT 2
+	`
+	rows, err := r.db.Query(ctx, query, phones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find parents by phone: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateLoginOTP stores a login code hash and
+// invalidates any earlier unused codes for the same user
+func (r *Repository) CreateLoginOTP(ctx context.Context, otp *LoginOTP) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `UPDATE login_otps SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, now, otp.UserID); err != nil {
+		return fmt.Errorf("failed to invalidate login codes: %w", err)
+	}
+
+	query := `
+		INSERT INTO login_otps (id, user_id, code_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	otp.ID = uuid.New()
+	otp.CreatedAt = now
+
+	if _, err := tx.Exec(ctx, query, otp.ID, otp.UserID, otp.CodeHash, otp.ExpiresAt, otp.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create login code: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetLastLoginOTPSentAt returns when the newest login code for a user was issued
+func (r *Repository) GetLastLoginOTPSentAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var sentAt *time.Time
+	err := r.db.QueryRow(ctx, `SELECT MAX(created_at) FROM login_otps WHERE user_id = $1`, userID).Scan(&sentAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last login code: %w", err)
+	}
+	return sentAt, nil
+}
+
+// GetActiveLoginOTP retrieves the user's current unused, unexpired login code
+func (r *Repository) GetActiveLoginOTP(ctx context.Context, userID uuid.UUID) (*LoginOTP, error) {
+	query := `
+		SELECT id, user_id, code_hash, expires_at, attempts, used_at, created_at
+		FROM login_otps
+		WHERE user_id = $1 AND used_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var otp LoginOTP
+	err := r.db.QueryRow(ctx, query, userID, time.Now()).Scan(
+		&otp.ID, &otp.UserID, &otp.CodeHash, &otp.ExpiresAt, &otp.Attempts, &otp.UsedAt, &otp.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get login code: %w", err)
+	}
+	return &otp, nil
+}
+
+// RecordLoginOTPAttempt counts a verification attempt and returns the new total.
+// Counting before comparing keeps concurrent guesses within the limit.
+func (r *Repository) RecordLoginOTPAttempt(ctx context.Context, otpID uuid.UUID) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(ctx, `UPDATE login_otps SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`, otpID).Scan(&attempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login code attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+// ConsumeLoginOTP marks a login code as used.
+// It returns false if the code had already been used.
+func (r *Repository) ConsumeLoginOTP(ctx context.Context, otpID uuid.UUID) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx, `UPDATE login_otps SET used_at = $1 WHERE id = $2 AND used_at IS NULL`, time.Now(), otpID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume login code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CreateEmailVerification stores a verification token hash and
+// invalidates any earlier unused tokens for the same user
+func (r *Repository) CreateEmailVerification(ctx context.Context, v *EmailVerification) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `UPDATE email_verifications SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, now, v.UserID); err != nil {
+		return fmt.Errorf("failed to invalidate email verifications: %w", err)
+	}
+
+	query := `
+		INSERT INTO email_verifications (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	v.ID = uuid.New()
+	v.CreatedAt = now
+
+	if _, err := tx.Exec(ctx, query, v.ID, v.UserID, v.TokenHash, v.ExpiresAt, v.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create email verification: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetEmailVerificationByToken retrieves an email verification by its token hash
+func (r *Repository) GetEmailVerificationByToken(ctx context.Context, tokenHash string) (*EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM email_verifications
+		WHERE token_hash = $1
+	`
+
+	var v EmailVerification
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&v.ID, &v.UserID, &v.TokenHash, &v.ExpiresAt, &v.UsedAt, &v.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get email verification: %w", err)
+	}
+	return &v, nil
+}
+
+// GetLastEmailVerificationSentAt returns when the user's latest verification email was created
+func (r *Repository) GetLastEmailVerificationSentAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	query := `SELECT MAX(created_at) FROM email_verifications WHERE user_id = $1`
+	var sentAt *time.Time
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&sentAt); err != nil {
+		return nil, err
+	}
+	return sentAt, nil
+}
+
+// CompleteEmailVerification consumes the token and marks the email verified in one transaction.
+// It returns false if the token had already been used.
+func (r *Repository) CompleteEmailVerification(ctx context.Context, v *EmailVerification) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx, `UPDATE email_verifications SET used_at = $1 WHERE id = $2 AND used_at IS NULL`, now, v.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume email verification: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET email_verified = true, updated_at = $1 WHERE id = $2`, now, v.UserID); err != nil {
+		return false, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompletePasswordReset consumes the reset token, sets the new password hash
+// and revokes all refresh tokens and sessions of the user in one transaction.
+// It returns false if the token had already been used.
+func (r *Repository) CompletePasswordReset(ctx context.Context, reset *PasswordReset, passwordHash string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE password_resets SET used = true WHERE id = $1 AND used = false`, reset.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume password reset: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`, passwordHash, now, reset.UserID); err != nil {
+		return false, fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`, now, reset.UserID); err != nil {
+		return false, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE user_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`, now, reset.UserID); err != nil {
+		return false, fmt.Errorf("failed to end sessions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpgradePasswordHash replaces a password hash with a stronger hash of the same password.
+// It returns false if the password was changed in the meantime.
+func (r *Repository) UpgradePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx,
+		`UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3`,
+		newHash, userID, oldHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetUserMFA retrieves a user's TOTP enrollment
+func (r *Repository) GetUserMFA(ctx context.Context, userID uuid.UUID) (*UserMFA, error) {
+	query := `
+		SELECT user_id, totp_secret, enabled, last_used_step, enabled_at, created_at, updated_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+
+	var m UserMFA
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&m.UserID, &m.TOTPSecret, &m.Enabled, &m.LastUsedStep, &m.EnabledAt, &m.CreatedAt, &m.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user mfa: %w", err)
+	}
+	return &m, nil
+}
+
+// SavePendingMFA stores a new, not yet confirmed TOTP secret.
+// An enabled enrollment is never overwritten.
+func (r *Repository) SavePendingMFA(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_mfa (user_id, totp_secret, enabled, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, false, 0, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET totp_secret = EXCLUDED.totp_secret, last_used_step = 0, updated_at = EXCLUDED.updated_at
+		WHERE user_mfa.enabled = false
+	`
+	return r.db.Exec(ctx, query, userID, secret, time.Now())
+}
+
+// EnableMFA turns on 2FA and replaces the user's recovery codes in one transaction
+func (r *Repository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	query := `
+		UPDATE user_mfa
+		SET enabled = true, enabled_at = $2, last_used_step = $3, updated_at = $2
+		WHERE user_id = $1
+	`
+	if _, err := tx.Exec(ctx, query, userID, now, step); err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AdvanceMFAStep records the time step of an accepted TOTP code.
+// It returns false if that step (or a later one) was already used.
+func (r *Repository) AdvanceMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND last_used_step < $2`
+	tag, err := r.db.Pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record mfa step: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode consumes an unused recovery code, returning false if none matched
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM mfa_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (r *Repository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// ReplaceRecoveryCodes discards all recovery codes of a user and stores new ones
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteMFA removes a user's TOTP enrollment and recovery codes
+func (r *Repository) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user mfa: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// replaceRecoveryCodes swaps recovery codes inside an existing transaction
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetLoginLockedUntil returns the latest active lock on either the account or the IP
+func (r *Repository) GetLoginLockedUntil(ctx context.Context, accountKey, ip string) (*time.Time, error) {
+	query := `
+		SELECT MAX(locked_until) FROM login_failures
+		WHERE locked_until > $1
+		  AND ((kind = 'account' AND key = $2) OR (kind = 'ip' AND key = $3))
+	`
+	var lockedUntil *time.Time
+	if err := r.db.QueryRow(ctx, query, time.Now(), accountKey, ip).Scan(&lockedUntil); err != nil {
+		return nil, fmt.Errorf("failed to check login lock: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure increments the failure count for a key and returns it.
+// Account counts restart once the last failure is older than window. IP counts
+// restart window after their first failure, so steady failures from a shared
+// school network never pile up past one window.
+func (r *Repository) RecordLoginFailure(ctx context.Context, kind, key string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_failures (kind, key, failures, first_failed_at, last_failed_at)
+		VALUES ($1, $2, 1, $3, $3)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE WHEN ` + loginWindowExpired + ` THEN 1 ELSE login_failures.failures + 1 END,
+			first_failed_at = CASE WHEN ` + loginWindowExpired + ` THEN $3 ELSE login_failures.first_failed_at END,
+			locked_until = CASE WHEN ` + loginWindowExpired + ` THEN NULL ELSE login_failures.locked_until END,
+			last_failed_at = $3
+		RETURNING failures
+	`
+	now := time.Now()
+	var failures int
+	if err := r.db.QueryRow(ctx, query, kind, key, now, now.Add(-window)).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return failures, nil
+}
+
+// loginWindowExpired is true when a login_failures row's window ($4 is its
+// start cutoff) has passed: a fixed window for IPs, a quiet period for accounts
+const loginWindowExpired = `(CASE WHEN login_failures.kind = 'ip'
+			THEN login_failures.first_failed_at ELSE login_failures.last_failed_at END) < $4`
+
+// SetLoginLockedUntil blocks further attempts for a key until the given time
+func (r *Repository) SetLoginLockedUntil(ctx context.Context, kind, key string, until time.Time) error {
+	query := `UPDATE login_failures SET locked_until = $3 WHERE kind = $1 AND key = $2`
+	return r.db.Exec(ctx, query, kind, key, until)
+}
+
+// ForgiveLoginFailure takes one failure off a key's count
+func (r *Repository) ForgiveLoginFailure(ctx context.Context, kind, key string) error {
+	query := `
+		UPDATE login_failures SET failures = GREATEST(failures - 1, 0)
+		WHERE kind = $1 AND key = $2 AND failures > 0
+	`
+	return r.db.Exec(ctx, query, kind, key)
+}
+
+// ClearLoginFailures forgets all failures for a key
+func (r *Repository) ClearLoginFailures(ctx context.Context, kind, key string) error {
+	query := `DELETE FROM login_failures WHERE kind = $1 AND key = $2`
+	return r.db.Exec(ctx, query, kind, key)
+}
+
+// GetAdditionalRoleNames returns the names of roles assigned on top of users.role
+func (r *Repository) GetAdditionalRoleNames(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT r.name FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// CreatePendingParent creates an inactive parent account and queues it for admin approval
+func (r *Repository) CreatePendingParent(ctx context.Context, user *User, childDetails string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.IsActive = false
+	user.EmailVerified = false
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO users (id, school_id, email, password_hash, role, full_name, phone, is_active, email_verified, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, false, false, $8, $8)
+	`, user.ID, user.SchoolID, user.Email, user.PasswordHash, user.Role, user.FullName, user.Phone, now); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO parent_signups (user_id, school_id, child_details)
+		VALUES ($1, $2, NULLIF($3, ''))
+	`, user.ID, user.SchoolID, childDetails); err != nil {
+		return fmt.Errorf("failed to queue parent sign-up: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+const invitationQuery = `
+	SELECT i.id, i.school_id, sc.name, i.email, i.role, i.full_name, i.student_id, i.relationship,
+	       i.class_id, i.admission_number, i.roll_number, i.date_of_birth, i.expires_at, i.accepted_at, i.revoked_at
+	FROM invitations i
+	JOIN schools sc ON sc.id = i.school_id
+`
+
+func (r *Repository) getInvitation(ctx context.Context, where string, arg interface{}) (*Invitation, error) {
+	var inv Invitation
+	err := r.db.QueryRow(ctx, invitationQuery+where, arg).Scan(
+		&inv.ID, &inv.SchoolID, &inv.SchoolName, &inv.Email, &inv.Role, &inv.FullName, &inv.StudentID, &inv.Relationship,
+		&inv.ClassID, &inv.AdmissionNumber, &inv.RollNumber, &inv.DateOfBirth, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// GetInvitationByID retrieves an invitation
+func (r *Repository) GetInvitationByID(ctx context.Context, id uuid.UUID) (*Invitation, error) {
+	return r.getInvitation(ctx, `WHERE i.id = $1`, id)
+}
+
+// GetInvitationByToken retrieves an invitation by the hash of its link token
+func (r *Repository) GetInvitationByToken(ctx context.Context, tokenHash string) (*Invitation, error) {
+	return r.getInvitation(ctx, `WHERE i.token_hash = $1 AND sc.is_active = true`, tokenHash)
+}
+
+// SetInvitationToken replaces the link token of an open invitation and restarts its expiry.
+// Returns false if the invitation was accepted or revoked.
+func (r *Repository) SetInvitationToken(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE invitations SET token_hash = $2, expires_at = $3
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, id, tokenHash, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkInvitationSent records when the invitation email went out
+func (r *Repository) MarkInvitationSent(ctx context.Context, id uuid.UUID) error {
+	return r.db.Exec(ctx, `UPDATE invitations SET sent_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+}
+
+// AcceptInvitation creates the invited user, consumes the invitation and applies
+// its student profile or parent link in one transaction. It returns false if the
+// invitation is no longer open, and whether a requested child link was made.
+func (r *Repository) AcceptInvitation(ctx context.Context, inv *Invitation, user *User) (bool, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.SchoolID = &inv.SchoolID
+	user.Email = inv.Email
+	user.Role = inv.Role
+	user.IsActive = true
+	user.EmailVerified = true // The invitation link proves the address
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO users (id, school_id, email, password_hash, role, full_name, phone, is_active, email_verified, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, true, $8, $8)
+	`, user.ID, user.SchoolID, user.Email, user.PasswordHash, user.Role, user.FullName, user.Phone, now); err != nil {
+		return false, false, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE invitations SET accepted_at = $2, accepted_user_id = $3
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+	`, inv.ID, now, user.ID)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to consume invitation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, false, nil
+	}
+
+	if inv.Role == RoleStudent && inv.ClassID != nil {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO students (user_id, admission_number, roll_number, class_id, date_of_birth, admission_date, school_id)
+			VALUES ($1, $2, $3, $4, $5, CURRENT_DATE, $6)
+		`, user.ID, inv.AdmissionNumber, inv.RollNumber, inv.ClassID, inv.DateOfBirth, inv.SchoolID); err != nil {
+			return false, false, fmt.Errorf("failed to create student profile: %w", err)
+		}
+	}
+
+	linked := false
+	if inv.Role == RoleParent && inv.StudentID != nil {
+		// Lock the child so concurrent links cannot exceed the guardian limit
+		var guardians int
+		err := tx.QueryRow(ctx, `
+			SELECT (SELECT COUNT(*) FROM parent_students WHERE student_id = s.id)
+			FROM students s WHERE s.id = $1 AND s.school_id = $2
+			FOR UPDATE
+		`, inv.StudentID, inv.SchoolID).Scan(&guardians)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return false, false, err
+		}
+		if err == nil && guardians < parent.MaxGuardiansPerStudent {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO parent_students (parent_user_id, student_id, relationship, school_id)
+				VALUES ($1, $2, COALESCE($3, 'guardian'), $4)
+			`, user.ID, inv.StudentID, inv.Relationship, inv.SchoolID); err != nil {
+				return false, false, fmt.Errorf("failed to link child: %w", err)
+			}
+			linked = true
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, false, err
+	}
+	return true, linked, nil
+}
+
+// ssoProviderColumns selects a provider in the order scanned by scanSSOProvider
+const ssoProviderColumns = `
+	id, school_id, slug, display_name, issuer, client_id, COALESCE(client_secret, ''),
+	scopes, allowed_domains, enabled, created_at, updated_at
+`
+
+// scanSSOProvider scans a provider selected with ssoProviderColumns
+func scanSSOProvider(row pgx.Row) (*SSOProvider, error) {
+	var p SSOProvider
+	err := row.Scan(&p.ID, &p.SchoolID, &p.Slug, &p.DisplayName, &p.Issuer, &p.ClientID, &p.ClientSecret,
+		&p.Scopes, &p.AllowedDomains, &p.Enabled, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.HasClientSecret = p.ClientSecret != ""
+	return &p, nil
+}
+
+// GetSSOProviders lists the providers of a school
+func (r *Repository) GetSSOProviders(ctx context.Context, schoolID uuid.UUID) ([]SSOProvider, error) {
+	query := `SELECT ` + ssoProviderColumns + ` FROM sso_providers WHERE school_id = $1 ORDER BY display_name`
+	rows, err := r.db.Query(ctx, query, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sso providers: %w", err)
+	}
+	defer rows.Close()
+
+	providers := []SSOProvider{}
+	for rows.Next() {
+		p, err := scanSSOProvider(rows)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, *p)
+	}
+	return providers, rows.Err()
+}
+
+// GetSSOProviderBySlug retrieves a school's provider
+func (r *Repository) GetSSOProviderBySlug(ctx context.Context, schoolID uuid.UUID, slug string) (*SSOProvider, error) {
+	query := `SELECT ` + ssoProviderColumns + ` FROM sso_providers WHERE school_id = $1 AND slug = $2`
+	p, err := scanSSOProvider(r.db.QueryRow(ctx, query, schoolID, slug))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sso provider: %w", err)
+	}
+	return p, nil
+}
+
+// GetSSOProviderByID retrieves a provider
+func (r *Repository) GetSSOProviderByID(ctx context.Context, id uuid.UUID) (*SSOProvider, error) {
+	query := `SELECT ` + ssoProviderColumns + ` FROM sso_providers WHERE id = $1`
+	p, err := scanSSOProvider(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sso provider: %w", err)
+	}
+	return p, nil
+}
+
+// SaveSSOProvider creates or updates a school's provider by slug
+func (r *Repository) SaveSSOProvider(ctx context.Context, schoolID uuid.UUID, slug string, req *SaveSSOProviderRequest) (uuid.UUID, error) {
+	query := `
+		INSERT INTO sso_providers (school_id, slug, display_name, issuer, client_id, client_secret, scopes, allowed_domains, enabled)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+		ON CONFLICT (school_id, slug) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			issuer = EXCLUDED.issuer,
+			client_id = EXCLUDED.client_id,
+			client_secret = CASE WHEN $10 THEN EXCLUDED.client_secret ELSE sso_providers.client_secret END,
+			scopes = EXCLUDED.scopes,
+			allowed_domains = EXCLUDED.allowed_domains,
+			enabled = EXCLUDED.enabled,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`
+	secret := ""
+	if req.ClientSecret != nil {
+		secret = *req.ClientSecret
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, query, schoolID, slug, req.DisplayName, req.Issuer, req.ClientID, secret,
+		req.Scopes, req.AllowedDomains, enabled, req.ClientSecret != nil).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to save sso provider: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteSSOProvider removes a school's provider and the identities linked through it
+func (r *Repository) DeleteSSOProvider(ctx context.Context, schoolID uuid.UUID, slug string) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM sso_providers WHERE school_id = $1 AND slug = $2`, schoolID, slug)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete sso provider: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CreateSSOLoginState stores a pending authorization request
+func (r *Repository) CreateSSOLoginState(ctx context.Context, providerID uuid.UUID, stateHash, nonce, verifier string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO sso_login_states (provider_id, state_hash, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if err := r.db.Exec(ctx, query, providerID, stateHash, nonce, verifier, expiresAt); err != nil {
+		return fmt.Errorf("failed to store sso state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeSSOLoginState marks a pending authorization request as used and returns it.
+// It returns nil for unknown, expired or already used states.
+func (r *Repository) ConsumeSSOLoginState(ctx context.Context, stateHash string) (*ssoLoginState, error) {
+	query := `
+		UPDATE sso_login_states SET used_at = $2
+		WHERE state_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING id, provider_id, nonce, code_verifier
+	`
+	var st ssoLoginState
+	err := r.db.QueryRow(ctx, query, stateHash, time.Now()).Scan(&st.ID, &st.ProviderID, &st.Nonce, &st.CodeVerifier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume sso state: %w", err)
+	}
+	return &st, nil
+}
+
+// GetIdentityUserID finds the user linked to a provider account
+func (r *Repository) GetIdentityUserID(ctx context.Context, providerID uuid.UUID, subject string) (*uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow(ctx,
+		`SELECT user_id FROM user_identities WHERE provider_id = $1 AND subject = $2`,
+		providerID, subject,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	return &userID, nil
+}
+
+// LinkUserIdentity links a provider account to a user and marks the user's
+// email verified, since the provider vouched for it. Returns false if the
+// account was already linked.
+func (r *Repository) LinkUserIdentity(ctx context.Context, userID, providerID uuid.UUID, subject, email string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO user_identities (user_id, provider_id, subject, email, linked_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (provider_id, subject) DO NOTHING
+	`, userID, providerID, subject, email, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to link identity: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET email_verified = true, updated_at = $1 WHERE id = $2`, now, userID); err != nil {
+		return false, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TouchUserIdentity records a sign-in through a linked provider account
+func (r *Repository) TouchUserIdentity(ctx context.Context, providerID uuid.UUID, subject, email string) error {
+	query := `
+		UPDATE user_identities SET last_login_at = $3, email = $4
+		WHERE provider_id = $1 AND subject = $2
+	`
+	return r.db.Exec(ctx, query, providerID, subject, time.Now(), email)
+}
@@ -2,19 +2,39 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/mailer"
 	"github.com/schools24/backend/internal/shared/middleware"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/schools24/backend/internal/shared/password"
+	"github.com/schools24/backend/internal/shared/sms"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
+// AuditLogger records security events (implemented by admin.Repository)
+type AuditLogger interface {
+	LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error
+}
+
 // Service handles authentication business logic
 type Service struct {
-	repo   *Repository
-	config *config.Config
+	repo      *Repository
+	mail      mailer.Sender
+	smsSender sms.Sender
+	passwords *password.Manager
+	denylist  *middleware.TokenDenylist
+	audit     AuditLogger
+	keys      *middleware.KeySet
+	sso       ssoStore
+	oidc      *oidcClient
+	config    *config.Config
 }
 
 // Common errors
@@ -22,18 +42,77 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrEmailExists        = errors.New("email already registered")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrSchoolNotFound     = errors.New("school not found")
+
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidResetToken   = errors.New("invalid or expired password reset token")
+
+	ErrEmailNotVerified         = errors.New("email address not verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa challenge")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication not enrolled")
+
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrInvalidPhone = errors.New("invalid phone number")
+	ErrInvalidOTP   = errors.New("invalid or expired login code")
+
+	ErrRegistrationClosed = errors.New("self-registration is disabled, ask your school for an invitation")
+	ErrInvalidInvitation  = errors.New("invalid or expired invitation")
+
+	ErrImpersonationNotAllowed = errors.New("this user cannot be impersonated")
+
+	ErrSSOProviderNotFound = errors.New("sign-in provider not found")
+	ErrInvalidSSOProvider  = errors.New("invalid sign-in provider configuration")
+	ErrSSODomainsRequired  = errors.New("list the email domains that may sign in with this provider")
+	ErrInvalidSSOState     = errors.New("invalid or expired sign-in request")
+	ErrSSOFailed           = errors.New("sign-in with the identity provider failed")
+	ErrSSOEmailNotVerified = errors.New("the identity provider did not confirm the email address")
+	ErrSSOEmailNotAllowed  = errors.New("this email domain cannot sign in to the school")
+	ErrSSONoAccount        = errors.New("no account matches this identity, ask your school for an invitation")
 )
 
+// impersonableRoles are the accounts support staff may view as; admins are
+// excluded so impersonation never widens the actor's access
+var impersonableRoles = map[string]bool{
+	RoleStudent: true,
+	RoleParent:  true,
+	RoleTeacher: true,
+	RoleStaff:   true,
+}
+
 // NewService creates a new auth service
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, mail mailer.Sender, smsSender sms.Sender, passwords *password.Manager, denylist *middleware.TokenDenylist, audit AuditLogger, keys *middleware.KeySet, cfg *config.Config) *Service {
 	return &Service{
-		repo:   repo,
-		config: cfg,
+		repo:      repo,
+		mail:      mail,
+		smsSender: smsSender,
+		passwords: passwords,
+		denylist:  denylist,
+		audit:     audit,
+		keys:      keys,
+		sso:       repo,
+		oidc:      newOIDCClient(nil),
+		config:    cfg,
 	}
 }
 
-// Register creates a new user account
+// Register signs up a parent when PARENT_SIGNUP_ENABLED is set. The account stays
+// inactive until a school admin approves it; every other role joins by invitation.
 func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
+	if !s.config.Auth.ParentSignupEnabled {
+		return nil, ErrRegistrationClosed
+	}
+	if err := s.passwords.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
 	// Check if email exists
 	exists, err := s.repo.EmailExists(ctx, req.Email)
 	if err != nil {
@@ -43,52 +122,1089 @@ func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*AuthResp
 		return nil, ErrEmailExists
 	}
 
+	// Resolve school
+	schoolCode := req.SchoolCode
+	if schoolCode == "" {
+		schoolCode = database.DefaultSchoolCode
+	}
+	schoolID, err := s.repo.GetSchoolIDByCode(ctx, schoolCode)
+	if err != nil {
+		return nil, err
+	}
+	if schoolID == nil {
+		return nil, ErrSchoolNotFound
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwords.Hash(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create user
+	// Create the pending account
 	user := &User{
+		SchoolID:     schoolID,
 		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		Role:         req.Role,
+		PasswordHash: hashedPassword,
+		Role:         RoleParent,
 		FullName:     req.FullName,
 	}
 	if req.Phone != "" {
 		user.Phone = &req.Phone
 	}
 
-	if err := s.repo.CreateUser(ctx, user); err != nil {
+	if err := s.repo.CreatePendingParent(ctx, user, req.ChildDetails); err != nil {
 		return nil, err
 	}
+	if err := s.passwords.Remember(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for %s: %v", user.Email, err)
+	}
 
-	// Generate tokens
-	return s.generateAuthResponse(user)
+	auditCtx := tenant.WithSchoolID(ctx, *schoolID)
+	if err := s.audit.LogAudit(auditCtx, &user.ID, "signup.requested", "user", &user.ID, nil, nil, req.IPAddress, req.UserAgent); err != nil {
+		log.Printf("Failed to audit parent sign-up for %s: %v", user.Email, err)
+	}
+
+	// The verification email is sent once an admin approves the account
+	return &AuthResponse{User: user, ApprovalPending: true}, nil
+}
+
+// SendInvitation issues a fresh link for an open invitation and emails it.
+// Earlier links stop working and the expiry restarts.
+func (s *Service) SendInvitation(ctx context.Context, invitationID uuid.UUID) error {
+	inv, err := s.repo.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if inv == nil || inv.AcceptedAt != nil || inv.RevokedAt != nil {
+		return ErrInvalidInvitation
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(s.config.Auth.InvitationTTLHours) * time.Hour
+	updated, err := s.repo.SetInvitationToken(ctx, inv.ID, tokenHash, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrInvalidInvitation
+	}
+
+	greeting := "Hello"
+	if inv.FullName != nil {
+		greeting = "Hello " + *inv.FullName
+	}
+	msg := &mailer.Message{
+		To:      inv.Email,
+		Subject: fmt.Sprintf("You're invited to join %s on Schools24", inv.SchoolName),
+		Body: fmt.Sprintf(
+			"%s,\n\n%s has invited you to Schools24 as a %s. Use the link below to set your password. It expires in %d hours.\n\n%s/accept-invitation?token=%s\n\nIf you did not expect this invitation, you can ignore this email.",
+			greeting, inv.SchoolName, inv.Role, s.config.Auth.InvitationTTLHours, s.config.App.FrontendURL, token,
+		),
+	}
+	if err := s.mail.Send(ctx, msg); err != nil {
+		return err
+	}
+	return s.repo.MarkInvitationSent(ctx, inv.ID)
+}
+
+// AcceptInvitation creates the invited account and signs it in
+func (s *Service) AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*AuthResponse, error) {
+	inv, err := s.repo.GetInvitationByToken(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil || inv.AcceptedAt != nil || inv.RevokedAt != nil || time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+
+	if err := s.passwords.Validate(req.Password); err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.EmailExists(ctx, inv.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailExists
+	}
+
+	hashedPassword, err := s.passwords.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{
+		PasswordHash: hashedPassword,
+		FullName:     req.FullName,
+	}
+	if req.Phone != "" {
+		user.Phone = &req.Phone
+	}
+
+	accepted, linked, err := s.repo.AcceptInvitation(ctx, inv, user)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvalidInvitation
+	}
+	if inv.StudentID != nil && !linked {
+		log.Printf("Invitation %s accepted without linking student %s (missing or at guardian limit)", inv.ID, *inv.StudentID)
+	}
+	if err := s.passwords.Remember(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for %s: %v", user.Email, err)
+	}
+
+	auditCtx := tenant.WithSchoolID(ctx, inv.SchoolID)
+	details := map[string]interface{}{"invitation_id": inv.ID, "role": inv.Role}
+	if err := s.audit.LogAudit(auditCtx, &user.ID, "invitation.accepted", "user", &user.ID, nil, details, req.IPAddress, req.UserAgent); err != nil {
+		log.Printf("Failed to audit invitation acceptance for %s: %v", user.Email, err)
+	}
+
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return s.generateAuthResponse(ctx, user, false, sessionClient{
+		ipAddress:  req.IPAddress,
+		userAgent:  req.UserAgent,
+		deviceName: req.DeviceName,
+	})
 }
 
 // Login authenticates a user and returns tokens
 func (s *Service) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
+	key := accountKey(req.Email)
+	if err := s.checkLoginThrottle(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		// Unknown emails are counted too, so lockouts do not reveal which accounts exist
+		if err := s.recordLoginFailure(ctx, key, nil, req.IPAddress, req.UserAgent); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	match, err := s.passwords.Verify(req.Password, user.PasswordHash)
+	if err != nil {
+		log.Printf("Failed to verify password hash of user %s: %v", user.ID, err)
+	}
+	if !match {
+		if err := s.recordLoginFailure(ctx, key, user, req.IPAddress, req.UserAgent); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
+	s.upgradePasswordHash(ctx, user, req.Password)
+
+	// Checked after the password so the response does not reveal unverified accounts
+	if s.config.Auth.RequireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Enrolled users get a challenge instead of tokens.
+	// Failures are only cleared once the second factor passes too.
+	mfa, err := s.repo.GetUserMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		challenge, err := s.generateMFAChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResponse{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	if err := s.clearLoginFailures(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
 
 	// Update last login
 	_ = s.repo.UpdateLastLogin(ctx, user.ID)
 
 	// Generate tokens
-	return s.generateAuthResponse(user)
+	return s.generateAuthResponse(ctx, user, false, sessionClient{
+		ipAddress:  req.IPAddress,
+		userAgent:  req.UserAgent,
+		deviceName: req.DeviceName,
+	})
+}
+
+// LoginMFA completes a two-step login with a TOTP or recovery code.
+// A challenge token is consumed on success.
+func (s *Service) LoginMFA(ctx context.Context, req *MFALoginRequest) (*AuthResponse, error) {
+	claims, err := s.keys.Parse(req.MFAToken)
+	if err != nil || claims.Purpose != middleware.PurposeMFAChallenge {
+		return nil, ErrInvalidMFAToken
+	}
+	revoked, err := s.denylist.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+
+	key := accountKey(claims.Email)
+	if err := s.checkLoginThrottle(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	mfa, err := s.repo.GetUserMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.checkSecondFactor(ctx, mfa, req.Code, req.RecoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.recordLoginFailure(ctx, key, user, req.IPAddress, req.UserAgent); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.denylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, err
+	}
+	if err := s.clearLoginFailures(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return s.generateAuthResponse(ctx, user, true, sessionClient{
+		ipAddress:  req.IPAddress,
+		userAgent:  req.UserAgent,
+		deviceName: req.DeviceName,
+	})
+}
+
+// RequestLoginOTP texts a login code to the parent account using the phone number.
+// Like ForgotPassword, it never reveals whether the number is registered.
+func (s *Service) RequestLoginOTP(ctx context.Context, req *OTPRequest) error {
+	phone, candidates, ok := normalizePhone(req.Phone, s.config.SMS.DefaultCountryCode)
+	if !ok {
+		return ErrInvalidPhone
+	}
+	if err := s.checkLoginThrottle(ctx, phoneAccountKey(phone), req.IPAddress); err != nil {
+		return err
+	}
+
+	// Numbers shared by several accounts cannot identify anyone
+	ids, err := s.repo.FindParentIDsByPhone(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return nil
+	}
+	userID := ids[0]
+
+	lastSent, err := s.repo.GetLastLoginOTPSentAt(ctx, userID)
+	if err != nil {
+		return err
+	}
+	throttle := time.Duration(s.config.Auth.OTPResendSeconds) * time.Second
+	if lastSent != nil && time.Since(*lastSent) < throttle {
+		return nil
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateLoginOTP(ctx, &LoginOTP{
+		UserID:    userID,
+		CodeHash:  hashOTP(userID, code),
+		ExpiresAt: time.Now().Add(time.Duration(s.config.Auth.OTPTTLMinutes) * time.Minute),
+	}); err != nil {
+		return err
+	}
+
+	msg := &sms.Message{
+		To: phone,
+		Body: fmt.Sprintf("%s is your Schools24 login code. It expires in %d minutes. Never share it with anyone.",
+			code, s.config.Auth.OTPTTLMinutes),
+	}
+	if err := s.smsSender.Send(ctx, msg); err != nil {
+		// Do not leak delivery failures to the caller
+		log.Printf("Failed to send login code to user %s: %v", userID, err)
+	}
+	return nil
+}
+
+// LoginOTP completes a phone login. Each code allows a limited number of
+// attempts and wrong codes count towards the login lockout.
+func (s *Service) LoginOTP(ctx context.Context, req *OTPLoginRequest) (*AuthResponse, error) {
+	phone, candidates, ok := normalizePhone(req.Phone, s.config.SMS.DefaultCountryCode)
+	if !ok {
+		return nil, ErrInvalidPhone
+	}
+	key := phoneAccountKey(phone)
+	if err := s.checkLoginThrottle(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	var user *User
+	ids, err := s.repo.FindParentIDsByPhone(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 1 {
+		if user, err = s.repo.GetUserByID(ctx, ids[0]); err != nil {
+			return nil, err
+		}
+	}
+	if user == nil {
+		if err := s.recordLoginFailure(ctx, key, nil, req.IPAddress, req.UserAgent); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidOTP
+	}
+
+	if err := s.checkLoginOTP(ctx, user.ID, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			if err := s.recordLoginFailure(ctx, key, user, req.IPAddress, req.UserAgent); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// The code proves the phone, not the second factor of an enrolled account
+	mfa, err := s.repo.GetUserMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		challenge, err := s.generateMFAChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResponse{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	if err := s.clearLoginFailures(ctx, key, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return s.generateAuthResponse(ctx, user, false, sessionClient{
+		ipAddress:  req.IPAddress,
+		userAgent:  req.UserAgent,
+		deviceName: req.DeviceName,
+	})
+}
+
+// checkLoginOTP verifies and consumes the user's current login code.
+// A code is discarded once its attempts are used up.
+func (s *Service) checkLoginOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	otp, err := s.repo.GetActiveLoginOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if otp == nil {
+		return ErrInvalidOTP
+	}
+
+	attempts, err := s.repo.RecordLoginOTPAttempt(ctx, otp.ID)
+	if err != nil {
+		return err
+	}
+	maxAttempts := s.config.Auth.OTPMaxAttempts
+	if attempts > maxAttempts {
+		return ErrInvalidOTP
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(hashOTP(userID, code))) != 1 {
+		if attempts == maxAttempts {
+			if _, err := s.repo.ConsumeLoginOTP(ctx, otp.ID); err != nil {
+				return err
+			}
+		}
+		return ErrInvalidOTP
+	}
+
+	consumed, err := s.repo.ConsumeLoginOTP(ctx, otp.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// checkLoginThrottle rejects attempts while the account or IP is delayed or locked
+func (s *Service) checkLoginThrottle(ctx context.Context, key, ip string) error {
+	lockedUntil, err := s.repo.GetLoginLockedUntil(ctx, key, ip)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		return &ThrottleError{RetryAfter: time.Until(*lockedUntil)}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt against the account and the IP,
+// applies progressive delays, and audits the moment a lockout starts
+func (s *Service) recordLoginFailure(ctx context.Context, key string, user *User, ip, userAgent string) error {
+	window := time.Duration(s.config.Auth.LoginFailureWindowMinutes) * time.Minute
+	lockout := time.Duration(s.config.Auth.LoginLockoutMinutes) * time.Minute
+
+	targets := []struct {
+		kind      string
+		key       string
+		threshold int
+	}{
+		{LoginFailureAccount, key, s.config.Auth.LoginMaxAccountFailures},
+		{LoginFailureIP, ip, s.config.Auth.LoginMaxIPFailures},
+	}
+
+	for _, t := range targets {
+		if t.key == "" {
+			continue
+		}
+		failures, err := s.repo.RecordLoginFailure(ctx, t.kind, t.key, window)
+		if err != nil {
+			return err
+		}
+		delay := loginBackoff(failures, t.threshold, lockout)
+		if delay == 0 {
+			continue
+		}
+		lockedUntil := time.Now().Add(delay)
+		if err := s.repo.SetLoginLockedUntil(ctx, t.kind, t.key, lockedUntil); err != nil {
+			return err
+		}
+		if failures == t.threshold {
+			s.auditLockout(ctx, t.kind, t.key, user, failures, lockedUntil, ip, userAgent)
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures resets the account's failures after a successful login.
+// The IP only loses one failure per success: a shared school network sheds
+// its users' typos as they log in, while an attacker holding one valid
+// password cannot wipe out a spraying run with a single login.
+func (s *Service) clearLoginFailures(ctx context.Context, key, ip string) error {
+	if err := s.repo.ClearLoginFailures(ctx, LoginFailureAccount, key); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.repo.ForgiveLoginFailure(ctx, LoginFailureIP, ip)
+}
+
+// auditLockout writes a lockout event, scoped to the user's school when known
+func (s *Service) auditLockout(ctx context.Context, kind, key string, user *User, failures int, lockedUntil time.Time, ip, userAgent string) {
+	var userID *uuid.UUID
+	if kind == LoginFailureAccount && user != nil {
+		userID = &user.ID
+		if user.SchoolID != nil {
+			ctx = tenant.WithSchoolID(ctx, *user.SchoolID)
+		}
+	}
+
+	details := map[string]interface{}{
+		"kind":         kind,
+		"key":          key,
+		"failures":     failures,
+		"locked_until": lockedUntil,
+	}
+	if err := s.audit.LogAudit(ctx, nil, "login.locked", "login_lockout", userID, nil, details, ip, userAgent); err != nil {
+		log.Printf("Failed to audit login lockout for %s %s: %v", kind, key, err)
+	}
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+// Presenting a token that was already rotated revokes its whole family.
+func (s *Service) Refresh(ctx context.Context, req *RefreshRequest) (*AuthResponse, error) {
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		if stored.ReplacedBy != nil {
+			// A rotated token came back: assume it was stolen
+			if err := s.endSession(ctx, stored.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		_ = s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	refreshToken, refreshHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	next := &RefreshToken{
+		UserID:      user.ID,
+		FamilyID:    stored.FamilyID,
+		TokenHash:   refreshHash,
+		ExpiresAt:   time.Now().Add(s.refreshExpiry()),
+		MFAVerified: stored.MFAVerified,
+	}
+
+	rotated, err := s.repo.RotateRefreshToken(ctx, stored.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Lost a race against another refresh with the same token
+		if err := s.endSession(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	client := sessionClient{ipAddress: req.IPAddress, userAgent: req.UserAgent}
+	if err := s.repo.TouchSession(ctx, &Session{
+		ID:        stored.FamilyID,
+		UserID:    user.ID,
+		Device:    client.device(),
+		UserAgent: client.userAgent,
+		IPAddress: client.ipAddress,
+		ExpiresAt: next.ExpiresAt,
+	}); err != nil {
+		log.Printf("Failed to update session %s: %v", stored.FamilyID, err)
+	}
+
+	accessToken, err := s.generateAccessToken(ctx, user, next.MFAVerified, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.config.JWT.ExpirationHours * 3600, // In seconds
+	}, nil
+}
+
+// ForgotPassword emails a single-use reset link if the account exists.
+// It never reveals whether the email is registered.
+func (s *Service) ForgotPassword(ctx context.Context, req *PasswordResetRequest) error {
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(s.config.Auth.PasswordResetTTLMinutes) * time.Minute
+	if err := s.repo.CreatePasswordReset(ctx, &PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Schools24 password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can be used once.\n\n%s/reset-password?token=%s\n\nIf you did not ask for this, you can ignore this email.",
+			user.FullName, s.config.Auth.PasswordResetTTLMinutes, s.config.App.FrontendURL, token,
+		),
+	}
+	if err := s.mail.Send(ctx, msg); err != nil {
+		// Do not leak delivery failures to the caller
+		log.Printf("Failed to send password reset email to %s: %v", user.Email, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user out everywhere
+func (s *Service) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	reset, err := s.repo.GetPasswordResetByToken(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if reset == nil || reset.Used || time.Now().After(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.passwords.Validate(req.Password); err != nil {
+		return err
+	}
+	user, err := s.GetMe(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.passwords.CheckReuse(ctx, user.ID, req.Password, user.PasswordHash); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwords.Hash(req.Password)
+	if err != nil {
+		return err
+	}
+
+	completed, err := s.repo.CompletePasswordReset(ctx, reset, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !completed {
+		return ErrInvalidResetToken
+	}
+	if err := s.passwords.Remember(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for user %s: %v", user.ID, err)
+	}
+
+	// Cut off access tokens that are still in flight
+	return s.denylist.RevokeUser(ctx, reset.UserID.String())
+}
+
+// SendVerificationEmail issues a fresh verification token for the user and emails the link.
+// Earlier unused tokens stop working.
+func (s *Service) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(s.config.Auth.EmailVerificationTTLHours) * time.Hour
+	if err := s.repo.CreateEmailVerification(ctx, &EmailVerification{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your Schools24 email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm your email address using the link below. It expires in %d hours.\n\n%s/verify-email?token=%s\n\nIf you did not expect this email, please contact your school.",
+			user.FullName, s.config.Auth.EmailVerificationTTLHours, s.config.App.FrontendURL, token,
+		),
+	}
+	return s.mail.Send(ctx, msg)
+}
+
+// VerifyEmail confirms an email address using a verification token
+func (s *Service) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) error {
+	v, err := s.repo.GetEmailVerificationByToken(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if v == nil || v.UsedAt != nil || time.Now().After(v.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	completed, err := s.repo.CompleteEmailVerification(ctx, v)
+	if err != nil {
+		return err
+	}
+	if !completed {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// ResendVerification sends a new verification email, at most once per throttle window.
+// Like ForgotPassword, it never reveals whether the email is registered.
+func (s *Service) ResendVerification(ctx context.Context, req *ResendVerificationRequest) error {
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailVerified {
+		return nil
+	}
+
+	lastSent, err := s.repo.GetLastEmailVerificationSentAt(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	throttle := time.Duration(s.config.Auth.EmailVerificationResendSeconds) * time.Second
+	if lastSent != nil && time.Since(*lastSent) < throttle {
+		return nil
+	}
+
+	if err := s.SendVerificationEmail(ctx, user.ID); err != nil {
+		// Do not leak delivery failures to the caller
+		log.Printf("Failed to send verification email to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// Logout ends the session of the presented access token. Tokens issued before
+// session tracking are revoked individually, together with the refresh token family if given.
+// Impersonation tokens are revoked on their own.
+func (s *Service) Logout(ctx context.Context, claims *middleware.Claims, req *LogoutRequest) error {
+	// Impersonation tokens share the admin's session, so only the token itself ends
+	if claims.ActorID != "" {
+		if claims.ExpiresAt == nil {
+			return nil
+		}
+		return s.denylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	}
+
+	if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+		return s.endSession(ctx, sessionID)
+	}
+
+	if claims.ExpiresAt != nil {
+		if err := s.denylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UserID.String() != claims.UserID {
+		return nil
+	}
+	return s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+// Impersonate issues a short-lived, read-only access token acting as another
+// user of the actor's school. The token records the actor, inherits the
+// actor's session (signing the actor out ends it) and is never refreshed.
+func (s *Service) Impersonate(ctx context.Context, actor *middleware.Claims, targetID uuid.UUID, req *ImpersonateRequest) (*ImpersonationResponse, error) {
+	if actor.ActorID != "" || actor.UserID == targetID.String() {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	target, err := s.repo.GetUserByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || !sameSchool(ctx, target) {
+		return nil, ErrUserNotFound
+	}
+	if !impersonableRoles[target.Role] {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	extraRoles, err := s.repo.GetAdditionalRoleNames(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := middleware.Claims{
+		UserID:    target.ID.String(),
+		Email:     target.Email,
+		Role:      target.Role,
+		Roles:     append([]string{target.Role}, extraRoles...),
+		MFA:       actor.MFA,
+		SessionID: actor.SessionID,
+		ActorID:   actor.UserID,
+	}
+	if target.SchoolID != nil {
+		claims.SchoolID = target.SchoolID.String()
+	}
+
+	expiry := time.Duration(s.config.Auth.ImpersonationTTLMinutes) * time.Minute
+	accessToken, err := s.keys.Sign(claims, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	actorID, _ := uuid.Parse(actor.UserID)
+	details := map[string]interface{}{
+		"reason":     req.Reason,
+		"role":       target.Role,
+		"expires_at": time.Now().Add(expiry),
+	}
+	if err := s.audit.LogAudit(ctx, &actorID, "impersonation.started", "user", &target.ID, nil, details, req.IPAddress, req.UserAgent); err != nil {
+		log.Printf("Failed to audit impersonation of user %s: %v", target.ID, err)
+	}
+
+	return &ImpersonationResponse{
+		User:           target,
+		AccessToken:    accessToken,
+		ExpiresIn:      int(expiry.Seconds()),
+		ImpersonatorID: actor.UserID,
+		ReadOnly:       true,
+	}, nil
+}
+
+// sameSchool reports whether a user belongs to the school the request is scoped to
+func sameSchool(ctx context.Context, user *User) bool {
+	schoolID := tenant.SchoolID(ctx)
+	return schoolID == nil || (user.SchoolID != nil && *user.SchoolID == *schoolID)
+}
+
+// GetSessions lists the user's active sessions, marking the one making the request
+func (s *Service) GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]Session, error) {
+	sessions, err := s.repo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one of their sessions
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return s.endSession(ctx, sessionID)
+}
+
+// endSession revokes a session's refresh tokens and rejects its outstanding access tokens
+func (s *Service) endSession(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.denylist.RevokeSession(ctx, sessionID.String())
+}
+
+// GetMFAStatus reports whether 2FA is on and how many recovery codes remain
+func (s *Service) GetMFAStatus(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return &MFAStatus{}, nil
+	}
+	remaining, err := s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAStatus{
+		Enabled:                true,
+		EnabledAt:              mfa.EnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// EnrollMFA starts TOTP enrollment. The secret is inactive until ConfirmMFA succeeds.
+func (s *Service) EnrollMFA(ctx context.Context, userID uuid.UUID) (*MFAEnrollResponse, error) {
+	user, err := s.GetMe(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePendingMFA(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+// ConfirmMFA activates 2FA with a first valid code and returns the recovery codes
+func (s *Service) ConfirmMFA(ctx context.Context, userID uuid.UUID, req *MFACodeRequest) (*MFARecoveryCodesResponse, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := validateTOTP(mfa.TOTPSecret, req.Code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableMFA(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after a valid TOTP code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *MFACodeRequest) (*MFARecoveryCodesResponse, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, ErrMFANotEnrolled
+	}
+	if err := s.checkSecondFactor(ctx, mfa, req.Code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA turns 2FA off after re-checking the password and a TOTP code
+func (s *Service) DisableMFA(ctx context.Context, userID uuid.UUID, req *MFADisableRequest) error {
+	user, err := s.GetMe(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if match, _ := s.passwords.Verify(req.Password, user.PasswordHash); !match {
+		return ErrInvalidCredentials
+	}
+
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return ErrMFANotEnrolled
+	}
+	if err := s.checkSecondFactor(ctx, mfa, req.Code, ""); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteMFA(ctx, userID)
+}
+
+// checkSecondFactor accepts either a fresh TOTP code or an unused recovery code
+func (s *Service) checkSecondFactor(ctx context.Context, mfa *UserMFA, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		used, err := s.repo.UseRecoveryCode(ctx, mfa.UserID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	step, ok := validateTOTP(mfa.TOTPSecret, code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	// Guards against the same code being replayed concurrently
+	advanced, err := s.repo.AdvanceMFAStep(ctx, mfa.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// upgradePasswordHash rehashes a verified password whose stored hash uses an
+// older algorithm or weaker parameters. Failures only delay the upgrade.
+func (s *Service) upgradePasswordHash(ctx context.Context, user *User, plain string) {
+	if !s.passwords.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	hashedPassword, err := s.passwords.Hash(plain)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		return
+	}
+	upgraded, err := s.repo.UpgradePasswordHash(ctx, user.ID, user.PasswordHash, hashedPassword)
+	if err != nil {
+		log.Printf("Failed to store upgraded password hash of user %s: %v", user.ID, err)
+		return
+	}
+	if upgraded {
+		user.PasswordHash = hashedPassword
+	}
 }
 
 // GetMe returns the current user's profile
@@ -108,34 +1224,89 @@ func (s *Service) UpdateProfile(ctx context.Context, userID uuid.UUID, req *Upda
 	return s.repo.UpdateProfile(ctx, userID, req)
 }
 
-// generateAuthResponse creates tokens and auth response
-func (s *Service) generateAuthResponse(user *User) (*AuthResponse, error) {
-	expiryHours := s.config.JWT.ExpirationHours
-	expiry := time.Duration(expiryHours) * time.Hour
-
-	claims := middleware.Claims{
-		UserID:   user.ID.String(),
-		Email:    user.Email,
-		Role:     user.Role,
-		SchoolID: "", // Will be populated when we add school support
+// generateAuthResponse creates tokens and auth response.
+// Every call starts a new session, whose ID is also the refresh token family.
+func (s *Service) generateAuthResponse(ctx context.Context, user *User, mfaVerified bool, client sessionClient) (*AuthResponse, error) {
+	sessionID := uuid.New()
+	if err := s.repo.CreateSession(ctx, &Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		Device:    client.device(),
+		UserAgent: client.userAgent,
+		IPAddress: client.ipAddress,
+		ExpiresAt: time.Now().Add(s.refreshExpiry()),
+	}); err != nil {
+		return nil, err
 	}
 
-	accessToken, err := middleware.GenerateToken(s.config.JWT.Secret, claims, expiry)
+	accessToken, err := s.generateAccessToken(ctx, user, mfaVerified, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate refresh token (longer expiry)
-	refreshExpiry := time.Duration(s.config.JWT.RefreshExpirationDays) * 24 * time.Hour
-	refreshToken, err := middleware.GenerateToken(s.config.JWT.Secret, claims, refreshExpiry)
+	// Generate opaque refresh token (only its hash is stored)
+	refreshToken, refreshHash, err := generateOpaqueToken()
 	if err != nil {
 		return nil, err
 	}
+	if err := s.repo.CreateRefreshToken(ctx, &RefreshToken{
+		UserID:      user.ID,
+		FamilyID:    sessionID,
+		TokenHash:   refreshHash,
+		ExpiresAt:   time.Now().Add(s.refreshExpiry()),
+		MFAVerified: mfaVerified,
+	}); err != nil {
+		return nil, err
+	}
 
 	return &AuthResponse{
 		User:         user,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		ExpiresIn:    expiryHours * 3600, // In seconds
+		ExpiresIn:    s.config.JWT.ExpirationHours * 3600, // In seconds
 	}, nil
 }
+
+// generateAccessToken signs a JWT access token for the user's session.
+// Roles lists every role the user holds; permissions are still checked server-side.
+func (s *Service) generateAccessToken(ctx context.Context, user *User, mfaVerified bool, sessionID uuid.UUID) (string, error) {
+	expiry := time.Duration(s.config.JWT.ExpirationHours) * time.Hour
+
+	extraRoles, err := s.repo.GetAdditionalRoleNames(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	claims := middleware.Claims{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Role:      user.Role,
+		Roles:     append([]string{user.Role}, extraRoles...),
+		MFA:       mfaVerified,
+		SessionID: sessionID.String(),
+	}
+	if user.SchoolID != nil {
+		claims.SchoolID = user.SchoolID.String()
+	}
+
+	return s.keys.Sign(claims, expiry)
+}
+
+// generateMFAChallenge signs the short-lived token that links the two login steps
+func (s *Service) generateMFAChallenge(user *User) (string, error) {
+	expiry := time.Duration(s.config.Auth.MFAChallengeTTLMinutes) * time.Minute
+
+	claims := middleware.Claims{
+		UserID:  user.ID.String(),
+		Email:   user.Email,
+		Role:    user.Role,
+		Purpose: middleware.PurposeMFAChallenge,
+	}
+
+	return s.keys.Sign(claims, expiry)
+}
+
+// refreshExpiry returns the lifetime of a refresh token
+func (s *Service) refreshExpiry() time.Duration {
+	return time.Duration(s.config.JWT.RefreshExpirationDays) * 24 * time.Hour
+}
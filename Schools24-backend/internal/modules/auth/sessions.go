@@ -0,0 +1,72 @@
+package auth
+
+import "strings"
+
+// sessionClient describes the device a session is started or refreshed from
+type sessionClient struct {
+	ipAddress  string
+	userAgent  string
+	deviceName string
+}
+
+// maxDeviceLength matches user_sessions.device
+const maxDeviceLength = 100
+
+// device returns the session label: the name given at login, or one derived from the user agent
+func (c sessionClient) device() string {
+	name := strings.TrimSpace(c.deviceName)
+	if name == "" {
+		name = describeUserAgent(c.userAgent)
+	}
+	if len(name) > maxDeviceLength {
+		name = name[:maxDeviceLength]
+	}
+	return name
+}
+
+// describeUserAgent turns a user agent into a short label such as "Chrome on Windows".
+// It only needs to be good enough for a person to recognise their own devices.
+func describeUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	// Order matters: Edge and Opera also claim Chrome, Chrome also claims Safari
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"Dart/", "Mobile app"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	// Android and iOS user agents also mention Linux and Mac OS X
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// ssoSlugPattern restricts provider slugs to URL-safe names such as "google"
+var ssoSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// defaultSSOScopes are requested when a provider does not set its own
+const defaultSSOScopes = "openid email profile"
+
+// ssoStore is the storage the sign-in flow needs (implemented by Repository)
+type ssoStore interface {
+	GetSchoolIDByCode(ctx context.Context, code string) (*uuid.UUID, error)
+	GetSSOProviderBySlug(ctx context.Context, schoolID uuid.UUID, slug string) (*SSOProvider, error)
+	GetSSOProviderByID(ctx context.Context, id uuid.UUID) (*SSOProvider, error)
+	CreateSSOLoginState(ctx context.Context, providerID uuid.UUID, stateHash, nonce, verifier string, expiresAt time.Time) error
+	ConsumeSSOLoginState(ctx context.Context, stateHash string) (*ssoLoginState, error)
+	GetIdentityUserID(ctx context.Context, providerID uuid.UUID, subject string) (*uuid.UUID, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	LinkUserIdentity(ctx context.Context, userID, providerID uuid.UUID, subject, email string) (bool, error)
+	TouchUserIdentity(ctx context.Context, providerID uuid.UUID, subject, email string) error
+}
+
+// ssoRoles are the accounts that can sign in through a school's provider.
+// Admins keep password and second-factor sign-in so that controlling an email
+// at the provider is never enough to take over a school; students and
+// parents are not on the school's directory.
+var ssoRoles = map[string]bool{
+	RoleTeacher: true,
+	RoleStaff:   true,
+}
+
+// GetSSOProviders lists the current school's identity providers
+func (s *Service) GetSSOProviders(ctx context.Context) ([]SSOProvider, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSSOProviders(ctx, schoolID)
+}
+
+// SaveSSOProvider creates or updates one of the current school's identity
+// providers. The issuer must be a public https URL serving a discovery document.
+func (s *Service) SaveSSOProvider(ctx context.Context, actorID uuid.UUID, slug string, req *SaveSSOProviderRequest, ipAddress, userAgent string) (*SSOProvider, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ssoSlugPattern.MatchString(slug) {
+		return nil, ErrInvalidSSOProvider
+	}
+
+	req.Scopes = strings.TrimSpace(req.Scopes)
+	if req.Scopes == "" {
+		req.Scopes = defaultSSOScopes
+	}
+	if !strings.Contains(" "+req.Scopes+" ", " openid ") {
+		req.Scopes = "openid " + req.Scopes
+	}
+	domains := make([]string, 0, len(req.AllowedDomains))
+	for _, domain := range req.AllowedDomains {
+		if domain = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(domain, "@"))); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, ErrSSODomainsRequired
+	}
+	req.AllowedDomains = domains
+
+	if _, err := s.oidc.discover(ctx, req.Issuer); err != nil {
+		log.Printf("SSO provider %s rejected: %v", slug, err)
+		return nil, ErrInvalidSSOProvider
+	}
+
+	id, err := s.repo.SaveSSOProvider(ctx, schoolID, slug, req)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{
+		"slug":            slug,
+		"issuer":          req.Issuer,
+		"client_id":       req.ClientID,
+		"allowed_domains": req.AllowedDomains,
+		"secret_changed":  req.ClientSecret != nil,
+	}
+	if err := s.audit.LogAudit(ctx, &actorID, "sso_provider.saved", "sso_provider", &id, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit SSO provider %s: %v", id, err)
+	}
+	return s.repo.GetSSOProviderByID(ctx, id)
+}
+
+// DeleteSSOProvider removes one of the current school's identity providers.
+// Users linked through it keep their accounts and can still log in with a password.
+func (s *Service) DeleteSSOProvider(ctx context.Context, actorID uuid.UUID, slug, ipAddress, userAgent string) error {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return err
+	}
+	provider, err := s.repo.GetSSOProviderBySlug(ctx, schoolID, slug)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return ErrSSOProviderNotFound
+	}
+
+	if _, err := s.repo.DeleteSSOProvider(ctx, schoolID, slug); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"slug": slug, "issuer": provider.Issuer}
+	if err := s.audit.LogAudit(ctx, &actorID, "sso_provider.deleted", "sso_provider", &provider.ID, nil, details, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit SSO provider %s: %v", provider.ID, err)
+	}
+	return nil
+}
+
+// GetLoginSSOProviders lists the enabled providers of a school for its login page
+func (s *Service) GetLoginSSOProviders(ctx context.Context, schoolCode string) ([]SSOProviderSummary, error) {
+	schoolID, err := s.repo.GetSchoolIDByCode(ctx, schoolCode)
+	if err != nil {
+		return nil, err
+	}
+	if schoolID == nil {
+		return nil, ErrSchoolNotFound
+	}
+
+	providers, err := s.repo.GetSSOProviders(ctx, *schoolID)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []SSOProviderSummary{}
+	for _, p := range providers {
+		if p.Enabled {
+			summaries = append(summaries, SSOProviderSummary{Slug: p.Slug, DisplayName: p.DisplayName})
+		}
+	}
+	return summaries, nil
+}
+
+// StartSSO begins an authorization code flow with PKCE. The state, nonce and
+// code verifier stay on the server; only the state's hash is stored.
+func (s *Service) StartSSO(ctx context.Context, req *SSOStartRequest) (*SSOStartResponse, error) {
+	schoolID, err := s.sso.GetSchoolIDByCode(ctx, req.SchoolCode)
+	if err != nil {
+		return nil, err
+	}
+	if schoolID == nil {
+		return nil, ErrSSOProviderNotFound
+	}
+	provider, err := s.sso.GetSSOProviderBySlug(ctx, *schoolID, req.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, ErrSSOProviderNotFound
+	}
+
+	metadata, err := s.oidc.discover(ctx, provider.Issuer)
+	if err != nil {
+		log.Printf("SSO discovery failed for provider %s: %v", provider.ID, err)
+		return nil, ErrSSOFailed
+	}
+
+	state, stateHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(s.config.Auth.OIDCStateTTLMinutes) * time.Minute
+	if err := s.sso.CreateSSOLoginState(ctx, provider.ID, stateHash, nonce, verifier, time.Now().Add(ttl)); err != nil {
+		return nil, err
+	}
+
+	return &SSOStartResponse{
+		AuthorizationURL: s.oidc.authorizationURL(metadata, provider, s.ssoRedirectURL(), state, nonce, verifier),
+		ExpiresIn:        int(ttl.Seconds()),
+	}, nil
+}
+
+// CompleteSSO redeems the authorization code and signs the user in. Provider
+// accounts are linked just in time to an existing teacher or staff member of
+// the provider's school with the same verified email in one of its allowed
+// domains; accounts are never created here.
+func (s *Service) CompleteSSO(ctx context.Context, req *SSOCallbackRequest) (*AuthResponse, error) {
+	user, err := s.authenticateSSO(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider replaces the password, not the second factor
+	mfa, err := s.repo.GetUserMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		challenge, err := s.generateMFAChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResponse{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return s.generateAuthResponse(ctx, user, false, sessionClient{
+		ipAddress:  req.IPAddress,
+		userAgent:  req.UserAgent,
+		deviceName: req.DeviceName,
+	})
+}
+
+// authenticateSSO consumes the sign-in request's state, redeems the code and
+// verifies the ID token, and returns the user the provider account signs in as
+func (s *Service) authenticateSSO(ctx context.Context, req *SSOCallbackRequest) (*User, error) {
+	state, err := s.sso.ConsumeSSOLoginState(ctx, hashToken(req.State))
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrInvalidSSOState
+	}
+	provider, err := s.sso.GetSSOProviderByID(ctx, state.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, ErrInvalidSSOState
+	}
+
+	metadata, err := s.oidc.discover(ctx, provider.Issuer)
+	if err != nil {
+		log.Printf("SSO discovery failed for provider %s: %v", provider.ID, err)
+		return nil, ErrSSOFailed
+	}
+	rawIDToken, err := s.oidc.exchangeCode(ctx, metadata, provider, s.ssoRedirectURL(), req.Code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("SSO code exchange failed for provider %s: %v", provider.ID, err)
+		return nil, ErrSSOFailed
+	}
+	claims, err := s.oidc.verifyIDToken(ctx, metadata, provider, rawIDToken, state.Nonce)
+	if err != nil {
+		log.Printf("SSO ID token rejected for provider %s: %v", provider.ID, err)
+		return nil, ErrSSOFailed
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !(bool(claims.EmailVerified) || bool(claims.EmailDomainVerified)) {
+		return nil, ErrSSOEmailNotVerified
+	}
+	if !domainAllowed(provider.AllowedDomains, emailDomain(email)) {
+		return nil, ErrSSOEmailNotAllowed
+	}
+
+	user, err := s.ssoUser(ctx, provider, claims.Subject, email, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sso.TouchUserIdentity(ctx, provider.ID, claims.Subject, email); err != nil {
+		log.Printf("Failed to record SSO login of user %s: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// ssoUser resolves the user behind a provider account, linking it on first use
+func (s *Service) ssoUser(ctx context.Context, provider *SSOProvider, subject, email string, req *SSOCallbackRequest) (*User, error) {
+	userID, err := s.sso.GetIdentityUserID(ctx, provider.ID, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *User
+	if userID != nil {
+		user, err = s.sso.GetUserByID(ctx, *userID)
+	} else {
+		user, err = s.sso.GetUserByEmail(ctx, email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ssoLinkable(user, provider) {
+		return nil, ErrSSONoAccount
+	}
+	if userID != nil {
+		return user, nil
+	}
+
+	linked, err := s.sso.LinkUserIdentity(ctx, user.ID, provider.ID, subject, email)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		details := map[string]interface{}{"provider": provider.Slug, "issuer": provider.Issuer, "email": email}
+		auditCtx := tenant.WithSchoolID(ctx, provider.SchoolID)
+		if err := s.audit.LogAudit(auditCtx, &user.ID, "sso.identity_linked", "user", &user.ID, nil, details, req.IPAddress, req.UserAgent); err != nil {
+			log.Printf("Failed to audit SSO link of user %s: %v", user.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// ssoLinkable reports whether a user may sign in through a provider: only staff
+// of the provider's school, never users of other schools or super admins
+func ssoLinkable(user *User, provider *SSOProvider) bool {
+	return user != nil && user.SchoolID != nil && *user.SchoolID == provider.SchoolID && ssoRoles[user.Role]
+}
+
+// ssoRedirectURL is the page providers return to; it must be registered with each provider
+func (s *Service) ssoRedirectURL() string {
+	if s.config.Auth.OIDCRedirectURL != "" {
+		return s.config.Auth.OIDCRedirectURL
+	}
+	return strings.TrimSuffix(s.config.App.FrontendURL, "/") + "/sso/callback"
+}
+
+// domainAllowed reports whether an email domain passes a provider's allow
+// list. An empty list, left by providers saved before one was required, allows none.
+func domainAllowed(allowed []string, domain string) bool {
+	if domain == "" {
+		return false
+	}
+	for _, d := range allowed {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
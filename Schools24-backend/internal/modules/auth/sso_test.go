@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+const testSchoolCode = "SCH001"
+
+// fakeSSOStore keeps the sign-in flow's data in memory. Login states follow
+// the repository's contract: each is returned once, and only before expiry.
+type fakeSSOStore struct {
+	mu         sync.Mutex
+	schoolID   uuid.UUID
+	provider   *SSOProvider
+	states     map[string]*fakeLoginState
+	users      map[uuid.UUID]*User
+	identities map[string]uuid.UUID
+}
+
+type fakeLoginState struct {
+	state     ssoLoginState
+	expiresAt time.Time
+	used      bool
+}
+
+func (f *fakeSSOStore) GetSchoolIDByCode(ctx context.Context, code string) (*uuid.UUID, error) {
+	if code != testSchoolCode {
+		return nil, nil
+	}
+	return &f.schoolID, nil
+}
+
+func (f *fakeSSOStore) GetSSOProviderBySlug(ctx context.Context, schoolID uuid.UUID, slug string) (*SSOProvider, error) {
+	if schoolID != f.provider.SchoolID || slug != f.provider.Slug {
+		return nil, nil
+	}
+	return f.provider, nil
+}
+
+func (f *fakeSSOStore) GetSSOProviderByID(ctx context.Context, id uuid.UUID) (*SSOProvider, error) {
+	if id != f.provider.ID {
+		return nil, nil
+	}
+	return f.provider, nil
+}
+
+func (f *fakeSSOStore) CreateSSOLoginState(ctx context.Context, providerID uuid.UUID, stateHash, nonce, verifier string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[stateHash] = &fakeLoginState{
+		state:     ssoLoginState{ID: uuid.New(), ProviderID: providerID, Nonce: nonce, CodeVerifier: verifier},
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (f *fakeSSOStore) ConsumeSSOLoginState(ctx context.Context, stateHash string) (*ssoLoginState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.states[stateHash]
+	if !ok || st.used || !time.Now().Before(st.expiresAt) {
+		return nil, nil
+	}
+	st.used = true
+	state := st.state
+	return &state, nil
+}
+
+func (f *fakeSSOStore) GetIdentityUserID(ctx context.Context, providerID uuid.UUID, subject string) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	userID, ok := f.identities[providerID.String()+"|"+subject]
+	if !ok {
+		return nil, nil
+	}
+	return &userID, nil
+}
+
+func (f *fakeSSOStore) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.users[id], nil
+}
+
+func (f *fakeSSOStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSSOStore) LinkUserIdentity(ctx context.Context, userID, providerID uuid.UUID, subject, email string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := providerID.String() + "|" + subject
+	if _, ok := f.identities[key]; ok {
+		return false, nil
+	}
+	f.identities[key] = userID
+	f.users[userID].EmailVerified = true
+	return true, nil
+}
+
+func (f *fakeSSOStore) TouchUserIdentity(ctx context.Context, providerID uuid.UUID, subject, email string) error {
+	return nil
+}
+
+func (f *fakeSSOStore) addUser(role string, schoolID *uuid.UUID, email string) *User {
+	user := &User{ID: uuid.New(), SchoolID: schoolID, Email: email, Role: role, IsActive: true}
+	f.mu.Lock()
+	f.users[user.ID] = user
+	f.mu.Unlock()
+	return user
+}
+
+func (f *fakeSSOStore) linkedUsers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.identities)
+}
+
+// fakeAudit records audited actions
+type fakeAudit struct {
+	mu      sync.Mutex
+	actions []string
+}
+
+func (a *fakeAudit) LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.actions = append(a.actions, action)
+	return nil
+}
+
+// newSSOTestService returns a service whose school has the mock provider
+// configured under the slug "idp"
+func newSSOTestService(t *testing.T, idp *mockIdP) (*Service, *fakeSSOStore, *fakeAudit) {
+	t.Helper()
+	schoolID := uuid.New()
+	provider := testProvider(idp)
+	provider.ID = uuid.New()
+	provider.SchoolID = schoolID
+	provider.Slug = "idp"
+
+	store := &fakeSSOStore{
+		schoolID:   schoolID,
+		provider:   provider,
+		states:     map[string]*fakeLoginState{},
+		users:      map[uuid.UUID]*User{},
+		identities: map[string]uuid.UUID{},
+	}
+	audit := &fakeAudit{}
+	service := &Service{
+		sso:   store,
+		audit: audit,
+		oidc:  idp.client(),
+		config: &config.Config{
+			App:  config.AppConfig{FrontendURL: "https://app.schools24.example"},
+			Auth: config.AuthConfig{OIDCStateTTLMinutes: 10},
+		},
+	}
+	return service, store, audit
+}
+
+// startSSO begins a sign-in and approves it at the provider
+func startSSO(t *testing.T, service *Service, idp *mockIdP, edit func(jwt.MapClaims)) *SSOCallbackRequest {
+	t.Helper()
+	start, err := service.StartSSO(context.Background(), &SSOStartRequest{SchoolCode: testSchoolCode, Provider: "idp"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	code, state := idp.authorize(start.AuthorizationURL, edit)
+	return &SSOCallbackRequest{State: state, Code: code}
+}
+
+func withEmail(email string) func(jwt.MapClaims) {
+	return func(claims jwt.MapClaims) { claims["email"] = email }
+}
+
+func TestSSOLinksStaffByVerifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, audit := newSSOTestService(t, idp)
+	teacher := store.addUser(RoleTeacher, &store.schoolID, "teacher@school.example")
+
+	user, err := service.authenticateSSO(context.Background(), startSSO(t, service, idp, nil))
+	if err != nil {
+		t.Fatalf("sign-in: %v (%s)", err, idp.lastTokenError)
+	}
+	if user.ID != teacher.ID {
+		t.Fatalf("signed in as %s, want %s", user.ID, teacher.ID)
+	}
+	if !teacher.EmailVerified || store.linkedUsers() != 1 {
+		t.Fatal("provider account was not linked")
+	}
+
+	// Later sign-ins resolve the linked account, even after the email changes
+	user, err = service.authenticateSSO(context.Background(), startSSO(t, service, idp, withEmail("renamed@school.example")))
+	if err != nil || user.ID != teacher.ID {
+		t.Fatalf("second sign-in = %v, %v", user, err)
+	}
+	if len(audit.actions) != 1 || audit.actions[0] != "sso.identity_linked" {
+		t.Fatalf("audited %v, want one sso.identity_linked", audit.actions)
+	}
+	if discovery, keys := idp.hits(); discovery != 1 || keys != 1 {
+		t.Fatalf("discovery/keys fetched %d/%d times, want 1/1", discovery, keys)
+	}
+}
+
+func TestSSOStateIsSingleUse(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	store.addUser(RoleStaff, &store.schoolID, "teacher@school.example")
+	ctx := context.Background()
+
+	req := startSSO(t, service, idp, nil)
+	if _, err := service.authenticateSSO(ctx, req); err != nil {
+		t.Fatalf("sign-in: %v", err)
+	}
+
+	// Replaying the state fails even with a fresh code from the provider
+	start, err := service.StartSSO(ctx, &SSOStartRequest{SchoolCode: testSchoolCode, Provider: "idp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := idp.authorize(start.AuthorizationURL, nil)
+	if _, err := service.authenticateSSO(ctx, &SSOCallbackRequest{State: req.State, Code: code}); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("replayed state: err = %v, want %v", err, ErrInvalidSSOState)
+	}
+	if _, err := service.authenticateSSO(ctx, &SSOCallbackRequest{State: "unknown", Code: code}); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("unknown state: err = %v, want %v", err, ErrInvalidSSOState)
+	}
+}
+
+func TestSSORejectsTokensForAnotherRequest(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	store.addUser(RoleTeacher, &store.schoolID, "teacher@school.example")
+
+	tests := []struct {
+		name string
+		edit func(jwt.MapClaims)
+	}{
+		{"nonce of another request", func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://attacker.example" }},
+		{"expired", func(c jwt.MapClaims) {
+			c["iat"] = time.Now().Add(-time.Hour).Unix()
+			c["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.authenticateSSO(context.Background(), startSSO(t, service, idp, tt.edit))
+			if !errors.Is(err, ErrSSOFailed) {
+				t.Fatalf("err = %v, want %v", err, ErrSSOFailed)
+			}
+		})
+	}
+	if store.linkedUsers() != 0 {
+		t.Fatal("a rejected token linked an account")
+	}
+}
+
+func TestSSORequiresVerifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	store.addUser(RoleTeacher, &store.schoolID, "teacher@school.example")
+	ctx := context.Background()
+
+	unverified := func(c jwt.MapClaims) { c["email_verified"] = false }
+	if _, err := service.authenticateSSO(ctx, startSSO(t, service, idp, unverified)); !errors.Is(err, ErrSSOEmailNotVerified) {
+		t.Fatalf("unverified email: err = %v, want %v", err, ErrSSOEmailNotVerified)
+	}
+	noEmail := func(c jwt.MapClaims) { delete(c, "email") }
+	if _, err := service.authenticateSSO(ctx, startSSO(t, service, idp, noEmail)); !errors.Is(err, ErrSSOEmailNotVerified) {
+		t.Fatalf("no email: err = %v, want %v", err, ErrSSOEmailNotVerified)
+	}
+
+	// Some providers send the flag as a string
+	verifiedString := func(c jwt.MapClaims) { c["email_verified"] = "true" }
+	if _, err := service.authenticateSSO(ctx, startSSO(t, service, idp, verifiedString)); err != nil {
+		t.Fatalf("email_verified \"true\": %v", err)
+	}
+}
+
+func TestSSOAllowedDomains(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	store.addUser(RoleTeacher, &store.schoolID, "teacher@gmail.example")
+	ctx := context.Background()
+
+	if _, err := service.authenticateSSO(ctx, startSSO(t, service, idp, withEmail("teacher@gmail.example"))); !errors.Is(err, ErrSSOEmailNotAllowed) {
+		t.Fatalf("other domain: err = %v, want %v", err, ErrSSOEmailNotAllowed)
+	}
+
+	// Providers saved before an allow list was required let nobody in
+	store.provider.AllowedDomains = nil
+	if _, err := service.authenticateSSO(ctx, startSSO(t, service, idp, nil)); !errors.Is(err, ErrSSOEmailNotAllowed) {
+		t.Fatalf("empty allow list: err = %v, want %v", err, ErrSSOEmailNotAllowed)
+	}
+	if store.linkedUsers() != 0 {
+		t.Fatal("a disallowed domain linked an account")
+	}
+}
+
+func TestSSORefusesAccountsOutsideSchoolStaff(t *testing.T) {
+	otherSchool := uuid.New()
+
+	tests := []struct {
+		name     string
+		role     string
+		schoolID func(store *fakeSSOStore) *uuid.UUID
+	}{
+		{"teacher of another school", RoleTeacher, func(*fakeSSOStore) *uuid.UUID { return &otherSchool }},
+		{"super admin", RoleSuperAdmin, func(*fakeSSOStore) *uuid.UUID { return nil }},
+		{"school admin", RoleAdmin, func(s *fakeSSOStore) *uuid.UUID { return &s.schoolID }},
+		{"student", RoleStudent, func(s *fakeSSOStore) *uuid.UUID { return &s.schoolID }},
+		{"parent", RoleParent, func(s *fakeSSOStore) *uuid.UUID { return &s.schoolID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			service, store, _ := newSSOTestService(t, idp)
+			store.addUser(tt.role, tt.schoolID(store), "teacher@school.example")
+
+			_, err := service.authenticateSSO(context.Background(), startSSO(t, service, idp, nil))
+			if !errors.Is(err, ErrSSONoAccount) {
+				t.Fatalf("err = %v, want %v", err, ErrSSONoAccount)
+			}
+			if store.linkedUsers() != 0 {
+				t.Fatal("provider account was linked")
+			}
+		})
+	}
+}
+
+func TestSSORefusesAdminLinkedBeforeRolesWereChecked(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	admin := store.addUser(RoleAdmin, &store.schoolID, "admin@school.example")
+	store.identities[store.provider.ID.String()+"|subject-1"] = admin.ID
+
+	if _, err := service.authenticateSSO(context.Background(), startSSO(t, service, idp, nil)); !errors.Is(err, ErrSSONoAccount) {
+		t.Fatalf("err = %v, want %v", err, ErrSSONoAccount)
+	}
+}
+
+func TestSaveSSOProviderValidation(t *testing.T) {
+	idp := newMockIdP(t)
+	service, store, _ := newSSOTestService(t, idp)
+	service.oidc = newOIDCClient(nil)
+	ctx := tenant.WithSchoolID(context.Background(), store.schoolID)
+
+	tests := []struct {
+		name    string
+		req     SaveSSOProviderRequest
+		wantErr error
+	}{
+		{"no allowed domains", SaveSSOProviderRequest{Issuer: "https://accounts.google.com", AllowedDomains: []string{" ", "@"}}, ErrSSODomainsRequired},
+		{"metadata service", SaveSSOProviderRequest{Issuer: "http://169.254.169.254/latest", AllowedDomains: []string{"school.example"}}, ErrInvalidSSOProvider},
+		{"loopback", SaveSSOProviderRequest{Issuer: idp.server.URL, AllowedDomains: []string{"school.example"}}, ErrInvalidSSOProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.DisplayName, req.ClientID = "IdP", testClientID
+			if _, err := service.SaveSSOProvider(ctx, uuid.New(), "idp", &req, "", ""); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if discovery, _ := idp.hits(); discovery != 0 {
+		t.Fatalf("provider on a loopback address was contacted %d times", discovery)
+	}
+}
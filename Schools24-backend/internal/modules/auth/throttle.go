@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Login failure kinds, stored in login_failures.kind
+const (
+	LoginFailureAccount = "account"
+	LoginFailureIP      = "ip"
+)
+
+// ThrottleError is returned while an account or IP is delayed or locked out.
+// It matches ErrTooManyAttempts with errors.Is.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Is lets callers match any ThrottleError against ErrTooManyAttempts
+func (e *ThrottleError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// loginBackoff returns how long a key is blocked after its n-th failure.
+// The first half of the threshold is free, then delays double from one second,
+// and reaching the threshold locks the key for the full lockout.
+func loginBackoff(failures, threshold int, lockout time.Duration) time.Duration {
+	if failures >= threshold {
+		return lockout
+	}
+	free := threshold / 2
+	if failures <= free {
+		return 0
+	}
+	shift := failures - free - 1
+	if shift > 30 {
+		return lockout
+	}
+	delay := time.Second << shift
+	if delay > lockout {
+		return lockout
+	}
+	return delay
+}
+
+// accountKey normalizes an email for failure tracking
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,255 @@
+package parent
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/middleware"
+)
+
+// Handler handles HTTP requests for the parent portal
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new parent handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetChildren returns the parent's linked children
+// GET /api/v1/parent/children
+func (h *Handler) GetChildren(c *gin.Context) {
+	parentID, ok := currentParentID(c)
+	if !ok {
+		return
+	}
+
+	children, err := h.service.GetChildren(c.Request.Context(), parentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"children": children})
+}
+
+// GetChildAttendance returns a child's attendance
+// GET /api/v1/parent/children/:id/attendance
+func (h *Handler) GetChildAttendance(c *gin.Context) {
+	parentID, studentID, ok := childParams(c)
+	if !ok {
+		return
+	}
+
+	var startDate, endDate time.Time
+	if v := c.Query("start_date"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		startDate = t
+		endDate = t.AddDate(0, 1, -1)
+	}
+	if v := c.Query("end_date"); v != "" && !startDate.IsZero() {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		endDate = t
+	}
+
+	records, stats, err := h.service.GetChildAttendance(c.Request.Context(), parentID, studentID, startDate, endDate)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attendance": records,
+		"stats":      stats,
+	})
+}
+
+// GetChildHomework returns homework for a child's class
+// GET /api/v1/parent/children/:id/homework
+func (h *Handler) GetChildHomework(c *gin.Context) {
+	parentID, studentID, ok := childParams(c)
+	if !ok {
+		return
+	}
+
+	homework, err := h.service.GetChildHomework(c.Request.Context(), parentID, studentID, c.Query("status"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"homework": homework})
+}
+
+// GetChildGrades returns a child's grades
+// GET /api/v1/parent/children/:id/grades
+func (h *Handler) GetChildGrades(c *gin.Context) {
+	parentID, studentID, ok := childParams(c)
+	if !ok {
+		return
+	}
+
+	grades, err := h.service.GetChildGrades(c.Request.Context(), parentID, studentID, c.Query("academic_year"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grades": grades})
+}
+
+// GetChildTimetable returns the timetable of a child's class
+// GET /api/v1/parent/children/:id/timetable
+func (h *Handler) GetChildTimetable(c *gin.Context) {
+	parentID, studentID, ok := childParams(c)
+	if !ok {
+		return
+	}
+
+	timetable, err := h.service.GetChildTimetable(c.Request.Context(), parentID, studentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timetable": timetable})
+}
+
+// GetChildAnnouncements returns announcements for a child's parents
+// GET /api/v1/parent/children/:id/announcements
+func (h *Handler) GetChildAnnouncements(c *gin.Context) {
+	parentID, studentID, ok := childParams(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	announcements, err := h.service.GetChildAnnouncements(c.Request.Context(), parentID, studentID, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"announcements": announcements})
+}
+
+// GetGuardians returns the parents linked to a student
+// GET /api/v1/admin/students/:id/parents
+func (h *Handler) GetGuardians(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	guardians, err := h.service.GetGuardians(c.Request.Context(), studentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"parents": guardians})
+}
+
+// LinkParent links a parent account to a student
+// POST /api/v1/admin/students/:id/parents
+func (h *Handler) LinkParent(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	var req LinkParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	guardians, err := h.service.LinkParent(c.Request.Context(), studentID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"parents": guardians})
+}
+
+// UnlinkParent removes a parent-student link
+// DELETE /api/v1/admin/students/:id/parents/:parentId
+func (h *Handler) UnlinkParent(c *gin.Context) {
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+	parentID, err := uuid.Parse(c.Param("parentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent ID"})
+		return
+	}
+
+	if err := h.service.UnlinkParent(c.Request.Context(), studentID, parentID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Parent unlinked successfully"})
+}
+
+// currentParentID reads the authenticated user's ID
+func currentParentID(c *gin.Context) (uuid.UUID, bool) {
+	parentID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+	return parentID, true
+}
+
+// childParams reads the authenticated parent and the :id student parameter
+func childParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	parentID, ok := currentParentID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	studentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return parentID, studentID, true
+}
+
+// respondError maps parent errors to responses
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrChildNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "child_not_found"})
+	case errors.Is(err, ErrStudentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student_not_found"})
+	case errors.Is(err, ErrLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "link_not_found"})
+	case errors.Is(err, ErrNoClassAssigned):
+		c.JSON(http.StatusNotFound, gin.H{"error": "class_not_assigned"})
+	case errors.Is(err, ErrNotParentAccount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "not_parent_account", "message": err.Error()})
+	case errors.Is(err, ErrGuardianLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": "guardian_limit_reached", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
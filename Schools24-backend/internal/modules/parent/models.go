@@ -0,0 +1,38 @@
+package parent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxGuardiansPerStudent limits how many parent accounts can be linked to a child
+const MaxGuardiansPerStudent = 2
+
+// Child is a student linked to a parent account
+type Child struct {
+	StudentID       uuid.UUID  `json:"student_id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	FullName        string     `json:"full_name"`
+	AdmissionNumber string     `json:"admission_number"`
+	RollNumber      *string    `json:"roll_number,omitempty"`
+	ClassID         *uuid.UUID `json:"class_id,omitempty"`
+	ClassName       string     `json:"class_name,omitempty"`
+	Relationship    string     `json:"relationship"`
+}
+
+// Guardian is a parent account linked to a student
+type Guardian struct {
+	ParentUserID uuid.UUID `json:"parent_user_id"`
+	FullName     string    `json:"full_name"`
+	Email        string    `json:"email"`
+	Phone        *string   `json:"phone,omitempty"`
+	Relationship string    `json:"relationship"`
+	LinkedAt     time.Time `json:"linked_at"`
+}
+
+// LinkParentRequest links a parent account to a student
+type LinkParentRequest struct {
+	ParentUserID uuid.UUID `json:"parent_user_id" binding:"required"`
+	Relationship string    `json:"relationship,omitempty" binding:"omitempty,oneof=mother father guardian other"`
+}
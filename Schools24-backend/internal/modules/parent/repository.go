@@ -0,0 +1,218 @@
+package parent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/modules/teacher"
+	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Repository handles database operations for parent-student links
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new parent repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// childColumns selects a linked student joined with parent_students ps
+const childColumns = `
+	s.id, s.user_id, u.full_name, s.admission_number, s.roll_number, s.class_id,
+	COALESCE(c.name, ''), ps.relationship
+`
+
+// GetChildren retrieves the students linked to a parent
+func (r *Repository) GetChildren(ctx context.Context, parentUserID uuid.UUID) ([]Child, error) {
+	query := `
+		SELECT ` + childColumns + `
+		FROM parent_students ps
+		JOIN students s ON s.id = ps.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN classes c ON c.id = s.class_id
+		WHERE ps.parent_user_id = $1 AND ($2::uuid IS NULL OR ps.school_id = $2)
+		ORDER BY u.full_name
+	`
+	rows, err := r.db.Query(ctx, query, parentUserID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	children := []Child{}
+	for rows.Next() {
+		var child Child
+		if err := rows.Scan(&child.StudentID, &child.UserID, &child.FullName, &child.AdmissionNumber,
+			&child.RollNumber, &child.ClassID, &child.ClassName, &child.Relationship); err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return children, rows.Err()
+}
+
+// GetChild retrieves a student only if it is linked to the parent
+func (r *Repository) GetChild(ctx context.Context, parentUserID, studentID uuid.UUID) (*Child, error) {
+	query := `
+		SELECT ` + childColumns + `
+		FROM parent_students ps
+		JOIN students s ON s.id = ps.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN classes c ON c.id = s.class_id
+		WHERE ps.parent_user_id = $1 AND ps.student_id = $2
+		  AND ($3::uuid IS NULL OR ps.school_id = $3)
+	`
+	var child Child
+	err := r.db.QueryRow(ctx, query, parentUserID, studentID, tenant.SchoolID(ctx)).Scan(
+		&child.StudentID, &child.UserID, &child.FullName, &child.AdmissionNumber,
+		&child.RollNumber, &child.ClassID, &child.ClassName, &child.Relationship,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &child, nil
+}
+
+// GetChildAnnouncements retrieves unexpired announcements addressed to a child's parents:
+// school-wide, parent-wide, or targeted at the child's class
+func (r *Repository) GetChildAnnouncements(ctx context.Context, classID *uuid.UUID, limit int) ([]teacher.Announcement, error) {
+	query := `
+		SELECT a.id, a.title, a.content, a.author_id, a.target_type, a.target_id,
+		       a.priority, a.is_pinned, a.expires_at, a.created_at, a.updated_at,
+		       u.full_name as author_name
+		FROM announcements a
+		JOIN users u ON a.author_id = u.id
+		WHERE (a.expires_at IS NULL OR a.expires_at > CURRENT_TIMESTAMP)
+		  AND (a.target_type IN ('all', 'parents') OR (a.target_type = 'class' AND a.target_id = $2))
+		  AND ($3::uuid IS NULL OR a.school_id = $3)
+		ORDER BY a.is_pinned DESC, a.created_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit, classID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []teacher.Announcement{}
+	for rows.Next() {
+		var a teacher.Announcement
+		if err := rows.Scan(
+			&a.ID, &a.Title, &a.Content, &a.AuthorID, &a.TargetType, &a.TargetID,
+			&a.Priority, &a.IsPinned, &a.ExpiresAt, &a.CreatedAt, &a.UpdatedAt,
+			&a.AuthorName,
+		); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+	return announcements, rows.Err()
+}
+
+// StudentExists checks that a student belongs to the current school
+func (r *Repository) StudentExists(ctx context.Context, studentID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM students WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2))`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, studentID, tenant.SchoolID(ctx)).Scan(&exists)
+	return exists, err
+}
+
+// IsParentAccount checks that a user is a parent in the same school as the student
+func (r *Repository) IsParentAccount(ctx context.Context, parentUserID, studentID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM users u
+			JOIN students s ON s.school_id = u.school_id
+			WHERE u.id = $1 AND s.id = $2 AND u.role = 'parent'
+		)
+	`
+	var ok bool
+	err := r.db.QueryRow(ctx, query, parentUserID, studentID).Scan(&ok)
+	return ok, err
+}
+
+// GetGuardians retrieves the parent accounts linked to a student
+func (r *Repository) GetGuardians(ctx context.Context, studentID uuid.UUID) ([]Guardian, error) {
+	query := `
+		SELECT u.id, u.full_name, u.email, u.phone, ps.relationship, ps.created_at
+		FROM parent_students ps
+		JOIN users u ON u.id = ps.parent_user_id
+		WHERE ps.student_id = $1 AND ($2::uuid IS NULL OR ps.school_id = $2)
+		ORDER BY ps.created_at
+	`
+	rows, err := r.db.Query(ctx, query, studentID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []Guardian{}
+	for rows.Next() {
+		var g Guardian
+		if err := rows.Scan(&g.ParentUserID, &g.FullName, &g.Email, &g.Phone, &g.Relationship, &g.LinkedAt); err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, rows.Err()
+}
+
+// LinkParent links a parent to a student unless the student already has the
+// maximum number of guardians. Returns false when the limit was reached.
+// Re-linking an existing pair only updates the relationship.
+func (r *Repository) LinkParent(ctx context.Context, parentUserID, studentID uuid.UUID, relationship string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the student so concurrent links cannot both pass the count check
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM students WHERE id = $1 FOR UPDATE`, studentID); err != nil {
+		return false, err
+	}
+
+	var others int
+	err = tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM parent_students WHERE student_id = $1 AND parent_user_id <> $2`,
+		studentID, parentUserID,
+	).Scan(&others)
+	if err != nil {
+		return false, err
+	}
+	if others >= MaxGuardiansPerStudent {
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO parent_students (parent_user_id, student_id, relationship, school_id)
+		SELECT $1::uuid, s.id, $3::varchar, s.school_id FROM students s WHERE s.id = $2
+		ON CONFLICT (parent_user_id, student_id) DO UPDATE SET relationship = EXCLUDED.relationship
+	`
+	if _, err := tx.Exec(ctx, query, parentUserID, studentID, relationship); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// UnlinkParent removes a parent-student link. Returns false if no link existed.
+func (r *Repository) UnlinkParent(ctx context.Context, parentUserID, studentID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM parent_students
+		WHERE parent_user_id = $1 AND student_id = $2 AND ($3::uuid IS NULL OR school_id = $3)
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, parentUserID, studentID, tenant.SchoolID(ctx))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,173 @@
+package parent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/academic"
+	"github.com/schools24/backend/internal/modules/student"
+	"github.com/schools24/backend/internal/modules/teacher"
+)
+
+// Service handles parent portal business logic
+type Service struct {
+	repo            *Repository
+	studentService  *student.Service
+	academicService *academic.Service
+	config          *config.Config
+}
+
+// Common errors
+var (
+	ErrChildNotFound    = errors.New("child not found")
+	ErrStudentNotFound  = errors.New("student not found")
+	ErrNotParentAccount = errors.New("user is not a parent in this school")
+	ErrGuardianLimit    = errors.New("student already has the maximum number of guardians")
+	ErrLinkNotFound     = errors.New("parent is not linked to this student")
+	ErrNoClassAssigned  = errors.New("child is not assigned to a class")
+)
+
+// NewService creates a new parent service
+func NewService(repo *Repository, studentService *student.Service, academicService *academic.Service, cfg *config.Config) *Service {
+	return &Service{
+		repo:            repo,
+		studentService:  studentService,
+		academicService: academicService,
+		config:          cfg,
+	}
+}
+
+// GetChildren returns the students linked to a parent
+func (s *Service) GetChildren(ctx context.Context, parentUserID uuid.UUID) ([]Child, error) {
+	return s.repo.GetChildren(ctx, parentUserID)
+}
+
+// GetChild returns a linked child; unlinked students are reported as not found
+func (s *Service) GetChild(ctx context.Context, parentUserID, studentID uuid.UUID) (*Child, error) {
+	child, err := s.repo.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil {
+		return nil, ErrChildNotFound
+	}
+	return child, nil
+}
+
+// GetChildAttendance returns a child's attendance records and stats
+func (s *Service) GetChildAttendance(ctx context.Context, parentUserID, studentID uuid.UUID, startDate, endDate time.Time) ([]student.Attendance, *student.AttendanceStats, error) {
+	child, err := s.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.studentService.GetStudentAttendance(ctx, child.StudentID, startDate, endDate)
+}
+
+// GetChildHomework returns homework for a child's class
+func (s *Service) GetChildHomework(ctx context.Context, parentUserID, studentID uuid.UUID, status string) ([]academic.Homework, error) {
+	child, err := s.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if child.ClassID == nil {
+		return nil, ErrNoClassAssigned
+	}
+	return s.academicService.GetClassHomework(ctx, *child.ClassID, status)
+}
+
+// GetChildGrades returns a child's grades
+func (s *Service) GetChildGrades(ctx context.Context, parentUserID, studentID uuid.UUID, academicYear string) ([]academic.Grade, error) {
+	child, err := s.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.academicService.GetStudentGrades(ctx, child.StudentID, academicYear)
+}
+
+// GetChildTimetable returns the timetable of a child's class
+func (s *Service) GetChildTimetable(ctx context.Context, parentUserID, studentID uuid.UUID) ([]academic.DaySchedule, error) {
+	child, err := s.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if child.ClassID == nil {
+		return nil, ErrNoClassAssigned
+	}
+	return s.academicService.GetClassTimetable(ctx, *child.ClassID)
+}
+
+// GetChildAnnouncements returns announcements relevant to a child's parents
+func (s *Service) GetChildAnnouncements(ctx context.Context, parentUserID, studentID uuid.UUID, limit int) ([]teacher.Announcement, error) {
+	child, err := s.GetChild(ctx, parentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	return s.repo.GetChildAnnouncements(ctx, child.ClassID, limit)
+}
+
+// GetGuardians returns the parents linked to a student (admin)
+func (s *Service) GetGuardians(ctx context.Context, studentID uuid.UUID) ([]Guardian, error) {
+	if err := s.requireStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGuardians(ctx, studentID)
+}
+
+// LinkParent links a parent account to a student (admin)
+func (s *Service) LinkParent(ctx context.Context, studentID uuid.UUID, req *LinkParentRequest) ([]Guardian, error) {
+	if err := s.requireStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	isParent, err := s.repo.IsParentAccount(ctx, req.ParentUserID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !isParent {
+		return nil, ErrNotParentAccount
+	}
+
+	relationship := req.Relationship
+	if relationship == "" {
+		relationship = "guardian"
+	}
+	linked, err := s.repo.LinkParent(ctx, req.ParentUserID, studentID, relationship)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, ErrGuardianLimit
+	}
+
+	return s.repo.GetGuardians(ctx, studentID)
+}
+
+// UnlinkParent removes a parent-student link (admin)
+func (s *Service) UnlinkParent(ctx context.Context, studentID, parentUserID uuid.UUID) error {
+	removed, err := s.repo.UnlinkParent(ctx, parentUserID, studentID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// requireStudent checks that a student exists in the current school
+func (s *Service) requireStudent(ctx context.Context, studentID uuid.UUID) error {
+	exists, err := s.repo.StudentExists(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrStudentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package role
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/middleware"
+)
+
+// Handler handles HTTP requests for role management
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new role handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetPermissions returns the permission catalog
+// GET /api/v1/admin/permissions
+func (h *Handler) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": h.service.GetPermissions()})
+}
+
+// GetRoles returns all roles visible to the school
+// GET /api/v1/admin/roles
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.service.GetRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// CreateRole creates a custom role
+// POST /api/v1/admin/roles
+func (h *Handler) CreateRole(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), actorID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole updates a role's description or permissions
+// PUT /api/v1/admin/roles/:id
+func (h *Handler) UpdateRole(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role ID"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), actorID, roleID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes a custom role
+// DELETE /api/v1/admin/roles/:id
+func (h *Handler) DeleteRole(c *gin.Context) {
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role ID"})
+		return
+	}
+
+	if err := h.service.DeleteRole(c.Request.Context(), roleID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// GetUserRoles returns a user's roles and effective permissions
+// GET /api/v1/admin/users/:id/roles
+func (h *Handler) GetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	roles, err := h.service.GetUserRoles(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRoles replaces a user's additional roles
+// PUT /api/v1/admin/users/:id/roles
+func (h *Handler) SetUserRoles(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var req SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := h.service.SetUserRoles(c.Request.Context(), actorID, userID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// respondError maps role errors to responses
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "role_not_found"})
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found"})
+	case errors.Is(err, ErrRoleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "role_exists"})
+	case errors.Is(err, ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown_permission"})
+	case errors.Is(err, ErrSystemRole), errors.Is(err, ErrPermissionDenied), errors.Is(err, ErrPlatformPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
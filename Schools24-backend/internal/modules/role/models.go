@@ -0,0 +1,52 @@
+package role
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is a named set of permissions. Roles without a school are shared by all schools.
+type Role struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	SchoolID    *uuid.UUID `json:"school_id,omitempty" db:"school_id"`
+	Name        string     `json:"name" db:"name"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	IsSystem    bool       `json:"is_system" db:"is_system"` // Built-in, matches users.role
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Permission describes a permission key
+type Permission struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Platform    bool   `json:"platform,omitempty"`
+}
+
+// UserRoles lists a user's primary role and any additional roles
+type UserRoles struct {
+	UserID      uuid.UUID `json:"user_id"`
+	PrimaryRole string    `json:"primary_role"`
+	Roles       []Role    `json:"roles"`
+	Permissions []string  `json:"permissions"`
+}
+
+// CreateRoleRequest for creating a custom role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest for changing a role's description or permission set
+type UpdateRoleRequest struct {
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// SetUserRolesRequest replaces a user's additional roles
+type SetUserRolesRequest struct {
+	RoleIDs []uuid.UUID `json:"role_ids" binding:"required"`
+}
@@ -0,0 +1,266 @@
+package role
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Repository handles database operations for roles and permissions
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new role repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// roleColumns selects a role with its permission keys
+const roleColumns = `
+	r.id, r.school_id, r.name, r.description, r.is_system, r.created_at, r.updated_at,
+	COALESCE(ARRAY(SELECT rp.permission_key FROM role_permissions rp WHERE rp.role_id = r.id ORDER BY rp.permission_key), '{}')
+`
+
+// GetRoles retrieves shared roles and the current school's roles
+func (r *Repository) GetRoles(ctx context.Context) ([]Role, error) {
+	query := `
+		SELECT ` + roleColumns + `
+		FROM roles r
+		WHERE r.school_id IS NULL OR $1::uuid IS NULL OR r.school_id = $1
+		ORDER BY r.is_system DESC, r.name
+	`
+	return r.queryRoles(ctx, query, tenant.SchoolID(ctx))
+}
+
+// GetRoleByID retrieves a role visible to the current school
+func (r *Repository) GetRoleByID(ctx context.Context, roleID uuid.UUID) (*Role, error) {
+	query := `
+		SELECT ` + roleColumns + `
+		FROM roles r
+		WHERE r.id = $1 AND (r.school_id IS NULL OR $2::uuid IS NULL OR r.school_id = $2)
+	`
+	roles, err := r.queryRoles(ctx, query, roleID, tenant.SchoolID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	return &roles[0], nil
+}
+
+// queryRoles scans roles selected with roleColumns
+func (r *Repository) queryRoles(ctx context.Context, query string, args ...interface{}) ([]Role, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.SchoolID, &role.Name, &role.Description, &role.IsSystem,
+			&role.CreatedAt, &role.UpdatedAt, &role.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// NameExists checks if a role name is taken in the current school (or among shared roles)
+func (r *Repository) NameExists(ctx context.Context, name string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM roles
+			WHERE name = $1 AND (school_id IS NULL OR school_id = $2)
+		)
+	`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, name, tenant.SchoolID(ctx)).Scan(&exists)
+	return exists, err
+}
+
+// CreateRole creates a role with its permissions in the current school.
+// Unscoped callers create a role shared by all schools.
+func (r *Repository) CreateRole(ctx context.Context, req *CreateRoleRequest) (uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO roles (school_id, name, description, is_system)
+		VALUES ($1, $2, NULLIF($3, ''), false)
+		RETURNING id
+	`
+	var roleID uuid.UUID
+	if err := tx.QueryRow(ctx, query, tenant.SchoolID(ctx), req.Name, req.Description).Scan(&roleID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := replaceRolePermissions(ctx, tx, roleID, req.Permissions); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	return roleID, nil
+}
+
+// UpdateRole updates a role's description and, if given, replaces its permissions
+func (r *Repository) UpdateRole(ctx context.Context, roleID uuid.UUID, req *UpdateRoleRequest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE roles SET
+			description = COALESCE($2, description),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, query, roleID, req.Description); err != nil {
+		return err
+	}
+
+	if req.Permissions != nil {
+		if err := replaceRolePermissions(ctx, tx, roleID, req.Permissions); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteRole deletes a custom role; its assignments are removed with it
+func (r *Repository) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
+	query := `DELETE FROM roles WHERE id = $1 AND is_system = false`
+	return r.db.Exec(ctx, query, roleID)
+}
+
+// GetRoleUserIDs lists users holding a role as an additional role
+func (r *Repository) GetRoleUserIDs(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `SELECT user_id FROM user_roles WHERE role_id = $1`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetUserPrimaryRole returns a user's users.role, scoped to the current school
+func (r *Repository) GetUserPrimaryRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	query := `SELECT role FROM users WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)`
+	var role string
+	err := r.db.QueryRow(ctx, query, userID, tenant.SchoolID(ctx)).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// GetUserRoles retrieves a user's additional roles
+func (r *Repository) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]Role, error) {
+	query := `
+		SELECT ` + roleColumns + `
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`
+	return r.queryRoles(ctx, query, userID)
+}
+
+// SetUserRoles replaces a user's additional roles. Roles of other schools are skipped.
+func (r *Repository) SetUserRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, ro.id
+		FROM users u, roles ro
+		WHERE u.id = $1 AND ro.id = $2
+		  AND (ro.school_id IS NULL OR ro.school_id = u.school_id)
+		ON CONFLICT DO NOTHING
+	`
+	for _, roleID := range roleIDs {
+		if _, err := tx.Exec(ctx, insertQuery, userID, roleID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UserPermissions returns the union of permissions from a user's built-in role
+// (matching users.role) and additional roles. Implements middleware.PermissionResolver.
+func (r *Repository) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	query := `
+		SELECT DISTINCT rp.permission_key
+		FROM role_permissions rp
+		JOIN roles ro ON ro.id = rp.role_id
+		JOIN users u ON u.id = $1
+		WHERE (ro.is_system AND ro.school_id IS NULL AND ro.name = u.role)
+		   OR ro.id IN (SELECT role_id FROM user_roles WHERE user_id = u.id)
+		ORDER BY rp.permission_key
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, key)
+	}
+	return permissions, rows.Err()
+}
+
+// replaceRolePermissions swaps a role's permission set inside a transaction
+func replaceRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissions []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return err
+	}
+	for _, key := range permissions {
+		if _, err := tx.Exec(ctx, `INSERT INTO role_permissions (role_id, permission_key) VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/permission"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Service handles role management business logic
+type Service struct {
+	repo     *Repository
+	authz    *middleware.Authorizer
+	denylist *middleware.TokenDenylist
+	config   *config.Config
+}
+
+// Common errors
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleExists         = errors.New("role name already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrSystemRole         = errors.New("built-in roles can only be changed by a super admin")
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrPermissionDenied   = errors.New("cannot grant a permission you do not hold")
+	ErrPlatformPermission = errors.New("platform permissions can only be granted to shared roles")
+)
+
+// NewService creates a new role service
+func NewService(repo *Repository, authz *middleware.Authorizer, denylist *middleware.TokenDenylist, cfg *config.Config) *Service {
+	return &Service{
+		repo:     repo,
+		authz:    authz,
+		denylist: denylist,
+		config:   cfg,
+	}
+}
+
+// GetPermissions returns the permission catalog
+func (s *Service) GetPermissions() []Permission {
+	permissions := make([]Permission, 0, len(permission.Catalog))
+	for _, p := range permission.Catalog {
+		permissions = append(permissions, Permission{Key: p.Key, Description: p.Description, Platform: p.Platform})
+	}
+	return permissions
+}
+
+// GetRoles returns roles visible to the current school
+func (s *Service) GetRoles(ctx context.Context) ([]Role, error) {
+	return s.repo.GetRoles(ctx)
+}
+
+// GetRole returns a single role
+func (s *Service) GetRole(ctx context.Context, roleID uuid.UUID) (*Role, error) {
+	role, err := s.repo.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// CreateRole creates a custom role for the current school
+func (s *Service) CreateRole(ctx context.Context, actorID uuid.UUID, req *CreateRoleRequest) (*Role, error) {
+	if err := s.checkGrantable(ctx, actorID, req.Permissions, tenant.SchoolID(ctx) == nil); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.NameExists(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleExists
+	}
+
+	roleID, err := s.repo.CreateRole(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRole(ctx, roleID)
+}
+
+// UpdateRole changes a role. Shared roles can only be changed by unscoped super admins.
+func (s *Service) UpdateRole(ctx context.Context, actorID, roleID uuid.UUID, req *UpdateRoleRequest) (*Role, error) {
+	role, err := s.GetRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.SchoolID == nil && tenant.SchoolID(ctx) != nil {
+		return nil, ErrSystemRole
+	}
+
+	if req.Permissions != nil {
+		if err := s.checkGrantable(ctx, actorID, req.Permissions, role.SchoolID == nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateRole(ctx, roleID, req); err != nil {
+		return nil, err
+	}
+	if req.Permissions != nil {
+		s.invalidateRoleUsers(ctx, roleID)
+	}
+	return s.GetRole(ctx, roleID)
+}
+
+// DeleteRole deletes a custom role
+func (s *Service) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
+	role, err := s.GetRole(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem || (role.SchoolID == nil && tenant.SchoolID(ctx) != nil) {
+		return ErrSystemRole
+	}
+
+	// Collect holders first; the assignments cascade away with the role
+	userIDs, err := s.repo.GetRoleUserIDs(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteRole(ctx, roleID); err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		s.invalidate(ctx, userID)
+	}
+	return nil
+}
+
+// GetUserRoles returns a user's roles and effective permissions
+func (s *Service) GetUserRoles(ctx context.Context, userID uuid.UUID) (*UserRoles, error) {
+	primary, err := s.repo.GetUserPrimaryRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if primary == "" {
+		return nil, ErrUserNotFound
+	}
+
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.repo.UserPermissions(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserRoles{
+		UserID:      userID,
+		PrimaryRole: primary,
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// SetUserRoles replaces a user's additional roles
+func (s *Service) SetUserRoles(ctx context.Context, actorID, userID uuid.UUID, req *SetUserRolesRequest) (*UserRoles, error) {
+	primary, err := s.repo.GetUserPrimaryRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if primary == "" {
+		return nil, ErrUserNotFound
+	}
+
+	// Assigning a role grants its permissions, so the same escalation rule applies
+	for _, roleID := range req.RoleIDs {
+		role, err := s.GetRole(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkGrantable(ctx, actorID, role.Permissions, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetUserRoles(ctx, userID, req.RoleIDs); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, userID)
+
+	// Access tokens list the user's roles, which RequireMFA checks; revoking
+	// them makes the next refresh issue tokens with the new roles
+	if err := s.denylist.RevokeUser(ctx, userID.String()); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserRoles(ctx, userID)
+}
+
+// checkGrantable rejects unknown permissions, permissions the actor lacks,
+// and platform permissions on school roles
+func (s *Service) checkGrantable(ctx context.Context, actorID uuid.UUID, permissions []string, shared bool) error {
+	held, err := s.authz.Permissions(ctx, actorID.String())
+	if err != nil {
+		return err
+	}
+	for _, key := range permissions {
+		def, ok := permission.Lookup(key)
+		if !ok {
+			return ErrUnknownPermission
+		}
+		if def.Platform && !shared {
+			return ErrPlatformPermission
+		}
+		if !held[key] {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// invalidateRoleUsers drops cached permissions of everyone holding a role
+func (s *Service) invalidateRoleUsers(ctx context.Context, roleID uuid.UUID) {
+	userIDs, err := s.repo.GetRoleUserIDs(ctx, roleID)
+	if err != nil {
+		log.Printf("Failed to list holders of role %s: %v", roleID, err)
+		return
+	}
+	for _, userID := range userIDs {
+		s.invalidate(ctx, userID)
+	}
+}
+
+// invalidate drops a user's cached permissions; stale entries expire on their own
+func (s *Service) invalidate(ctx context.Context, userID uuid.UUID) {
+	if err := s.authz.Invalidate(ctx, userID.String()); err != nil {
+		log.Printf("Failed to invalidate permissions for user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,145 @@
+package rollover
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Handler handles HTTP requests for academic-year rollovers
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new rollover handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// bindRequest reads an optional rollover request; an empty body uses the defaults
+func bindRequest(c *gin.Context) (*RolloverRequest, bool) {
+	var req RolloverRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+// PreviewRollover returns each student's outcome and what would be copied and archived
+// POST /api/v1/admin/rollovers/preview
+func (h *Handler) PreviewRollover(c *gin.Context) {
+	req, ok := bindRequest(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.Preview(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan})
+}
+
+// StartRollover moves the school into the next academic year
+// POST /api/v1/admin/rollovers
+func (h *Handler) StartRollover(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	req, ok := bindRequest(c)
+	if !ok {
+		return
+	}
+
+	rollover, plan, err := h.service.StartRollover(c.Request.Context(), actorID, req, c.ClientIP(), c.Request.UserAgent())
+	switch {
+	case errors.Is(err, ErrPlanHasProblems):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "plan_has_problems", "plan": plan})
+	case errors.Is(err, ErrRolloverExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "rollover_exists", "rollover": rollover})
+	case err != nil:
+		respondRunError(c, rollover, err)
+	default:
+		c.JSON(http.StatusCreated, gin.H{"rollover": rollover})
+	}
+}
+
+// ResumeRollover runs the steps a failed or interrupted rollover has left
+// POST /api/v1/admin/rollovers/:id/resume
+func (h *Handler) ResumeRollover(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rollover ID"})
+		return
+	}
+
+	rollover, err := h.service.ResumeRollover(c.Request.Context(), actorID, id, c.ClientIP(), c.Request.UserAgent())
+	switch {
+	case errors.Is(err, ErrRolloverCompleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "rollover_completed", "rollover": rollover})
+	case err != nil:
+		respondRunError(c, rollover, err)
+	default:
+		c.JSON(http.StatusOK, gin.H{"rollover": rollover})
+	}
+}
+
+// GetRollovers lists the school's rollovers
+// GET /api/v1/admin/rollovers
+func (h *Handler) GetRollovers(c *gin.Context) {
+	rollovers, err := h.service.GetRollovers(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollovers": rollovers})
+}
+
+// GetRollover returns a rollover with its progress and summary
+// GET /api/v1/admin/rollovers/:id
+func (h *Handler) GetRollover(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rollover ID"})
+		return
+	}
+
+	rollover, err := h.service.GetRollover(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollover": rollover})
+}
+
+// respondRunError reports a rollover that stopped, with its recorded progress
+func respondRunError(c *gin.Context, rollover *Rollover, err error) {
+	if errors.Is(err, ErrRolloverStepFailed) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "rollover_failed", "message": err.Error(), "rollover": rollover})
+		return
+	}
+	respondError(c, err)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidYear):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_academic_year", "message": err.Error()})
+	case errors.Is(err, ErrRolloverNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "rollover_not_found"})
+	case errors.Is(err, ErrRolloverRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": "rollover_running", "message": err.Error()})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,108 @@
+package rollover
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Student outcomes of a rollover
+const (
+	OutcomePromoted  = "promoted"  // moves to the next grade
+	OutcomeDetained  = "detained"  // repeats the grade
+	OutcomeLeft      = "left"      // leaves the school
+	OutcomeGraduated = "graduated" // completes the school's highest grade
+)
+
+// Rollover is an academic-year rollover of a school
+type Rollover struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	FromYear    string          `json:"from_year" db:"from_year"`
+	ToYear      string          `json:"to_year" db:"to_year"`
+	Status      string          `json:"status" db:"status"` // in_progress, completed, failed
+	Step        int             `json:"step" db:"step"`     // steps completed
+	Steps       []string        `json:"steps"`
+	Summary     json.RawMessage `json:"summary" db:"summary"`
+	Error       *string         `json:"error,omitempty" db:"error"`
+	CreatedBy   *uuid.UUID      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// RolloverRequest describes a rollover to preview or run. Years default to the
+// current academic year and the one after it.
+type RolloverRequest struct {
+	FromYear  string            `json:"from_year" binding:"omitempty,max=20"`
+	ToYear    string            `json:"to_year" binding:"omitempty,max=20"`
+	Overrides []StudentOverride `json:"overrides" binding:"omitempty,dive"`
+}
+
+// StudentOverride replaces a student's default outcome. ClassID picks the class
+// a promoted or detained student joins: a class of the new year, or a class of
+// the old year meaning the class it is cloned into.
+type StudentOverride struct {
+	StudentID uuid.UUID  `json:"student_id" binding:"required"`
+	Outcome   string     `json:"outcome" binding:"required,oneof=promoted detained left graduated"`
+	ClassID   *uuid.UUID `json:"class_id,omitempty"`
+}
+
+// Plan is what a rollover will do
+type Plan struct {
+	FromYear    string           `json:"from_year"`
+	ToYear      string           `json:"to_year"`
+	Classes     []PlannedClass   `json:"classes"`
+	Students    []PlannedStudent `json:"students"`
+	Outcomes    map[string]int   `json:"outcomes"`
+	Assignments int              `json:"teacher_assignments"`
+	Timetables  int              `json:"timetable_entries_to_archive"`
+	Homework    int              `json:"homework_to_archive"`
+	Problems    []PlanProblem    `json:"problems"`
+}
+
+// PlannedClass is an old-year class and the new-year class it becomes
+type PlannedClass struct {
+	FromClassID     uuid.UUID  `json:"from_class_id"`
+	Name            string     `json:"name"`
+	Grade           int        `json:"grade"`
+	Section         *string    `json:"section,omitempty"`
+	ExistingClassID *uuid.UUID `json:"existing_class_id,omitempty"` // the new year already has it
+}
+
+// PlannedStudent is a student's outcome. TargetClassID is an old-year class
+// (joined through its clone) or a new-year class.
+type PlannedStudent struct {
+	StudentID       uuid.UUID  `json:"student_id"`
+	FullName        string     `json:"full_name"`
+	AdmissionNumber string     `json:"admission_number"`
+	FromClassID     uuid.UUID  `json:"from_class_id"`
+	FromClassName   string     `json:"from_class_name"`
+	Outcome         string     `json:"outcome"`
+	TargetClassID   *uuid.UUID `json:"target_class_id,omitempty"`
+	TargetClassName string     `json:"target_class_name,omitempty"`
+	Overridden      bool       `json:"overridden"`
+}
+
+// PlanProblem must be fixed, usually with an override, before the rollover can run
+type PlanProblem struct {
+	StudentID *uuid.UUID `json:"student_id,omitempty"`
+	Message   string     `json:"message"`
+}
+
+// yearClass is a class of the old or new year
+type yearClass struct {
+	ID      uuid.UUID
+	Name    string
+	Grade   int
+	Section *string
+	Year    string
+}
+
+// yearStudent is an active student of an old-year class
+type yearStudent struct {
+	ID              uuid.UUID
+	FullName        string
+	AdmissionNumber string
+	ClassID         uuid.UUID
+}
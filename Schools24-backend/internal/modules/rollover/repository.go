@@ -0,0 +1,250 @@
+package rollover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+)
+
+// Repository handles database operations for academic-year rollovers
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new rollover repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetYearClasses retrieves a school's classes of an academic year
+func (r *Repository) GetYearClasses(ctx context.Context, schoolID uuid.UUID, year string) ([]yearClass, error) {
+	query := `
+		SELECT id, name, grade, section, academic_year
+		FROM classes
+		WHERE school_id = $1 AND academic_year = $2
+		ORDER BY grade, section, name
+	`
+	rows, err := r.db.Query(ctx, query, schoolID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []yearClass
+	for rows.Next() {
+		var c yearClass
+		if err := rows.Scan(&c.ID, &c.Name, &c.Grade, &c.Section, &c.Year); err != nil {
+			return nil, err
+		}
+		classes = append(classes, c)
+	}
+	return classes, rows.Err()
+}
+
+// GetYearStudents retrieves the active students of a school's classes of an academic year
+func (r *Repository) GetYearStudents(ctx context.Context, schoolID uuid.UUID, year string) ([]yearStudent, error) {
+	query := `
+		SELECT s.id, u.full_name, s.admission_number, s.class_id
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		JOIN classes c ON c.id = s.class_id
+		WHERE s.school_id = $1 AND c.academic_year = $2 AND s.status = 'active'
+		ORDER BY c.grade, c.section, s.roll_number, u.full_name
+	`
+	rows, err := r.db.Query(ctx, query, schoolID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []yearStudent
+	for rows.Next() {
+		var s yearStudent
+		if err := rows.Scan(&s.ID, &s.FullName, &s.AdmissionNumber, &s.ClassID); err != nil {
+			return nil, err
+		}
+		students = append(students, s)
+	}
+	return students, rows.Err()
+}
+
+// CountYearRecords counts what a rollover of a year copies and archives
+func (r *Repository) CountYearRecords(ctx context.Context, schoolID uuid.UUID, year string) (assignments, timetables, homework int, err error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM teacher_assignments ta
+			 JOIN teachers t ON t.id = ta.teacher_id
+			 JOIN users u ON u.id = t.user_id AND u.is_active
+			 WHERE ta.school_id = $1 AND ta.academic_year = $2),
+			(SELECT COUNT(*) FROM timetables
+			 WHERE school_id = $1 AND academic_year = $2 AND archived_at IS NULL),
+			(SELECT COUNT(*) FROM homework h
+			 JOIN classes c ON c.id = h.class_id
+			 WHERE h.school_id = $1 AND c.academic_year = $2 AND h.status <> 'archived')
+	`
+	err = r.db.QueryRow(ctx, query, schoolID, year).Scan(&assignments, &timetables, &homework)
+	return assignments, timetables, homework, err
+}
+
+const rolloverSelect = `
+	SELECT id, from_year, to_year, status, step, summary, error, created_by, created_at, updated_at, completed_at
+	FROM academic_rollovers
+`
+
+func scanRollover(row pgx.Row) (*Rollover, error) {
+	var ro Rollover
+	err := row.Scan(&ro.ID, &ro.FromYear, &ro.ToYear, &ro.Status, &ro.Step, &ro.Summary, &ro.Error,
+		&ro.CreatedBy, &ro.CreatedAt, &ro.UpdatedAt, &ro.CompletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ro, nil
+}
+
+// GetRollover retrieves a school's rollover by ID
+func (r *Repository) GetRollover(ctx context.Context, schoolID, id uuid.UUID) (*Rollover, error) {
+	return scanRollover(r.db.QueryRow(ctx, rolloverSelect+` WHERE school_id = $1 AND id = $2`, schoolID, id))
+}
+
+// GetRolloverByYear retrieves the rollover out of an academic year
+func (r *Repository) GetRolloverByYear(ctx context.Context, schoolID uuid.UUID, fromYear string) (*Rollover, error) {
+	return scanRollover(r.db.QueryRow(ctx, rolloverSelect+` WHERE school_id = $1 AND from_year = $2`, schoolID, fromYear))
+}
+
+// GetRollovers lists a school's rollovers, newest first
+func (r *Repository) GetRollovers(ctx context.Context, schoolID uuid.UUID) ([]Rollover, error) {
+	rows, err := r.db.Query(ctx, rolloverSelect+` WHERE school_id = $1 ORDER BY created_at DESC`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollovers := []Rollover{}
+	for rows.Next() {
+		ro, err := scanRollover(rows)
+		if err != nil {
+			return nil, err
+		}
+		rollovers = append(rollovers, *ro)
+	}
+	return rollovers, rows.Err()
+}
+
+// CreateRollover records a rollover and its student plan. It returns false if
+// the school already has a rollover out of the same year.
+func (r *Repository) CreateRollover(ctx context.Context, schoolID, actorID uuid.UUID, plan *Plan) (uuid.UUID, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO academic_rollovers (school_id, from_year, to_year, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (school_id, from_year) DO NOTHING
+		RETURNING id
+	`, schoolID, plan.FromYear, plan.ToYear, actorID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	studentIDs := make([]string, len(plan.Students))
+	fromClassIDs := make([]string, len(plan.Students))
+	outcomes := make([]string, len(plan.Students))
+	targetIDs := make([]string, len(plan.Students))
+	for i, st := range plan.Students {
+		studentIDs[i] = st.StudentID.String()
+		fromClassIDs[i] = st.FromClassID.String()
+		outcomes[i] = st.Outcome
+		if st.TargetClassID != nil {
+			targetIDs[i] = st.TargetClassID.String()
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO academic_rollover_students (rollover_id, student_id, from_class_id, outcome, target_class_id)
+		SELECT $1, s::uuid, f::uuid, o, NULLIF(t, '')::uuid
+		FROM unnest($2::text[], $3::text[], $4::text[], $5::text[]) AS p(s, f, o, t)
+	`, id, studentIDs, fromClassIDs, outcomes, targetIDs); err != nil {
+		return uuid.Nil, false, err
+	}
+
+	return id, true, tx.Commit(ctx)
+}
+
+// RunStep runs one step of a rollover in a transaction that also records it
+// as done, so each step happens exactly once. Steps already done are skipped;
+// a rollover being run by another request yields ErrRolloverRunning.
+func (r *Repository) RunStep(ctx context.Context, schoolID, id uuid.UUID, index int, step rolloverStep, last bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var ro Rollover
+	err = tx.QueryRow(ctx, `
+		SELECT id, from_year, to_year, step FROM academic_rollovers
+		WHERE school_id = $1 AND id = $2
+		FOR UPDATE SKIP LOCKED
+	`, schoolID, id).Scan(&ro.ID, &ro.FromYear, &ro.ToYear, &ro.Step)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrRolloverRunning
+	}
+	if err != nil {
+		return err
+	}
+	if ro.Step > index {
+		return nil
+	}
+
+	counts, err := step.run(ctx, tx, schoolID, &ro)
+	if err != nil {
+		return err
+	}
+	summary, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE academic_rollovers
+		SET step = $2, summary = summary || $3::jsonb, error = NULL, updated_at = CURRENT_TIMESTAMP,
+		    status = CASE WHEN $4 THEN 'completed' ELSE 'in_progress' END,
+		    completed_at = CASE WHEN $4 THEN CURRENT_TIMESTAMP END
+		WHERE id = $1
+	`, id, index+1, string(summary), last); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MarkRolloverFailed records why a rollover stopped
+func (r *Repository) MarkRolloverFailed(ctx context.Context, id uuid.UUID, cause string) error {
+	query := `
+		UPDATE academic_rollovers
+		SET status = 'failed', error = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status <> 'completed'
+	`
+	return r.db.Exec(ctx, query, id, cause)
+}
+
+// MarkRolloverResumed clears a failure before a rollover is run again
+func (r *Repository) MarkRolloverResumed(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE academic_rollovers
+		SET status = 'in_progress', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'failed'
+	`
+	return r.db.Exec(ctx, query, id)
+}
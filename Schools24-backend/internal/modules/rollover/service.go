@@ -0,0 +1,385 @@
+package rollover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/settings"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// AuditLogger records rollovers (implemented by admin.Repository)
+type AuditLogger interface {
+	LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error
+}
+
+// Service handles academic-year rollovers. A rollover runs as a sequence of
+// transactional steps and can be resumed from the first step that failed.
+type Service struct {
+	repo     *Repository
+	settings *settings.Service
+	audit    AuditLogger
+	config   *config.Config
+}
+
+// Common errors
+var (
+	ErrInvalidYear        = errors.New("academic years must look like 2025-2026 and follow each other")
+	ErrPlanHasProblems    = errors.New("rollover plan has problems")
+	ErrRolloverExists     = errors.New("a rollover out of this academic year already exists")
+	ErrRolloverNotFound   = errors.New("rollover not found")
+	ErrRolloverCompleted  = errors.New("rollover already completed")
+	ErrRolloverRunning    = errors.New("rollover is being run by another request")
+	ErrRolloverStepFailed = errors.New("rollover step failed")
+)
+
+var academicYearPattern = regexp.MustCompile(`^(\d{4})-(\d{4})$`)
+
+// NewService creates a new rollover service
+func NewService(repo *Repository, settingsService *settings.Service, audit AuditLogger, cfg *config.Config) *Service {
+	return &Service{
+		repo:     repo,
+		settings: settingsService,
+		audit:    audit,
+		config:   cfg,
+	}
+}
+
+// Preview returns what a rollover would do without changing anything
+func (s *Service) Preview(ctx context.Context, req *RolloverRequest) (*Plan, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildPlan(ctx, schoolID, req)
+}
+
+// StartRollover plans and runs a rollover. A plan with problems is returned
+// with ErrPlanHasProblems and nothing is changed.
+func (s *Service) StartRollover(ctx context.Context, actorID uuid.UUID, req *RolloverRequest, ipAddress, userAgent string) (*Rollover, *Plan, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := s.buildPlan(ctx, schoolID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(plan.Problems) > 0 {
+		return nil, plan, ErrPlanHasProblems
+	}
+
+	id, created, err := s.repo.CreateRollover(ctx, schoolID, actorID, plan)
+	if err != nil {
+		return nil, plan, err
+	}
+	if !created {
+		existing, err := s.repo.GetRolloverByYear(ctx, schoolID, plan.FromYear)
+		if err != nil {
+			return nil, plan, err
+		}
+		if existing != nil {
+			existing.Steps = stepNames()
+		}
+		return existing, plan, ErrRolloverExists
+	}
+
+	newValues := map[string]interface{}{"from_year": plan.FromYear, "to_year": plan.ToYear, "outcomes": plan.Outcomes}
+	if err := s.audit.LogAudit(ctx, &actorID, "rollover.started", "academic_rollover", &id, nil, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit start of rollover %s: %v", id, err)
+	}
+
+	rollover, err := s.run(ctx, schoolID, actorID, id, ipAddress, userAgent)
+	return rollover, plan, err
+}
+
+// ResumeRollover runs the remaining steps of a rollover that failed or was interrupted
+func (s *Service) ResumeRollover(ctx context.Context, actorID, id uuid.UUID, ipAddress, userAgent string) (*Rollover, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rollover, err := s.repo.GetRollover(ctx, schoolID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rollover == nil {
+		return nil, ErrRolloverNotFound
+	}
+	if rollover.Status == "completed" {
+		rollover.Steps = stepNames()
+		return rollover, ErrRolloverCompleted
+	}
+
+	if err := s.repo.MarkRolloverResumed(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.run(ctx, schoolID, actorID, id, ipAddress, userAgent)
+}
+
+// run runs the steps a rollover has not completed yet. A failed step is
+// recorded on the rollover, which is returned along with the error.
+func (s *Service) run(ctx context.Context, schoolID, actorID, id uuid.UUID, ipAddress, userAgent string) (*Rollover, error) {
+	for i, step := range rolloverSteps {
+		err := s.repo.RunStep(ctx, schoolID, id, i, step, i == len(rolloverSteps)-1)
+		if errors.Is(err, ErrRolloverRunning) {
+			return nil, err
+		}
+		if err != nil {
+			// Record the failure even if the request was cancelled
+			cause := fmt.Sprintf("%s: %v", step.name, err)
+			if markErr := s.repo.MarkRolloverFailed(context.WithoutCancel(ctx), id, cause); markErr != nil {
+				log.Printf("Failed to mark rollover %s failed: %v", id, markErr)
+			}
+			rollover, _ := s.GetRollover(context.WithoutCancel(ctx), id)
+			return rollover, fmt.Errorf("%w: %s", ErrRolloverStepFailed, cause)
+		}
+	}
+
+	rollover, err := s.GetRollover(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	newValues := map[string]interface{}{"from_year": rollover.FromYear, "to_year": rollover.ToYear, "summary": rollover.Summary}
+	if err := s.audit.LogAudit(ctx, &actorID, "rollover.completed", "academic_rollover", &id, nil, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit completion of rollover %s: %v", id, err)
+	}
+	return rollover, nil
+}
+
+// GetRollover returns a rollover of the request's school
+func (s *Service) GetRollover(ctx context.Context, id uuid.UUID) (*Rollover, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rollover, err := s.repo.GetRollover(ctx, schoolID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rollover == nil {
+		return nil, ErrRolloverNotFound
+	}
+	rollover.Steps = stepNames()
+	return rollover, nil
+}
+
+// GetRollovers returns the rollovers of the request's school, newest first
+func (s *Service) GetRollovers(ctx context.Context) ([]Rollover, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rollovers, err := s.repo.GetRollovers(ctx, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rollovers {
+		rollovers[i].Steps = stepNames()
+	}
+	return rollovers, nil
+}
+
+// resolveYears fills in the default years and checks the new year follows the old one
+func (s *Service) resolveYears(ctx context.Context, req *RolloverRequest) (string, string, error) {
+	fromYear := req.FromYear
+	if fromYear == "" {
+		fromYear = s.settings.CurrentAcademicYear(ctx)
+	}
+	match := academicYearPattern.FindStringSubmatch(fromYear)
+	if match == nil {
+		return "", "", ErrInvalidYear
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	if end != start+1 {
+		return "", "", ErrInvalidYear
+	}
+
+	toYear := fmt.Sprintf("%d-%d", start+1, start+2)
+	if req.ToYear != "" && req.ToYear != toYear {
+		return "", "", ErrInvalidYear
+	}
+	return fromYear, toYear, nil
+}
+
+// buildPlan works out each student's outcome. By default students of the
+// highest grade graduate and the others move to the next grade's class with
+// the same section, or to its only class if the section does not exist there.
+func (s *Service) buildPlan(ctx context.Context, schoolID uuid.UUID, req *RolloverRequest) (*Plan, error) {
+	fromYear, toYear, err := s.resolveYears(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	fromClasses, err := s.repo.GetYearClasses(ctx, schoolID, fromYear)
+	if err != nil {
+		return nil, err
+	}
+	toClasses, err := s.repo.GetYearClasses(ctx, schoolID, toYear)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.repo.GetYearStudents(ctx, schoolID, fromYear)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		FromYear: fromYear,
+		ToYear:   toYear,
+		Classes:  []PlannedClass{},
+		Students: []PlannedStudent{},
+		Outcomes: map[string]int{OutcomePromoted: 0, OutcomeDetained: 0, OutcomeLeft: 0, OutcomeGraduated: 0},
+		Problems: []PlanProblem{},
+	}
+	plan.Assignments, plan.Timetables, plan.Homework, err = s.repo.CountYearRecords(ctx, schoolID, fromYear)
+	if err != nil {
+		return nil, err
+	}
+	if len(fromClasses) == 0 {
+		plan.Problems = append(plan.Problems, PlanProblem{Message: fmt.Sprintf("%s has no classes", fromYear)})
+		return plan, nil
+	}
+
+	// The classes of the new year: those it already has, and clones of the
+	// old-year classes it lacks, which students join through the old class
+	classes := map[uuid.UUID]yearClass{}
+	var targets []yearClass
+	maxGrade := 0
+	for _, tc := range toClasses {
+		classes[tc.ID] = tc
+		targets = append(targets, tc)
+	}
+	for _, fc := range fromClasses {
+		classes[fc.ID] = fc
+		if fc.Grade > maxGrade {
+			maxGrade = fc.Grade
+		}
+		planned := PlannedClass{FromClassID: fc.ID, Name: fc.Name, Grade: fc.Grade, Section: fc.Section}
+		if existing := findClass(toClasses, fc.Grade, fc.Section); existing != nil {
+			planned.ExistingClassID = &existing.ID
+		} else {
+			targets = append(targets, fc)
+		}
+		plan.Classes = append(plan.Classes, planned)
+	}
+
+	overrides := map[uuid.UUID]StudentOverride{}
+	for _, o := range req.Overrides {
+		if _, dup := overrides[o.StudentID]; dup {
+			plan.addProblem(o.StudentID, "student has more than one override")
+			continue
+		}
+		overrides[o.StudentID] = o
+	}
+
+	for _, st := range students {
+		from := classes[st.ClassID]
+		planned := PlannedStudent{
+			StudentID:       st.ID,
+			FullName:        st.FullName,
+			AdmissionNumber: st.AdmissionNumber,
+			FromClassID:     st.ClassID,
+			FromClassName:   from.Name,
+			Outcome:         OutcomePromoted,
+		}
+		if from.Grade == maxGrade {
+			planned.Outcome = OutcomeGraduated
+		}
+
+		var classID *uuid.UUID
+		if o, ok := overrides[st.ID]; ok {
+			planned.Outcome = o.Outcome
+			planned.Overridden = true
+			classID = o.ClassID
+			delete(overrides, st.ID)
+		}
+
+		switch planned.Outcome {
+		case OutcomePromoted, OutcomeDetained:
+			var target *yearClass
+			switch {
+			case classID != nil:
+				if c, ok := classes[*classID]; ok {
+					target = &c
+				} else {
+					plan.addProblem(st.ID, fmt.Sprintf("class %s is not a class of %s or %s", classID, fromYear, toYear))
+				}
+			case planned.Outcome == OutcomeDetained:
+				target = &from
+			default:
+				target = promotionTarget(targets, from)
+				if target == nil {
+					plan.addProblem(st.ID, fmt.Sprintf("no class to promote %s of %s into", st.FullName, from.Name))
+				}
+			}
+			if target != nil {
+				planned.TargetClassID = &target.ID
+				planned.TargetClassName = target.Name
+			}
+		default:
+			if classID != nil {
+				plan.addProblem(st.ID, fmt.Sprintf("students who have %s cannot be given a class", planned.Outcome))
+			}
+		}
+
+		plan.Outcomes[planned.Outcome]++
+		plan.Students = append(plan.Students, planned)
+	}
+
+	for _, o := range req.Overrides {
+		if _, unused := overrides[o.StudentID]; unused {
+			plan.addProblem(o.StudentID, fmt.Sprintf("student is not an active student of a %s class", fromYear))
+			delete(overrides, o.StudentID)
+		}
+	}
+	return plan, nil
+}
+
+// addProblem records a problem with a student's outcome
+func (p *Plan) addProblem(studentID uuid.UUID, message string) {
+	p.Problems = append(p.Problems, PlanProblem{StudentID: &studentID, Message: message})
+}
+
+// findClass returns the class of a grade and section
+func findClass(classes []yearClass, grade int, section *string) *yearClass {
+	for i := range classes {
+		if classes[i].Grade == grade && sameSection(classes[i].Section, section) {
+			return &classes[i]
+		}
+	}
+	return nil
+}
+
+// promotionTarget returns the next grade's class with the same section as
+// from, or the next grade's only class
+func promotionTarget(targets []yearClass, from yearClass) *yearClass {
+	if c := findClass(targets, from.Grade+1, from.Section); c != nil {
+		return c
+	}
+	var only *yearClass
+	for i := range targets {
+		if targets[i].Grade != from.Grade+1 {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = &targets[i]
+	}
+	return only
+}
+
+func sameSection(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,201 @@
+package rollover
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// rolloverStep is one transactional step of a rollover. run returns counts
+// that are merged into the rollover's summary.
+type rolloverStep struct {
+	name string
+	run  func(ctx context.Context, tx pgx.Tx, schoolID uuid.UUID, ro *Rollover) (map[string]int, error)
+}
+
+// rolloverSteps run in order; a rollover's step column is the index of the
+// next one to run
+var rolloverSteps = []rolloverStep{
+	{name: "clone_classes", run: cloneClasses},
+	{name: "copy_teacher_assignments", run: copyTeacherAssignments},
+	{name: "move_students", run: moveStudents},
+	{name: "archive_old_year", run: archiveOldYear},
+}
+
+// stepNames lists the names of the rollover steps in order
+func stepNames() []string {
+	names := make([]string, len(rolloverSteps))
+	for i, step := range rolloverSteps {
+		names[i] = step.name
+	}
+	return names
+}
+
+// cloneClasses creates a new-year class for each old-year grade and section the
+// new year does not have yet, and maps every old-year class to its new class
+func cloneClasses(ctx context.Context, tx pgx.Tx, schoolID uuid.UUID, ro *Rollover) (map[string]int, error) {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO classes (name, grade, section, academic_year, room_number, school_id)
+		SELECT DISTINCT ON (c.grade, c.section) c.name, c.grade, c.section, $3, c.room_number, c.school_id
+		FROM classes c
+		WHERE c.school_id = $1 AND c.academic_year = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM classes n
+			WHERE n.school_id = $1 AND n.academic_year = $3
+			  AND n.grade = c.grade AND n.section IS NOT DISTINCT FROM c.section
+		  )
+		ORDER BY c.grade, c.section, c.created_at
+	`, schoolID, ro.FromYear, ro.ToYear)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO academic_rollover_classes (rollover_id, from_class_id, to_class_id)
+		SELECT $4, c.id, (
+			SELECT n.id FROM classes n
+			WHERE n.school_id = $1 AND n.academic_year = $3
+			  AND n.grade = c.grade AND n.section IS NOT DISTINCT FROM c.section
+			ORDER BY n.created_at, n.id
+			LIMIT 1
+		)
+		FROM classes c
+		WHERE c.school_id = $1 AND c.academic_year = $2
+		ON CONFLICT DO NOTHING
+	`, schoolID, ro.FromYear, ro.ToYear, ro.ID); err != nil {
+		return nil, err
+	}
+
+	return map[string]int{"classes_created": int(tag.RowsAffected())}, nil
+}
+
+// copyTeacherAssignments copies the old-year assignments of active teachers
+// to the mapped new-year classes, and class teachers to new classes without one
+func copyTeacherAssignments(ctx context.Context, tx pgx.Tx, schoolID uuid.UUID, ro *Rollover) (map[string]int, error) {
+	assignments, err := tx.Exec(ctx, `
+		INSERT INTO teacher_assignments (teacher_id, class_id, subject_id, is_class_teacher, academic_year, school_id)
+		SELECT DISTINCT ON (ta.teacher_id, m.to_class_id, ta.subject_id)
+		       ta.teacher_id, m.to_class_id, ta.subject_id, ta.is_class_teacher, $3, ta.school_id
+		FROM teacher_assignments ta
+		JOIN academic_rollover_classes m ON m.rollover_id = $4 AND m.from_class_id = ta.class_id
+		JOIN teachers t ON t.id = ta.teacher_id
+		JOIN users u ON u.id = t.user_id AND u.is_active
+		WHERE ta.school_id = $1 AND ta.academic_year = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM teacher_assignments x
+			WHERE x.teacher_id = ta.teacher_id AND x.class_id = m.to_class_id
+			  AND x.subject_id IS NOT DISTINCT FROM ta.subject_id AND x.academic_year = $3
+		  )
+		ORDER BY ta.teacher_id, m.to_class_id, ta.subject_id, ta.is_class_teacher DESC
+	`, schoolID, ro.FromYear, ro.ToYear, ro.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	classTeachers, err := tx.Exec(ctx, `
+		UPDATE classes n
+		SET class_teacher_id = c.class_teacher_id, updated_at = CURRENT_TIMESTAMP
+		FROM academic_rollover_classes m
+		JOIN classes c ON c.id = m.from_class_id
+		JOIN teachers t ON t.id = c.class_teacher_id
+		JOIN users u ON u.id = t.user_id AND u.is_active
+		WHERE m.rollover_id = $2 AND n.id = m.to_class_id
+		  AND n.school_id = $1 AND n.class_teacher_id IS NULL
+	`, schoolID, ro.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int{
+		"teacher_assignments_copied": int(assignments.RowsAffected()),
+		"class_teachers_copied":      int(classTeachers.RowsAffected()),
+	}, nil
+}
+
+// moveStudents applies the planned outcomes. Promoted and detained students
+// join their new-year class; leavers keep their last class and change status.
+// Students whose class changed since the rollover started are skipped.
+func moveStudents(ctx context.Context, tx pgx.Tx, schoolID uuid.UUID, ro *Rollover) (map[string]int, error) {
+	// Enrollment history attributes these moves to the rollover
+	if _, err := tx.Exec(ctx, `SELECT set_config('app.enrollment_reason', 'rollover', true)`); err != nil {
+		return nil, err
+	}
+
+	var promoted, detained, left, graduated, planned int
+	err := tx.QueryRow(ctx, `
+		WITH moved AS (
+			UPDATE students s
+			SET class_id = t.class_id, section = t.section, academic_year = $3, updated_at = CURRENT_TIMESTAMP
+			FROM (
+				SELECT rs.student_id, rs.from_class_id, rs.outcome, c.id AS class_id, c.section
+				FROM academic_rollover_students rs
+				LEFT JOIN academic_rollover_classes m
+				       ON m.rollover_id = rs.rollover_id AND m.from_class_id = rs.target_class_id
+				JOIN classes c ON c.id = COALESCE(m.to_class_id, rs.target_class_id) AND c.academic_year = $3
+				WHERE rs.rollover_id = $2 AND rs.outcome IN ('promoted', 'detained') AND rs.applied_at IS NULL
+			) t
+			WHERE s.id = t.student_id AND s.school_id = $1
+			  AND s.class_id = t.from_class_id AND s.status = 'active'
+			RETURNING s.id, t.outcome
+		), gone AS (
+			UPDATE students s
+			SET status = rs.outcome, updated_at = CURRENT_TIMESTAMP
+			FROM academic_rollover_students rs
+			WHERE rs.rollover_id = $2 AND rs.outcome IN ('left', 'graduated') AND rs.applied_at IS NULL
+			  AND s.id = rs.student_id AND s.school_id = $1
+			  AND s.class_id = rs.from_class_id AND s.status = 'active'
+			RETURNING s.id, rs.outcome
+		), applied AS (
+			UPDATE academic_rollover_students
+			SET applied_at = CURRENT_TIMESTAMP
+			WHERE rollover_id = $2
+			  AND student_id IN (SELECT id FROM moved UNION ALL SELECT id FROM gone)
+		)
+		SELECT
+			(SELECT COUNT(*) FROM moved WHERE outcome = 'promoted'),
+			(SELECT COUNT(*) FROM moved WHERE outcome = 'detained'),
+			(SELECT COUNT(*) FROM gone WHERE outcome = 'left'),
+			(SELECT COUNT(*) FROM gone WHERE outcome = 'graduated'),
+			(SELECT COUNT(*) FROM academic_rollover_students WHERE rollover_id = $2)
+	`, schoolID, ro.ID, ro.ToYear).Scan(&promoted, &detained, &left, &graduated, &planned)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int{
+		"students_promoted":  promoted,
+		"students_detained":  detained,
+		"students_left":      left,
+		"students_graduated": graduated,
+		"students_skipped":   planned - promoted - detained - left - graduated,
+	}, nil
+}
+
+// archiveOldYear archives the old year's timetables and homework
+func archiveOldYear(ctx context.Context, tx pgx.Tx, schoolID uuid.UUID, ro *Rollover) (map[string]int, error) {
+	timetables, err := tx.Exec(ctx, `
+		UPDATE timetables
+		SET archived_at = CURRENT_TIMESTAMP
+		WHERE school_id = $1 AND academic_year = $2 AND archived_at IS NULL
+	`, schoolID, ro.FromYear)
+	if err != nil {
+		return nil, err
+	}
+
+	homework, err := tx.Exec(ctx, `
+		UPDATE homework h
+		SET status = 'archived', updated_at = CURRENT_TIMESTAMP
+		FROM classes c
+		WHERE c.id = h.class_id AND h.school_id = $1
+		  AND c.academic_year = $2 AND h.status <> 'archived'
+	`, schoolID, ro.FromYear)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int{
+		"timetable_entries_archived": int(timetables.RowsAffected()),
+		"homework_archived":          int(homework.RowsAffected()),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package school
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/password"
+)
+
+// Handler handles HTTP requests for school provisioning
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new school handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetSchools returns all schools
+// GET /api/v1/super-admin/schools
+func (h *Handler) GetSchools(c *gin.Context) {
+	schools, err := h.service.GetSchools(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schools": schools})
+}
+
+// GetSchool returns a single school
+// GET /api/v1/super-admin/schools/:id
+func (h *Handler) GetSchool(c *gin.Context) {
+	schoolID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid school ID"})
+		return
+	}
+
+	school, err := h.service.GetSchool(c.Request.Context(), schoolID)
+	if err != nil {
+		if errors.Is(err, ErrSchoolNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "school_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, school)
+}
+
+// CreateSchool provisions a new school with its first admin
+// POST /api/v1/super-admin/schools
+func (h *Handler) CreateSchool(c *gin.Context) {
+	var req CreateSchoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	school, adminID, err := h.service.CreateSchool(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrSchoolCodeExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "school_code_exists"})
+			return
+		}
+		if errors.Is(err, ErrEmailExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "email_exists"})
+			return
+		}
+		if policyErr, ok := password.AsPolicyError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Code, "message": policyErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "School created successfully",
+		"school":        school,
+		"admin_user_id": adminID,
+	})
+}
+
+// UpdateSchool updates a school
+// PUT /api/v1/super-admin/schools/:id
+func (h *Handler) UpdateSchool(c *gin.Context) {
+	schoolID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid school ID"})
+		return
+	}
+
+	var req UpdateSchoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateSchool(c.Request.Context(), schoolID, &req); err != nil {
+		if errors.Is(err, ErrSchoolNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "school_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "School updated successfully"})
+}
@@ -0,0 +1,50 @@
+package school
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// School represents a tenant of the platform
+type School struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Code      string    `json:"code" db:"code"`
+	Address   *string   `json:"address,omitempty" db:"address"`
+	Phone     *string   `json:"phone,omitempty" db:"phone"`
+	Email     *string   `json:"email,omitempty" db:"email"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Aggregates
+	TotalUsers int `json:"total_users"`
+}
+
+// CreateSchoolRequest provisions a school together with its first admin
+type CreateSchoolRequest struct {
+	Name    string           `json:"name" binding:"required"`
+	Code    string           `json:"code" binding:"required,alphanum,max=50"`
+	Address string           `json:"address,omitempty"`
+	Phone   string           `json:"phone,omitempty"`
+	Email   string           `json:"email,omitempty" binding:"omitempty,email"`
+	Admin   SchoolAdminInput `json:"admin" binding:"required"`
+}
+
+// SchoolAdminInput describes the first admin of a new school
+type SchoolAdminInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	FullName string `json:"full_name" binding:"required"`
+	Phone    string `json:"phone,omitempty"`
+}
+
+// UpdateSchoolRequest for super admins updating a school
+type UpdateSchoolRequest struct {
+	Name     string `json:"name,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
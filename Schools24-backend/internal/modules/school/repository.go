@@ -0,0 +1,140 @@
+package school
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+)
+
+// Repository handles database operations for schools
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new school repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetAllSchools retrieves all schools with their user counts
+func (r *Repository) GetAllSchools(ctx context.Context) ([]School, error) {
+	query := `
+		SELECT s.id, s.name, s.code, s.address, s.phone, s.email, s.is_active, s.created_at, s.updated_at,
+		       (SELECT COUNT(*) FROM users u WHERE u.school_id = s.id) as total_users
+		FROM schools s
+		ORDER BY s.name
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schools []School
+	for rows.Next() {
+		var s School
+		err := rows.Scan(&s.ID, &s.Name, &s.Code, &s.Address, &s.Phone, &s.Email, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.TotalUsers)
+		if err != nil {
+			return nil, err
+		}
+		schools = append(schools, s)
+	}
+
+	return schools, nil
+}
+
+// GetSchoolByID retrieves a school by ID
+func (r *Repository) GetSchoolByID(ctx context.Context, schoolID uuid.UUID) (*School, error) {
+	query := `
+		SELECT s.id, s.name, s.code, s.address, s.phone, s.email, s.is_active, s.created_at, s.updated_at,
+		       (SELECT COUNT(*) FROM users u WHERE u.school_id = s.id) as total_users
+		FROM schools s
+		WHERE s.id = $1
+	`
+	var s School
+	err := r.db.QueryRow(ctx, query, schoolID).Scan(&s.ID, &s.Name, &s.Code, &s.Address, &s.Phone, &s.Email, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.TotalUsers)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CodeExists checks if a school code is taken
+func (r *Repository) CodeExists(ctx context.Context, code string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM schools WHERE code = $1)`, code).Scan(&exists)
+	return exists, err
+}
+
+// EmailExists checks if a user email is taken
+func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, email).Scan(&exists)
+	return exists, err
+}
+
+// CreateSchoolWithAdmin creates a school and its first admin in one transaction
+func (r *Repository) CreateSchoolWithAdmin(ctx context.Context, req *CreateSchoolRequest, passwordHash string) (uuid.UUID, uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	schoolQuery := `
+		INSERT INTO schools (name, code, address, phone, email, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), true)
+		RETURNING id
+	`
+	var schoolID uuid.UUID
+	if err := tx.QueryRow(ctx, schoolQuery, req.Name, req.Code, req.Address, req.Phone, req.Email).Scan(&schoolID); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	adminQuery := `
+		INSERT INTO users (email, password_hash, full_name, role, phone, is_active, school_id)
+		VALUES ($1, $2, $3, 'admin', NULLIF($4, ''), true, $5)
+		RETURNING id
+	`
+	var adminID uuid.UUID
+	if err := tx.QueryRow(ctx, adminQuery, req.Admin.Email, passwordHash, req.Admin.FullName, req.Admin.Phone, schoolID).Scan(&adminID); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return schoolID, adminID, nil
+}
+
+// UpdateSchool updates a school
+func (r *Repository) UpdateSchool(ctx context.Context, schoolID uuid.UUID, req *UpdateSchoolRequest) error {
+	query := `
+		UPDATE schools SET
+			name = COALESCE(NULLIF($2, ''), name),
+			address = COALESCE(NULLIF($3, ''), address),
+			phone = COALESCE(NULLIF($4, ''), phone),
+			email = COALESCE(NULLIF($5, ''), email),
+			is_active = COALESCE($6, is_active),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	return r.db.Exec(ctx, query, schoolID, req.Name, req.Address, req.Phone, req.Email, req.IsActive)
+}
+
+// PromoteSuperAdmin turns an existing user into a super admin detached from any school
+func (r *Repository) PromoteSuperAdmin(ctx context.Context, email string) (bool, error) {
+	query := `UPDATE users SET role = 'super_admin', school_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE email = $1 AND role <> 'super_admin'`
+	tag, err := r.db.Pool.Exec(ctx, query, email)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,109 @@
+package school
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/password"
+)
+
+// Service handles school provisioning for super admins
+type Service struct {
+	repo      *Repository
+	passwords *password.Manager
+	config    *config.Config
+}
+
+// Common errors
+var (
+	ErrSchoolNotFound   = errors.New("school not found")
+	ErrSchoolCodeExists = errors.New("school code already exists")
+	ErrEmailExists      = errors.New("email already exists")
+)
+
+// NewService creates a new school service
+func NewService(repo *Repository, passwords *password.Manager, cfg *config.Config) *Service {
+	return &Service{
+		repo:      repo,
+		passwords: passwords,
+		config:    cfg,
+	}
+}
+
+// GetSchools returns all schools
+func (s *Service) GetSchools(ctx context.Context) ([]School, error) {
+	return s.repo.GetAllSchools(ctx)
+}
+
+// GetSchool returns a single school
+func (s *Service) GetSchool(ctx context.Context, schoolID uuid.UUID) (*School, error) {
+	school, err := s.repo.GetSchoolByID(ctx, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	if school == nil {
+		return nil, ErrSchoolNotFound
+	}
+	return school, nil
+}
+
+// CreateSchool provisions a new school and its first admin
+func (s *Service) CreateSchool(ctx context.Context, req *CreateSchoolRequest) (*School, uuid.UUID, error) {
+	if err := s.passwords.Validate(req.Admin.Password); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	exists, err := s.repo.CodeExists(ctx, req.Code)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if exists {
+		return nil, uuid.Nil, ErrSchoolCodeExists
+	}
+
+	exists, err = s.repo.EmailExists(ctx, req.Admin.Email)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if exists {
+		return nil, uuid.Nil, ErrEmailExists
+	}
+
+	hashedPassword, err := s.passwords.Hash(req.Admin.Password)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	schoolID, adminID, err := s.repo.CreateSchoolWithAdmin(ctx, req, hashedPassword)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if err := s.passwords.Remember(ctx, adminID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for user %s: %v", adminID, err)
+	}
+
+	school, err := s.repo.GetSchoolByID(ctx, schoolID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return school, adminID, nil
+}
+
+// UpdateSchool updates a school; deactivated schools can no longer sign in
+func (s *Service) UpdateSchool(ctx context.Context, schoolID uuid.UUID, req *UpdateSchoolRequest) error {
+	existing, err := s.repo.GetSchoolByID(ctx, schoolID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrSchoolNotFound
+	}
+	return s.repo.UpdateSchool(ctx, schoolID, req)
+}
+
+// PromoteSuperAdmin grants super admin to the configured bootstrap account
+func (s *Service) PromoteSuperAdmin(ctx context.Context, email string) (bool, error) {
+	return s.repo.PromoteSuperAdmin(ctx, email)
+}
@@ -0,0 +1,116 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/middleware"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// Handler handles HTTP requests for settings
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new settings handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetPublicSettings returns the settings every user of the school can read
+// GET /api/v1/settings
+func (h *Handler) GetPublicSettings(c *gin.Context) {
+	settings, err := h.service.GetSettings(c.Request.Context(), "", true)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// GetSettings returns every setting with its schema, default and the school's value
+// GET /api/v1/admin/settings
+func (h *Handler) GetSettings(c *gin.Context) {
+	settings, err := h.service.GetSettings(c.Request.Context(), "", false)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": Categories(), "settings": settings})
+}
+
+// GetCategorySettings returns the settings of one category
+// GET /api/v1/admin/settings/:category
+func (h *Handler) GetCategorySettings(c *gin.Context) {
+	settings, err := h.service.GetSettings(c.Request.Context(), c.Param("category"), false)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateCategorySettings sets several settings of a category; all are validated first
+// PUT /api/v1/admin/settings/:category
+func (h *Handler) UpdateCategorySettings(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request.Context(), actorID, c.Param("category"), req.Values, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// ResetSetting restores a setting to its default
+// DELETE /api/v1/admin/settings/:category/:key
+func (h *Handler) ResetSetting(c *gin.Context) {
+	actorID, _ := uuid.Parse(middleware.GetUserID(c))
+
+	setting, err := h.service.ResetSetting(c.Request.Context(), actorID, c.Param("category"), c.Param("key"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"setting": setting})
+}
+
+// GetSettingHistory returns who changed a setting, when, and from what to what
+// GET /api/v1/admin/settings/:category/:key/history?limit=
+func (h *Handler) GetSettingHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	history, err := h.service.GetSettingHistory(c.Request.Context(), c.Param("category"), c.Param("key"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+func respondError(c *gin.Context, err error) {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_setting", "key": validationErr.Key, "message": validationErr.Error()})
+	case errors.Is(err, ErrUnknownCategory):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown_category"})
+	case errors.Is(err, ErrUnknownSetting):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown_setting", "message": err.Error()})
+	case errors.Is(err, tenant.ErrNoSchool):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_required", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,51 @@
+package settings
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Setting is a setting's definition together with the school's value
+type Setting struct {
+	Key         string          `json:"key"`
+	Category    string          `json:"category"`
+	Description string          `json:"description"`
+	Value       json.RawMessage `json:"value"`
+	Default     json.RawMessage `json:"default"`
+	Schema      *Schema         `json:"schema"`
+	IsPublic    bool            `json:"is_public"`
+	IsDefault   bool            `json:"is_default"` // the school has not set its own value
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty"`
+	UpdatedBy   *uuid.UUID      `json:"updated_by,omitempty"`
+}
+
+// storedSetting is a school's value of a setting as stored
+type storedSetting struct {
+	Key       string
+	Value     string
+	UpdatedAt *time.Time
+	UpdatedBy *uuid.UUID
+}
+
+// SettingChange is one entry of a setting's history
+type SettingChange struct {
+	Action    string      `json:"action"` // setting.updated, setting.reset
+	OldValue  interface{} `json:"old_value"`
+	NewValue  interface{} `json:"new_value"`
+	UserID    *uuid.UUID  `json:"user_id,omitempty"`
+	UserName  string      `json:"user_name"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// UpdateSettingsRequest sets several settings of a category at once
+type UpdateSettingsRequest struct {
+	Values map[string]json.RawMessage `json:"values" binding:"required,min=1"`
+}
+
+// GradeBand awards a grade from a minimum percentage
+type GradeBand struct {
+	Grade         string  `json:"grade"`
+	MinPercentage float64 `json:"min_percentage"`
+}
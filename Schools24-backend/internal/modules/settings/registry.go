@@ -0,0 +1,135 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Setting keys read by the application
+const (
+	AcademicYearStartMonth = "academic.year_start_month"
+	AcademicWeekDays       = "academic.week_days"
+	GradingScale           = "grading.scale"
+)
+
+// Definition describes a setting: its category, the schema its value must
+// satisfy and the value used until a school sets its own
+type Definition struct {
+	Key         string
+	Category    string
+	Description string
+	Schema      *Schema
+	Default     json.RawMessage
+	// Public settings can be read by every signed-in user of the school
+	Public bool
+	// check validates what the schema cannot express
+	check func(value json.RawMessage) error
+}
+
+// definitions lists every setting known to the application
+var definitions = []Definition{
+	{
+		Key:         AcademicYearStartMonth,
+		Category:    "academic",
+		Description: "Month (1-12) in which the academic year starts",
+		Schema:      mustParseSchema(`{"type": "integer", "minimum": 1, "maximum": 12}`),
+		Default:     json.RawMessage(`4`),
+		Public:      true,
+	},
+	{
+		Key:         AcademicWeekDays,
+		Category:    "academic",
+		Description: "Days of the week with classes (0=Sunday to 6=Saturday)",
+		Schema: mustParseSchema(`{"type": "array", "minItems": 1, "maxItems": 7, "uniqueItems": true,
+			"items": {"type": "integer", "minimum": 0, "maximum": 6}}`),
+		Default: json.RawMessage(`[1, 2, 3, 4, 5, 6]`),
+		Public:  true,
+	},
+	{
+		Key:         GradingScale,
+		Category:    "grading",
+		Description: "Grades awarded from a minimum percentage of the maximum marks",
+		Schema: mustParseSchema(`{"type": "array", "minItems": 1, "maxItems": 20,
+			"items": {"type": "object", "required": ["grade", "min_percentage"], "additionalProperties": false,
+				"properties": {
+					"grade": {"type": "string", "minLength": 1, "maxLength": 5},
+					"min_percentage": {"type": "number", "minimum": 0, "maximum": 100}
+				}}}`),
+		Default: json.RawMessage(`[
+			{"grade": "A1", "min_percentage": 91}, {"grade": "A2", "min_percentage": 81},
+			{"grade": "B1", "min_percentage": 71}, {"grade": "B2", "min_percentage": 61},
+			{"grade": "C1", "min_percentage": 51}, {"grade": "C2", "min_percentage": 41},
+			{"grade": "D", "min_percentage": 33}, {"grade": "E", "min_percentage": 0}
+		]`),
+		Public: true,
+		check:  checkGradingScale,
+	},
+}
+
+// Lookup returns the definition of a setting key
+func Lookup(key string) (Definition, bool) {
+	for _, d := range definitions {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Categories returns the setting categories in alphabetical order
+func Categories() []string {
+	seen := map[string]bool{}
+	var categories []string
+	for _, d := range definitions {
+		if !seen[d.Category] {
+			seen[d.Category] = true
+			categories = append(categories, d.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// validateValue checks a value against its definition
+func (d *Definition) validateValue(value json.RawMessage) error {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return &ValidationError{Key: d.Key, Message: "is not valid JSON"}
+	}
+	if err := d.Schema.validate(decoded, ""); err != nil {
+		err.Key = d.Key
+		return err
+	}
+	if d.check != nil {
+		if err := d.check(value); err != nil {
+			return &ValidationError{Key: d.Key, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// checkGradingScale requires distinct grades and thresholds and a grade for 0%
+func checkGradingScale(value json.RawMessage) error {
+	var bands []GradeBand
+	if err := json.Unmarshal(value, &bands); err != nil {
+		return err
+	}
+	grades := map[string]bool{}
+	thresholds := map[float64]bool{}
+	hasZero := false
+	for _, b := range bands {
+		if grades[b.Grade] {
+			return fmt.Errorf("grade %q is listed twice", b.Grade)
+		}
+		if thresholds[b.MinPercentage] {
+			return fmt.Errorf("two grades start at %v%%", b.MinPercentage)
+		}
+		grades[b.Grade], thresholds[b.MinPercentage] = true, true
+		hasZero = hasZero || b.MinPercentage == 0
+	}
+	if !hasZero {
+		return fmt.Errorf("a grade must start at 0%%")
+	}
+	return nil
+}
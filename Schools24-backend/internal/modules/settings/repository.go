@@ -0,0 +1,116 @@
+package settings
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+)
+
+// Repository handles database operations for settings
+type Repository struct {
+	db *database.PostgresDB
+}
+
+// NewRepository creates a new settings repository
+func NewRepository(db *database.PostgresDB) *Repository {
+	return &Repository{db: db}
+}
+
+// settingWrite is a value to store for a setting
+type settingWrite struct {
+	def   *Definition
+	value string
+}
+
+// GetSchoolSettings retrieves the values a school has set
+func (r *Repository) GetSchoolSettings(ctx context.Context, schoolID uuid.UUID) ([]storedSetting, error) {
+	query := `
+		SELECT key, value, updated_at, updated_by
+		FROM settings
+		WHERE school_id = $1
+	`
+	rows, err := r.db.Query(ctx, query, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stored []storedSetting
+	for rows.Next() {
+		var s storedSetting
+		if err := rows.Scan(&s.Key, &s.Value, &s.UpdatedAt, &s.UpdatedBy); err != nil {
+			return nil, err
+		}
+		stored = append(stored, s)
+	}
+	return stored, rows.Err()
+}
+
+// SaveSettings stores several values of a school in one transaction
+func (r *Repository) SaveSettings(ctx context.Context, schoolID, actorID uuid.UUID, writes []settingWrite) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO settings (key, value, description, category, is_public, school_id, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (school_id, key) DO UPDATE
+		SET value = EXCLUDED.value, description = EXCLUDED.description, category = EXCLUDED.category,
+		    is_public = EXCLUDED.is_public, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+	`
+	for _, w := range writes {
+		if _, err := tx.Exec(ctx, query, w.def.Key, w.value, w.def.Description, w.def.Category, w.def.Public, schoolID, actorID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteSetting removes a school's value of a setting and returns it, or nil
+// if the school had not set one
+func (r *Repository) DeleteSetting(ctx context.Context, schoolID uuid.UUID, key string) (*string, error) {
+	var value string
+	err := r.db.QueryRow(ctx, `DELETE FROM settings WHERE school_id = $1 AND key = $2 RETURNING value`, schoolID, key).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// GetSettingHistory retrieves the audit entries of a school's setting, newest first
+func (r *Repository) GetSettingHistory(ctx context.Context, schoolID uuid.UUID, key string, limit int) ([]SettingChange, error) {
+	query := `
+		SELECT a.action, a.old_values -> 'value', a.new_values -> 'value', a.user_id,
+		       COALESCE(u.full_name, 'System'), a.created_at
+		FROM audit_logs a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.school_id = $1 AND a.entity_type = 'setting'
+		  AND COALESCE(a.new_values ->> 'key', a.old_values ->> 'key') = $2
+		ORDER BY a.created_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, schoolID, key, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []SettingChange{}
+	for rows.Next() {
+		var c SettingChange
+		if err := rows.Scan(&c.Action, &c.OldValue, &c.NewValue, &c.UserID, &c.UserName, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,190 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema used to describe setting values
+type Schema struct {
+	Type                 string             `json:"type,omitempty"` // string, integer, number, boolean, array, object
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// mustParseSchema parses a schema literal of the registry; a bad literal is a programming error
+func mustParseSchema(src string) *Schema {
+	var s Schema
+	if err := json.Unmarshal([]byte(src), &s); err != nil {
+		panic(fmt.Sprintf("settings: invalid schema %s: %v", src, err))
+	}
+	s.compile()
+	return &s
+}
+
+func (s *Schema) compile() {
+	if s.Pattern != "" {
+		s.pattern = regexp.MustCompile(s.Pattern)
+	}
+	if s.Items != nil {
+		s.Items.compile()
+	}
+	for _, p := range s.Properties {
+		p.compile()
+	}
+}
+
+// validate checks a decoded JSON value against the schema and returns the
+// first violation, located by a JSON pointer-like path
+func (s *Schema) validate(v interface{}, path string) *ValidationError {
+	fail := func(format string, args ...interface{}) *ValidationError {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(normalizeNumber(e), normalizeNumber(v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %v", s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		n := utf8.RuneCountInString(str)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			return fail("must match %s", s.Pattern)
+		}
+
+	case "integer", "number":
+		num, ok := v.(float64)
+		if !ok {
+			return fail("must be a %s", s.Type)
+		}
+		if s.Type == "integer" && num != math.Trunc(num) {
+			return fail("must be an integer")
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			return fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			return fail("must be at most %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("must be true or false")
+		}
+
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			return fail("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range items {
+			if s.UniqueItems {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(items[j], item) {
+						return fail("items %d and %d are the same", j, i)
+					}
+				}
+			}
+			if s.Items != nil {
+				if err := s.Items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fail("%s is required", name)
+			}
+		}
+		for name, value := range obj {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fail("%s is not allowed", name)
+				}
+				continue
+			}
+			if err := prop.validate(value, path+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeNumber makes integers from enum literals comparable with decoded JSON numbers
+func normalizeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
+
+// ValidationError describes why a value was rejected
+type ValidationError struct {
+	Key     string `json:"key"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	where := e.Key
+	if e.Path != "" {
+		where += strings.ReplaceAll(e.Path, "/", ".")
+	}
+	return where + " " + e.Message
+}
@@ -0,0 +1,370 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/cache"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// AuditLogger records setting changes (implemented by admin.Repository)
+type AuditLogger interface {
+	LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error
+}
+
+// Service handles settings business logic. A school's values are cached
+// together and dropped from the cache whenever one of them is written.
+type Service struct {
+	repo   *Repository
+	cache  *cache.Cache
+	audit  AuditLogger
+	config *config.Config
+}
+
+// Common errors
+var (
+	ErrUnknownCategory = errors.New("unknown settings category")
+	ErrUnknownSetting  = errors.New("unknown setting")
+)
+
+// maxHistory caps the entries of a setting's history
+const maxHistory = 100
+
+// NewService creates a new settings service
+func NewService(repo *Repository, appCache *cache.Cache, audit AuditLogger, cfg *config.Config) *Service {
+	return &Service{
+		repo:   repo,
+		cache:  appCache,
+		audit:  audit,
+		config: cfg,
+	}
+}
+
+// cacheKey is the cache entry holding a school's values
+func cacheKey(schoolID uuid.UUID) string {
+	return "settings:" + schoolID.String()
+}
+
+// values returns the values the request's school has set, by key. Requests
+// without a school (super admins) see the defaults.
+func (s *Service) values(ctx context.Context) (map[string]json.RawMessage, error) {
+	schoolID := tenant.SchoolID(ctx)
+	if schoolID == nil {
+		return map[string]json.RawMessage{}, nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := s.cache.FetchAndDecompress(ctx, cacheKey(*schoolID), &values); err == nil {
+		return values, nil
+	}
+
+	values, err := s.storedValues(ctx, *schoolID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.CompressAndStore(ctx, cacheKey(*schoolID), values, 0); err != nil {
+		log.Printf("Failed to cache settings of school %s: %v", schoolID, err)
+	}
+	return values, nil
+}
+
+// storedValues reads a school's values from the database, skipping unknown
+// keys and values that are not JSON
+func (s *Service) storedValues(ctx context.Context, schoolID uuid.UUID) (map[string]json.RawMessage, error) {
+	stored, err := s.repo.GetSchoolSettings(ctx, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]json.RawMessage, len(stored))
+	for _, st := range stored {
+		if _, ok := Lookup(st.Key); !ok {
+			continue
+		}
+		if !json.Valid([]byte(st.Value)) {
+			log.Printf("Ignoring invalid value of setting %s for school %s", st.Key, schoolID)
+			continue
+		}
+		values[st.Key] = json.RawMessage(st.Value)
+	}
+	return values, nil
+}
+
+// Get decodes a setting's value for the request's school into dest
+func (s *Service) Get(ctx context.Context, key string, dest interface{}) error {
+	def, ok := Lookup(key)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+	values, err := s.values(ctx)
+	if err != nil {
+		return err
+	}
+	value, ok := values[key]
+	if !ok {
+		value = def.Default
+	}
+	return json.Unmarshal(value, dest)
+}
+
+// getOrDefault decodes a setting into dest, falling back to its default so
+// that a settings outage does not take down the features that read them
+func (s *Service) getOrDefault(ctx context.Context, key string, dest interface{}) {
+	if err := s.Get(ctx, key, dest); err != nil {
+		log.Printf("Failed to read setting %s, using default: %v", key, err)
+		def, _ := Lookup(key)
+		json.Unmarshal(def.Default, dest)
+	}
+}
+
+// AcademicYearStartMonth returns the month the school's academic year starts in
+func (s *Service) AcademicYearStartMonth(ctx context.Context) time.Month {
+	var month int
+	s.getOrDefault(ctx, AcademicYearStartMonth, &month)
+	return time.Month(month)
+}
+
+// AcademicYear returns the academic year containing t, such as "2025-2026"
+func (s *Service) AcademicYear(ctx context.Context, t time.Time) string {
+	year := t.Year()
+	if t.Month() < s.AcademicYearStartMonth(ctx) {
+		year--
+	}
+	return fmt.Sprintf("%d-%d", year, year+1)
+}
+
+// CurrentAcademicYear returns the school's current academic year
+func (s *Service) CurrentAcademicYear(ctx context.Context) string {
+	return s.AcademicYear(ctx, time.Now())
+}
+
+// WeekDays returns the school's days with classes in week order (0=Sunday)
+func (s *Service) WeekDays(ctx context.Context) []int {
+	var days []int
+	s.getOrDefault(ctx, AcademicWeekDays, &days)
+	sort.Ints(days)
+	return days
+}
+
+// GradingScale returns the school's grade bands, highest first
+func (s *Service) GradingScale(ctx context.Context) []GradeBand {
+	var bands []GradeBand
+	s.getOrDefault(ctx, GradingScale, &bands)
+	sort.Slice(bands, func(i, j int) bool { return bands[i].MinPercentage > bands[j].MinPercentage })
+	return bands
+}
+
+// GradeFor returns the grade awarded for marks out of maxMarks
+func (s *Service) GradeFor(ctx context.Context, marks, maxMarks float64) string {
+	if maxMarks <= 0 {
+		return ""
+	}
+	percentage := marks / maxMarks * 100
+	for _, band := range s.GradingScale(ctx) {
+		if percentage >= band.MinPercentage {
+			return band.Grade
+		}
+	}
+	return ""
+}
+
+// GetSettings returns the settings of a category, or of every category if it
+// is empty. With publicOnly only settings visible to all users are returned.
+func (s *Service) GetSettings(ctx context.Context, category string, publicOnly bool) ([]Setting, error) {
+	if category != "" && !knownCategory(category) {
+		return nil, ErrUnknownCategory
+	}
+
+	stored := map[string]storedSetting{}
+	if schoolID := tenant.SchoolID(ctx); schoolID != nil {
+		rows, err := s.repo.GetSchoolSettings(ctx, *schoolID)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range rows {
+			stored[st.Key] = st
+		}
+	}
+
+	settings := []Setting{}
+	for _, def := range definitions {
+		if (category != "" && def.Category != category) || (publicOnly && !def.Public) {
+			continue
+		}
+		setting := Setting{
+			Key:         def.Key,
+			Category:    def.Category,
+			Description: def.Description,
+			Value:       def.Default,
+			Default:     def.Default,
+			Schema:      def.Schema,
+			IsPublic:    def.Public,
+			IsDefault:   true,
+		}
+		if st, ok := stored[def.Key]; ok && json.Valid([]byte(st.Value)) {
+			setting.Value = json.RawMessage(st.Value)
+			setting.IsDefault = false
+			setting.UpdatedAt = st.UpdatedAt
+			setting.UpdatedBy = st.UpdatedBy
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// UpdateSettings validates and stores several settings of a category at once.
+// Nothing is stored if any value is rejected. Each changed value is audited.
+func (s *Service) UpdateSettings(ctx context.Context, actorID uuid.UUID, category string, values map[string]json.RawMessage, ipAddress, userAgent string) ([]Setting, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !knownCategory(category) {
+		return nil, ErrUnknownCategory
+	}
+	current, err := s.storedValues(ctx, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var writes []settingWrite
+	var changes []settingAudit
+	for _, key := range keys {
+		def, ok := Lookup(key)
+		if !ok || def.Category != category {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+		}
+		if err := def.validateValue(values[key]); err != nil {
+			return nil, err
+		}
+
+		var compact bytes.Buffer
+		json.Compact(&compact, values[key])
+		old, ok := current[key]
+		if !ok {
+			old = def.Default
+		}
+		if jsonEqual(old, compact.Bytes()) {
+			continue
+		}
+		writes = append(writes, settingWrite{def: &def, value: compact.String()})
+		changes = append(changes, settingAudit{key: key, old: old, new: json.RawMessage(compact.Bytes())})
+	}
+
+	if len(writes) > 0 {
+		if err := s.repo.SaveSettings(ctx, schoolID, actorID, writes); err != nil {
+			return nil, err
+		}
+		s.invalidate(ctx, schoolID)
+		for _, c := range changes {
+			s.auditChange(ctx, actorID, "setting.updated", c, ipAddress, userAgent)
+		}
+	}
+	return s.GetSettings(ctx, category, false)
+}
+
+// ResetSetting drops the school's value of a setting so its default applies again
+func (s *Service) ResetSetting(ctx context.Context, actorID uuid.UUID, category, key, ipAddress, userAgent string) (*Setting, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	def, ok := Lookup(key)
+	if !ok || def.Category != category {
+		return nil, ErrUnknownSetting
+	}
+
+	old, err := s.repo.DeleteSetting(ctx, schoolID, key)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		s.invalidate(ctx, schoolID)
+		if !jsonEqual(json.RawMessage(*old), def.Default) {
+			s.auditChange(ctx, actorID, "setting.reset", settingAudit{key: key, old: json.RawMessage(*old), new: def.Default}, ipAddress, userAgent)
+		}
+	}
+
+	return &Setting{
+		Key:         def.Key,
+		Category:    def.Category,
+		Description: def.Description,
+		Value:       def.Default,
+		Default:     def.Default,
+		Schema:      def.Schema,
+		IsPublic:    def.Public,
+		IsDefault:   true,
+	}, nil
+}
+
+// GetSettingHistory returns the recorded changes of a setting, newest first
+func (s *Service) GetSettingHistory(ctx context.Context, category, key string, limit int) ([]SettingChange, error) {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	def, ok := Lookup(key)
+	if !ok || def.Category != category {
+		return nil, ErrUnknownSetting
+	}
+	if limit <= 0 || limit > maxHistory {
+		limit = maxHistory
+	}
+	return s.repo.GetSettingHistory(ctx, schoolID, key, limit)
+}
+
+// invalidate drops a school's cached values after a write
+func (s *Service) invalidate(ctx context.Context, schoolID uuid.UUID) {
+	if err := s.cache.Delete(ctx, cacheKey(schoolID)); err != nil {
+		log.Printf("Failed to invalidate cached settings of school %s: %v", schoolID, err)
+	}
+}
+
+// settingAudit is a change of a setting's value
+type settingAudit struct {
+	key      string
+	old, new json.RawMessage
+}
+
+// auditChange records a change with the key in both values, which is how a
+// setting's history is found again
+func (s *Service) auditChange(ctx context.Context, actorID uuid.UUID, action string, c settingAudit, ipAddress, userAgent string) {
+	oldValues := map[string]interface{}{"key": c.key, "value": c.old}
+	newValues := map[string]interface{}{"key": c.key, "value": c.new}
+	if err := s.audit.LogAudit(ctx, &actorID, action, "setting", nil, oldValues, newValues, ipAddress, userAgent); err != nil {
+		log.Printf("Failed to audit change of setting %s: %v", c.key, err)
+	}
+}
+
+func knownCategory(category string) bool {
+	for _, c := range Categories() {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares two JSON documents by value
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Repository handles database operations for students
@@ -32,11 +33,11 @@ func (r *Repository) GetStudentByUserID(ctx context.Context, userID uuid.UUID) (
 		FROM students s
 		JOIN users u ON s.user_id = u.id
 		LEFT JOIN classes c ON s.class_id = c.id
-		WHERE s.user_id = $1
+		WHERE s.user_id = $1 AND ($2::uuid IS NULL OR s.school_id = $2)
 	`
 
 	var student Student
-	err := r.db.QueryRow(ctx, query, userID).Scan(
+	err := r.db.QueryRow(ctx, query, userID, tenant.SchoolID(ctx)).Scan(
 		&student.ID, &student.UserID, &student.AdmissionNumber, &student.RollNumber,
 		&student.ClassID, &student.Section, &student.DateOfBirth, &student.Gender,
 		&student.BloodGroup, &student.Address, &student.ParentName, &student.ParentEmail,
@@ -57,12 +58,17 @@ func (r *Repository) GetStudentByUserID(ctx context.Context, userID uuid.UUID) (
 
 // CreateStudent creates a new student profile
 func (r *Repository) CreateStudent(ctx context.Context, student *Student) error {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO students (id, user_id, admission_number, roll_number, class_id, section,
 		                      date_of_birth, gender, blood_group, address, parent_name,
 		                      parent_email, parent_phone, emergency_contact, admission_date,
-		                      academic_year, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		                      academic_year, created_at, updated_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -77,7 +83,7 @@ func (r *Repository) CreateStudent(ctx context.Context, student *Student) error
 		student.ClassID, student.Section, student.DateOfBirth, student.Gender,
 		student.BloodGroup, student.Address, student.ParentName, student.ParentEmail,
 		student.ParentPhone, student.EmergencyContact, student.AdmissionDate,
-		student.AcademicYear, student.CreatedAt, student.UpdatedAt,
+		student.AcademicYear, student.CreatedAt, student.UpdatedAt, schoolID,
 	).Scan(&student.ID, &student.CreatedAt, &student.UpdatedAt)
 }
 
@@ -90,11 +96,11 @@ func (r *Repository) GetClassByID(ctx context.Context, classID uuid.UUID) (*Clas
 		FROM classes c
 		LEFT JOIN teachers t ON c.class_teacher_id = t.id
 		LEFT JOIN users u ON t.user_id = u.id
-		WHERE c.id = $1
+		WHERE c.id = $1 AND ($2::uuid IS NULL OR c.school_id = $2)
 	`
 
 	var class Class
-	err := r.db.QueryRow(ctx, query, classID).Scan(
+	err := r.db.QueryRow(ctx, query, classID, tenant.SchoolID(ctx)).Scan(
 		&class.ID, &class.Name, &class.Grade, &class.Section, &class.ClassTeacherID,
 		&class.AcademicYear, &class.TotalStudents, &class.RoomNumber,
 		&class.CreatedAt, &class.UpdatedAt, &class.ClassTeacherName,
@@ -119,11 +125,11 @@ func (r *Repository) GetAllClasses(ctx context.Context, academicYear string) ([]
 		FROM classes c
 		LEFT JOIN teachers t ON c.class_teacher_id = t.id
 		LEFT JOIN users u ON t.user_id = u.id
-		WHERE c.academic_year = $1
+		WHERE c.academic_year = $1 AND ($2::uuid IS NULL OR c.school_id = $2)
 		ORDER BY c.grade, c.section
 	`
 
-	rows, err := r.db.Query(ctx, query, academicYear)
+	rows, err := r.db.Query(ctx, query, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get classes: %w", err)
 	}
@@ -156,10 +162,11 @@ func (r *Repository) GetAttendanceStats(ctx context.Context, studentID uuid.UUID
 			COUNT(*) FILTER (WHERE status = 'late') as late_days
 		FROM attendance
 		WHERE student_id = $1 AND date BETWEEN $2 AND $3
+		  AND ($4::uuid IS NULL OR school_id = $4)
 	`
 
 	var stats AttendanceStats
-	err := r.db.QueryRow(ctx, query, studentID, startDate, endDate).Scan(
+	err := r.db.QueryRow(ctx, query, studentID, startDate, endDate, tenant.SchoolID(ctx)).Scan(
 		&stats.TotalDays, &stats.PresentDays, &stats.AbsentDays, &stats.LateDays,
 	)
 	if err != nil {
@@ -178,12 +185,12 @@ func (r *Repository) GetRecentAttendance(ctx context.Context, studentID uuid.UUI
 	query := `
 		SELECT id, student_id, class_id, date, status, marked_by, remarks, created_at
 		FROM attendance
-		WHERE student_id = $1
+		WHERE student_id = $1 AND ($3::uuid IS NULL OR school_id = $3)
 		ORDER BY date DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.Query(ctx, query, studentID, limit)
+	rows, err := r.db.Query(ctx, query, studentID, limit, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -204,9 +211,14 @@ func (r *Repository) GetRecentAttendance(ctx context.Context, studentID uuid.UUI
 
 // CreateClass creates a new class
 func (r *Repository) CreateClass(ctx context.Context, class *Class) error {
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO classes (id, name, grade, section, academic_year, total_students, room_number, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO classes (id, name, grade, section, academic_year, total_students, room_number, created_at, updated_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
 
@@ -217,6 +229,6 @@ func (r *Repository) CreateClass(ctx context.Context, class *Class) error {
 
 	return r.db.QueryRow(ctx, query,
 		class.ID, class.Name, class.Grade, class.Section, class.AcademicYear,
-		class.TotalStudents, class.RoomNumber, class.CreatedAt, class.UpdatedAt,
+		class.TotalStudents, class.RoomNumber, class.CreatedAt, class.UpdatedAt, schoolID,
 	).Scan(&class.ID)
 }
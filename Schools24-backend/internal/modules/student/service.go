@@ -7,12 +7,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/settings"
 )
 
 // Service handles student business logic
 type Service struct {
-	repo   *Repository
-	config *config.Config
+	repo     *Repository
+	settings *settings.Service
+	config   *config.Config
 }
 
 // Common errors
@@ -22,10 +24,11 @@ var (
 )
 
 // NewService creates a new student service
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, settingsService *settings.Service, cfg *config.Config) *Service {
 	return &Service{
-		repo:   repo,
-		config: cfg,
+		repo:     repo,
+		settings: settingsService,
+		config:   cfg,
 	}
 }
 
@@ -97,6 +100,11 @@ func (s *Service) GetAttendance(ctx context.Context, userID uuid.UUID, startDate
 		return nil, nil, ErrStudentNotFound
 	}
 
+	return s.GetStudentAttendance(ctx, student.ID, startDate, endDate)
+}
+
+// GetStudentAttendance returns attendance records and stats for a student profile
+func (s *Service) GetStudentAttendance(ctx context.Context, studentID uuid.UUID, startDate, endDate time.Time) ([]Attendance, *AttendanceStats, error) {
 	// If no dates provided, default to current month
 	if startDate.IsZero() {
 		now := time.Now()
@@ -105,12 +113,12 @@ func (s *Service) GetAttendance(ctx context.Context, userID uuid.UUID, startDate
 	}
 
 	// Get attendance records - for now return recent
-	records, err := s.repo.GetRecentAttendance(ctx, student.ID, 30)
+	records, err := s.repo.GetRecentAttendance(ctx, studentID, 30)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	stats, err := s.repo.GetAttendanceStats(ctx, student.ID, startDate, endDate)
+	stats, err := s.repo.GetAttendanceStats(ctx, studentID, startDate, endDate)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -121,7 +129,7 @@ func (s *Service) GetAttendance(ctx context.Context, userID uuid.UUID, startDate
 // GetClasses returns all available classes
 func (s *Service) GetClasses(ctx context.Context, academicYear string) ([]Class, error) {
 	if academicYear == "" {
-		academicYear = getCurrentAcademicYear()
+		academicYear = s.settings.CurrentAcademicYear(ctx)
 	}
 	return s.repo.GetAllClasses(ctx, academicYear)
 }
@@ -130,16 +138,3 @@ func (s *Service) GetClasses(ctx context.Context, academicYear string) ([]Class,
 func (s *Service) CreateClass(ctx context.Context, class *Class) error {
 	return s.repo.CreateClass(ctx, class)
 }
-
-// getCurrentAcademicYear returns current academic year (e.g., "2025-2026")
-func getCurrentAcademicYear() string {
-	now := time.Now()
-	year := now.Year()
-	month := now.Month()
-
-	// Academic year starts in April (for Indian schools)
-	if month < time.April {
-		return time.Date(year-1, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006") + "-" + time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
-	}
-	return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006") + "-" + time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006")
-}
@@ -96,8 +96,8 @@ func (h *Handler) GetClasses(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"classes": classes})
 }
 
-// GetClassStudents returns students in a class
-// GET /api/v1/teacher/classes/:classId/students
+// GetClassStudents returns the students enrolled in a class on a date, today by default
+// GET /api/v1/teacher/classes/:classId/students?date=2025-06-02
 func (h *Handler) GetClassStudents(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
@@ -118,7 +118,15 @@ func (h *Handler) GetClassStudents(c *gin.Context) {
 		return
 	}
 
-	students, err := h.service.GetStudentsByClass(c.Request.Context(), userID, classID)
+	var date time.Time
+	if dateStr := c.Query("date"); dateStr != "" {
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	students, err := h.service.GetStudentsByClass(c.Request.Context(), userID, classID, date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
 )
 
 // Repository handles database operations for teacher module
@@ -31,10 +32,11 @@ func (r *Repository) GetTeacherByUserID(ctx context.Context, userID uuid.UUID) (
 		FROM teachers t
 		JOIN users u ON t.user_id = u.id
 		WHERE t.user_id = $1 AND t.is_active = true
+		  AND ($2::uuid IS NULL OR t.school_id = $2)
 	`
 
 	var t Teacher
-	err := r.db.QueryRow(ctx, query, userID).Scan(
+	err := r.db.QueryRow(ctx, query, userID, tenant.SchoolID(ctx)).Scan(
 		&t.ID, &t.UserID, &t.EmployeeID, &t.Department, &t.Designation,
 		&t.Qualifications, &t.JoiningDate, &t.SubjectsTaught, &t.Experience,
 		&t.IsActive, &t.CreatedAt, &t.UpdatedAt,
@@ -59,10 +61,11 @@ func (r *Repository) GetTeacherAssignments(ctx context.Context, teacherID uuid.U
 		JOIN classes c ON ta.class_id = c.id
 		LEFT JOIN subjects s ON ta.subject_id = s.id
 		WHERE ta.teacher_id = $1 AND ta.academic_year = $2
+		  AND ($3::uuid IS NULL OR ta.school_id = $3)
 		ORDER BY c.grade, c.section
 	`
 
-	rows, err := r.db.Query(ctx, query, teacherID, academicYear)
+	rows, err := r.db.Query(ctx, query, teacherID, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -94,10 +97,11 @@ func (r *Repository) GetTodaySchedule(ctx context.Context, teacherID uuid.UUID,
 		JOIN classes c ON t.class_id = c.id
 		LEFT JOIN subjects s ON t.subject_id = s.id
 		WHERE t.teacher_id = $1 AND t.day_of_week = $2 AND t.academic_year = $3
+		  AND ($4::uuid IS NULL OR t.school_id = $4)
 		ORDER BY t.period_number
 	`
 
-	rows, err := r.db.Query(ctx, query, teacherID, dayOfWeek, academicYear)
+	rows, err := r.db.Query(ctx, query, teacherID, dayOfWeek, academicYear, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -122,10 +126,11 @@ func (r *Repository) GetStudentCountByClasses(ctx context.Context, teacherID uui
 		FROM students s
 		JOIN teacher_assignments ta ON s.class_id = ta.class_id
 		WHERE ta.teacher_id = $1 AND ta.academic_year = $2 AND s.is_active = true
+		  AND ($3::uuid IS NULL OR s.school_id = $3)
 	`
 
 	var count int
-	err := r.db.QueryRow(ctx, query, teacherID, academicYear).Scan(&count)
+	err := r.db.QueryRow(ctx, query, teacherID, academicYear, tenant.SchoolID(ctx)).Scan(&count)
 	return count, err
 }
 
@@ -136,10 +141,11 @@ func (r *Repository) GetPendingHomeworkCount(ctx context.Context, teacherID uuid
 		FROM homework_submissions hs
 		JOIN homework h ON hs.homework_id = h.id
 		WHERE h.teacher_id = $1 AND hs.status = 'submitted'
+		  AND ($2::uuid IS NULL OR h.school_id = $2)
 	`
 
 	var count int
-	err := r.db.QueryRow(ctx, query, teacherID).Scan(&count)
+	err := r.db.QueryRow(ctx, query, teacherID, tenant.SchoolID(ctx)).Scan(&count)
 	return count, err
 }
 
@@ -151,23 +157,38 @@ func (r *Repository) MarkAttendance(ctx context.Context, teacherID uuid.UUID, cl
 	}
 	defer tx.Rollback(ctx)
 
+	// Attendance belongs to the class's school
+	var schoolID uuid.UUID
+	schoolQuery := `SELECT school_id FROM classes WHERE id = $1 AND ($2::uuid IS NULL OR school_id = $2)`
+	if err := tx.QueryRow(ctx, schoolQuery, classID, tenant.SchoolID(ctx)).Scan(&schoolID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidClass
+		}
+		return err
+	}
+
 	// 1. Create/Update Attendance Session (Photo proof)
 	if photoURL != "" {
 		sessionQuery := `
-			INSERT INTO attendance_sessions (class_id, teacher_id, date, photo_url, created_at)
-			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+			INSERT INTO attendance_sessions (class_id, teacher_id, date, photo_url, created_at, school_id)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, $5)
 			ON CONFLICT (class_id, date) 
 			DO UPDATE SET photo_url = EXCLUDED.photo_url, teacher_id = EXCLUDED.teacher_id
 		`
-		if _, err := tx.Exec(ctx, sessionQuery, classID, teacherID, date, photoURL); err != nil {
+		if _, err := tx.Exec(ctx, sessionQuery, classID, teacherID, date, photoURL, schoolID); err != nil {
 			return err
 		}
 	}
 
-	// 2. Insert/Update individual Student Records
+	// 2. Insert/Update individual Student Records (students of other schools,
+	// deactivated, or not enrolled in the class on the date, are skipped)
 	query := `
-		INSERT INTO attendance (student_id, class_id, date, status, remarks, marked_by)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO attendance (student_id, class_id, date, status, remarks, marked_by, school_id)
+		SELECT s.id, $2::uuid, $3::date, $4::varchar, $5::text, $6::uuid, s.school_id
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.school_id = $7 AND u.is_active = true
+		  AND student_class_on(s.id, $3::date) = $2::uuid
 		ON CONFLICT (student_id, date) 
 		DO UPDATE SET status = EXCLUDED.status, remarks = EXCLUDED.remarks, marked_by = EXCLUDED.marked_by
 	`
@@ -176,7 +197,7 @@ func (r *Repository) MarkAttendance(ctx context.Context, teacherID uuid.UUID, cl
 		if err != nil {
 			continue // Skip invalid uuid
 		}
-		if _, err := tx.Exec(ctx, query, studentID, classID, date, record.Status, record.Remarks, teacherID); err != nil {
+		if _, err := tx.Exec(ctx, query, studentID, classID, date, record.Status, record.Remarks, teacherID, schoolID); err != nil {
 			return err
 		}
 	}
@@ -199,8 +220,9 @@ func (r *Repository) CreateHomework(ctx context.Context, teacherID uuid.UUID, hw
 	}
 
 	query := `
-		INSERT INTO homework (title, description, class_id, subject_id, teacher_id, due_date, max_marks, attachments, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'active')
+		INSERT INTO homework (title, description, class_id, subject_id, teacher_id, due_date, max_marks, attachments, status, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'active',
+		        (SELECT school_id FROM classes WHERE id = $3 AND ($9::uuid IS NULL OR school_id = $9)))
 		RETURNING id
 	`
 
@@ -212,14 +234,14 @@ func (r *Repository) CreateHomework(ctx context.Context, teacherID uuid.UUID, hw
 	var id uuid.UUID
 	err = r.db.QueryRow(ctx, query,
 		hw.Title, hw.Description, classID, subjectID, teacherID,
-		dueDate, maxMarks, hw.Attachments,
+		dueDate, maxMarks, hw.Attachments, tenant.SchoolID(ctx),
 	).Scan(&id)
 
 	return id, err
 }
 
 // EnterGrade enters a grade for a student
-func (r *Repository) EnterGrade(ctx context.Context, teacherID uuid.UUID, req *EnterGradeRequest) error {
+func (r *Repository) EnterGrade(ctx context.Context, teacherID uuid.UUID, req *EnterGradeRequest, academicYear, grade string) error {
 	studentID, _ := uuid.Parse(req.StudentID)
 	var subjectID *uuid.UUID
 	if req.SubjectID != "" {
@@ -233,30 +255,34 @@ func (r *Repository) EnterGrade(ctx context.Context, teacherID uuid.UUID, req *E
 		examDate = &t
 	}
 
-	academicYear := getCurrentAcademicYear()
-
 	query := `
-		INSERT INTO grades (student_id, subject_id, exam_type, exam_name, max_marks, marks_obtained, remarks, graded_by, exam_date, academic_year)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO grades (student_id, subject_id, exam_type, exam_name, max_marks, marks_obtained, grade, remarks, graded_by, exam_date, academic_year, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11,
+		        (SELECT school_id FROM students WHERE id = $1 AND ($12::uuid IS NULL OR school_id = $12)))
 	`
 
 	return r.db.Exec(ctx, query,
 		studentID, subjectID, req.ExamType, req.ExamName,
-		req.MaxMarks, req.MarksObtained, req.Remarks, teacherID, examDate, academicYear,
+		req.MaxMarks, req.MarksObtained, grade, req.Remarks, teacherID, examDate, academicYear,
+		tenant.SchoolID(ctx),
 	)
 }
 
-// GetStudentsByClass retrieves students in a class
-func (r *Repository) GetStudentsByClass(ctx context.Context, classID uuid.UUID) ([]StudentInfo, error) {
+// GetStudentsByClass retrieves the active students enrolled in a class on a date.
+// Deactivated students keep their enrollment but leave the register.
+func (r *Repository) GetStudentsByClass(ctx context.Context, classID uuid.UUID, date time.Time) ([]StudentInfo, error) {
 	query := `
 		SELECT s.id, s.user_id, s.roll_number, u.full_name, u.email
-		FROM students s
+		FROM student_enrollments e
+		JOIN students s ON s.id = e.student_id
 		JOIN users u ON s.user_id = u.id
-		WHERE s.class_id = $1 AND s.is_active = true
+		WHERE e.class_id = $1 AND e.start_date <= $2 AND (e.end_date IS NULL OR e.end_date > $2)
+		  AND u.is_active = true
+		  AND ($3::uuid IS NULL OR s.school_id = $3)
 		ORDER BY s.roll_number
 	`
 
-	rows, err := r.db.Query(ctx, query, classID)
+	rows, err := r.db.Query(ctx, query, classID, date, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -293,15 +319,20 @@ func (r *Repository) CreateAnnouncement(ctx context.Context, authorID uuid.UUID,
 		expiresAt = &t
 	}
 
+	schoolID, err := tenant.RequireSchoolID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	query := `
-		INSERT INTO announcements (title, content, author_id, target_type, target_id, priority, is_pinned, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO announcements (title, content, author_id, target_type, target_id, priority, is_pinned, expires_at, school_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
 	var id uuid.UUID
-	err := r.db.QueryRow(ctx, query,
-		req.Title, req.Content, authorID, req.TargetType, targetID, priority, req.IsPinned, expiresAt,
+	err = r.db.QueryRow(ctx, query,
+		req.Title, req.Content, authorID, req.TargetType, targetID, priority, req.IsPinned, expiresAt, schoolID,
 	).Scan(&id)
 
 	return id, err
@@ -316,11 +347,12 @@ func (r *Repository) GetRecentAnnouncements(ctx context.Context, limit int) ([]A
 		FROM announcements a
 		JOIN users u ON a.author_id = u.id
 		WHERE (a.expires_at IS NULL OR a.expires_at > CURRENT_TIMESTAMP)
+		  AND ($2::uuid IS NULL OR a.school_id = $2)
 		ORDER BY a.is_pinned DESC, a.created_at DESC
 		LIMIT $1
 	`
 
-	rows, err := r.db.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, limit, tenant.SchoolID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -350,15 +382,3 @@ type StudentInfo struct {
 	FullName   string    `json:"full_name"`
 	Email      string    `json:"email"`
 }
-
-// Helper function
-func getCurrentAcademicYear() string {
-	now := time.Now()
-	year := now.Year()
-	month := now.Month()
-
-	if month < time.April {
-		return fmt.Sprintf("%d-%d", year-1, year)
-	}
-	return fmt.Sprintf("%d-%d", year, year+1)
-}
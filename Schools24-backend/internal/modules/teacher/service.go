@@ -7,12 +7,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/modules/settings"
 )
 
 // Service handles teacher business logic
 type Service struct {
-	repo   *Repository
-	config *config.Config
+	repo     *Repository
+	settings *settings.Service
+	config   *config.Config
 }
 
 // Common errors
@@ -23,10 +25,11 @@ var (
 )
 
 // NewService creates a new teacher service
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, settingsService *settings.Service, cfg *config.Config) *Service {
 	return &Service{
-		repo:   repo,
-		config: cfg,
+		repo:     repo,
+		settings: settingsService,
+		config:   cfg,
 	}
 }
 
@@ -40,7 +43,7 @@ func (s *Service) GetDashboard(ctx context.Context, userID uuid.UUID) (*TeacherD
 		return nil, ErrTeacherNotFound
 	}
 
-	academicYear := getCurrentAcademicYear()
+	academicYear := s.settings.CurrentAcademicYear(ctx)
 
 	// Get assigned classes
 	assignments, err := s.repo.GetTeacherAssignments(ctx, teacher.ID, academicYear)
@@ -106,12 +109,12 @@ func (s *Service) GetAssignedClasses(ctx context.Context, userID uuid.UUID) ([]T
 		return nil, ErrTeacherNotFound
 	}
 
-	academicYear := getCurrentAcademicYear()
+	academicYear := s.settings.CurrentAcademicYear(ctx)
 	return s.repo.GetTeacherAssignments(ctx, teacher.ID, academicYear)
 }
 
-// GetStudentsByClass returns students in a class
-func (s *Service) GetStudentsByClass(ctx context.Context, userID, classID uuid.UUID) ([]StudentInfo, error) {
+// GetStudentsByClass returns the class register on a date, today if date is zero
+func (s *Service) GetStudentsByClass(ctx context.Context, userID, classID uuid.UUID, date time.Time) ([]StudentInfo, error) {
 	teacher, err := s.repo.GetTeacherByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -121,7 +124,10 @@ func (s *Service) GetStudentsByClass(ctx context.Context, userID, classID uuid.U
 	}
 
 	// TODO: Verify teacher is assigned to this class
-	return s.repo.GetStudentsByClass(ctx, classID)
+	if date.IsZero() {
+		date = time.Now()
+	}
+	return s.repo.GetStudentsByClass(ctx, classID, date)
 }
 
 // MarkAttendance marks attendance for a class
@@ -170,7 +176,17 @@ func (s *Service) EnterGrade(ctx context.Context, userID uuid.UUID, req *EnterGr
 		return ErrTeacherNotFound
 	}
 
-	return s.repo.EnterGrade(ctx, teacher.ID, req)
+	// The grade and academic year follow the school's settings on the exam date
+	examDate := time.Now()
+	if req.ExamDate != "" {
+		if t, err := time.Parse("2006-01-02", req.ExamDate); err == nil {
+			examDate = t
+		}
+	}
+	academicYear := s.settings.AcademicYear(ctx, examDate)
+	grade := s.settings.GradeFor(ctx, req.MarksObtained, float64(req.MaxMarks))
+
+	return s.repo.EnterGrade(ctx, teacher.ID, req, academicYear, grade)
 }
 
 // CreateAnnouncement creates a new announcement
@@ -52,18 +52,18 @@ func RegisterRoutes(r *gin.Engine, deps *Dependencies) {
 			auth.POST("/login", placeholderHandler("auth.login"))
 			auth.POST("/register", placeholderHandler("auth.register"))
 			auth.POST("/forgot-password", placeholderHandler("auth.forgot_password"))
+			auth.POST("/refresh", placeholderHandler("auth.refresh"))
 		}
 	}
 
 	// Protected routes (auth required)
 	protected := r.Group("/api/v1")
-	protected.Use(middleware.JWTAuth(middleware.DefaultJWTConfig(deps.Config.JWT.Secret)))
+	protected.Use(middleware.JWTAuth(middleware.DefaultJWTConfig(middleware.NewHMACKeySet(deps.Config.JWT.Secret))))
 	{
 		// Auth protected routes
 		auth := protected.Group("/auth")
 		{
 			auth.POST("/logout", placeholderHandler("auth.logout"))
-			auth.POST("/refresh", placeholderHandler("auth.refresh"))
 			auth.GET("/me", placeholderHandler("auth.me"))
 		}
 
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/schools24/backend/internal/shared/database"
+)
+
+// PostgresStore keeps entries in the denylist_entries table. It stands in for
+// Redis where entries must outlive the process and be shared by every
+// instance, at the cost of a query per lookup.
+type PostgresStore struct {
+	db   *database.PostgresDB
+	stop chan struct{}
+}
+
+// NewPostgresStore creates a store and starts removing expired entries every cleanup interval
+func NewPostgresStore(db *database.PostgresDB, cleanup time.Duration) *PostgresStore {
+	s := &PostgresStore{db: db, stop: make(chan struct{})}
+	go s.cleanupLoop(cleanup)
+	return s
+}
+
+// Get returns a key's value, or ErrNotFound if it is missing or expired
+func (s *PostgresStore) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(ctx,
+		`SELECT value FROM denylist_entries WHERE key = $1 AND expires_at > $2`,
+		key, time.Now(),
+	).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// Set stores a value until ttl has passed
+func (s *PostgresStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	var data string
+	switch v := value.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		jsonBytes, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		data = string(jsonBytes)
+	}
+
+	query := `
+		INSERT INTO denylist_entries (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+	`
+	return s.db.Exec(ctx, query, key, data, time.Now().Add(ttl))
+}
+
+// Delete removes keys
+func (s *PostgresStore) Delete(ctx context.Context, keys ...string) error {
+	return s.db.Exec(ctx, `DELETE FROM denylist_entries WHERE key = ANY($1)`, keys)
+}
+
+// Close stops the cleanup loop
+func (s *PostgresStore) Close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *PostgresStore) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := s.db.Exec(ctx, `DELETE FROM denylist_entries WHERE expires_at <= $1`, time.Now()); err != nil {
+				log.Printf("Failed to remove expired denylist entries: %v", err)
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/go-redis/redis/v8"
+)
+
+// Store is the key/value interface shared by the in-memory Cache and RedisClient
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+var (
+	_ Store = (*Cache)(nil)
+	_ Store = (*RedisClient)(nil)
+	_ Store = (*PostgresStore)(nil)
+)
+
+// ErrNotFound is returned by PostgresStore for missing or expired keys
+var ErrNotFound = errors.New("cache: key not found")
+
+// IsNotFound reports whether err means the key does not exist in any backend
+func IsNotFound(err error) bool {
+	return errors.Is(err, bigcache.ErrEntryNotFound) || errors.Is(err, redis.Nil) || errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditActor identifies who is behind the row changes of a request.
+// The audit triggers read it from the connection's app.* settings.
+type AuditActor struct {
+	UserID    string
+	SchoolID  string
+	IPAddress string
+	UserAgent string
+}
+
+type auditActorKey struct{}
+
+// auditActorData is the connection's CustomData key for the actor its settings hold
+const auditActorData = "audit_actor"
+
+// WithAuditActor returns a context whose queries are attributed to actor
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, &actor)
+}
+
+// applyAuditActor runs before each connection is handed out and points the
+// connection's app.* settings at the context's actor, clearing them for
+// contexts without one. Connections already set for the same request are
+// left alone, so a request costs at most one extra round trip per acquire.
+func applyAuditActor(ctx context.Context, conn *pgx.Conn) bool {
+	actor, _ := ctx.Value(auditActorKey{}).(*AuditActor)
+	data := conn.PgConn().CustomData()
+	current, _ := data[auditActorData].(*AuditActor)
+	if actor == current {
+		return true
+	}
+
+	settings := actor
+	if settings == nil {
+		settings = &AuditActor{}
+	}
+	_, err := conn.Exec(ctx, `
+		SELECT set_config('app.actor_id', $1, false), set_config('app.school_id', $2, false),
+		       set_config('app.ip_address', $3, false), set_config('app.user_agent', $4, false)
+	`, settings.UserID, settings.SchoolID, settings.IPAddress, settings.UserAgent)
+	if err != nil {
+		// The pool discards the connection rather than risk misattributed changes
+		log.Printf("Failed to set audit actor on connection: %v", err)
+		return false
+	}
+
+	if actor == nil {
+		delete(data, auditActorData)
+	} else {
+		data[auditActorData] = actor
+	}
+	return true
+}
@@ -37,7 +37,7 @@ func (db *PostgresDB) RunMigrations(ctx context.Context) error {
 	}
 	log.Println("✓ users table ready")
 
-	// Password resets table
+	// Password resets table (token holds the SHA-256 hash, never the raw token)
 	passwordResetsTable := `
 		CREATE TABLE IF NOT EXISTS password_resets (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -57,6 +57,173 @@ func (db *PostgresDB) RunMigrations(ctx context.Context) error {
 	}
 	log.Println("✓ password_resets table ready")
 
+	// Refresh tokens table (opaque, single-use, rotated within a family)
+	refreshTokensTable := `
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			family_id UUID NOT NULL,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP,
+			replaced_by UUID REFERENCES refresh_tokens(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+		CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+	`
+
+	if err := db.Exec(ctx, refreshTokensTable); err != nil {
+		return err
+	}
+	log.Println("✓ refresh_tokens table ready")
+
+	// Email verifications table (token_hash holds the SHA-256 hash, never the raw token)
+	emailVerificationsTable := `
+		CREATE TABLE IF NOT EXISTS email_verifications (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id);
+	`
+
+	if err := db.Exec(ctx, emailVerificationsTable); err != nil {
+		return err
+	}
+	log.Println("✓ email_verifications table ready")
+
+	// TOTP two-factor authentication. last_used_step blocks code replay.
+	mfaTables := `
+		CREATE TABLE IF NOT EXISTS user_mfa (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			totp_secret VARCHAR(64) NOT NULL,
+			enabled BOOLEAN DEFAULT false,
+			last_used_step BIGINT NOT NULL DEFAULT 0,
+			enabled_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash VARCHAR(64) NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS mfa_verified BOOLEAN NOT NULL DEFAULT false;
+	`
+
+	if err := db.Exec(ctx, mfaTables); err != nil {
+		return err
+	}
+	log.Println("✓ user_mfa tables ready")
+
+	// Failed login tracking, keyed by account (normalized email) and by client IP
+	loginFailuresTable := `
+		CREATE TABLE IF NOT EXISTS login_failures (
+			kind VARCHAR(10) NOT NULL CHECK (kind IN ('account', 'ip')),
+			key VARCHAR(255) NOT NULL,
+			failures INTEGER NOT NULL DEFAULT 0,
+			first_failed_at TIMESTAMP NOT NULL,
+			last_failed_at TIMESTAMP NOT NULL,
+			locked_until TIMESTAMP,
+			PRIMARY KEY (kind, key)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_login_failures_locked_until ON login_failures(locked_until);
+	`
+
+	if err := db.Exec(ctx, loginFailuresTable); err != nil {
+		return err
+	}
+	log.Println("✓ login_failures table ready")
+
+	// Login sessions; a session's id is the family_id of its refresh tokens
+	userSessionsTable := `
+		CREATE TABLE IF NOT EXISTS user_sessions (
+			id UUID PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			device VARCHAR(100),
+			user_agent TEXT,
+			ip_address VARCHAR(45),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+	`
+
+	if err := db.Exec(ctx, userSessionsTable); err != nil {
+		return err
+	}
+	log.Println("✓ user_sessions table ready")
+
+	// One-time codes for phone login (code_hash is salted with the user ID)
+	loginOTPsTable := `
+		CREATE TABLE IF NOT EXISTS login_otps (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash VARCHAR(64) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_login_otps_user_id ON login_otps(user_id);
+	`
+
+	if err := db.Exec(ctx, loginOTPsTable); err != nil {
+		return err
+	}
+	log.Println("✓ login_otps table ready")
+
+	// Recent password hashes, checked so users cannot cycle back to an old password
+	passwordHistoryTable := `
+		CREATE TABLE IF NOT EXISTS password_history (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			password_hash VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history(user_id, created_at DESC);
+	`
+
+	if err := db.Exec(ctx, passwordHistoryTable); err != nil {
+		return err
+	}
+	log.Println("✓ password_history table ready")
+
+	// Token revocations when Redis is not configured, so they survive restarts
+	// and reach every instance
+	denylistTable := `
+		CREATE TABLE IF NOT EXISTS denylist_entries (
+			key VARCHAR(255) PRIMARY KEY,
+			value TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_denylist_entries_expires_at ON denylist_entries(expires_at);
+	`
+
+	if err := db.Exec(ctx, denylistTable); err != nil {
+		return err
+	}
+	log.Println("✓ denylist_entries table ready")
+
 	log.Println("All migrations completed successfully!")
 	return nil
 }
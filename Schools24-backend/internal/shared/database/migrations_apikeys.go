@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunAPIKeyMigrations creates the API key table used by service accounts.
+// Service accounts are users with role 'service'. Must run after tenancy migrations.
+func (db *PostgresDB) RunAPIKeyMigrations(ctx context.Context) error {
+	log.Println("Running API key migrations...")
+
+	// key_hash holds the SHA-256 hash of the full key; prefix identifies the
+	// key in listings and logs and is used to look it up
+	apiKeysTable := `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			service_account_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			prefix VARCHAR(20) UNIQUE NOT NULL,
+			key_hash VARCHAR(64) NOT NULL,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			last_used_ip VARCHAR(45),
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_api_keys_school_id ON api_keys(school_id);
+		CREATE INDEX IF NOT EXISTS idx_api_keys_service_account_id ON api_keys(service_account_id);
+	`
+	if err := db.Exec(ctx, apiKeysTable); err != nil {
+		return err
+	}
+	log.Println("✓ api_keys table ready")
+
+	return nil
+}
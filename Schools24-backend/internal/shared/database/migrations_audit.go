@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// auditedTables maps each table whose row changes are written to audit_logs
+// to its entity type and the column holding the entity ID. Tables whose
+// changes are already audited by their service (invitations, API keys, SSO
+// providers, settings) are left out.
+var auditedTables = []struct {
+	table, entityType, idColumn string
+}{
+	{"users", "user", "id"},
+	{"students", "student", "id"},
+	{"teachers", "teacher", "id"},
+	{"parent_students", "parent_link", "student_id"},
+	{"classes", "class", "id"},
+	{"subjects", "subject", "id"},
+	{"teacher_assignments", "teacher_assignment", "id"},
+	{"timetables", "timetable", "id"},
+	{"homework", "homework", "id"},
+	{"grades", "grade", "id"},
+	{"attendance", "attendance", "id"},
+	{"fee_structures", "fee_structure", "id"},
+	{"fee_items", "fee_item", "id"},
+	{"student_fees", "student_fee", "id"},
+	{"payments", "payment", "id"},
+	{"roles", "role", "id"},
+	{"user_roles", "user_role", "user_id"},
+}
+
+// RunAuditMigrations chains audit_logs entries into a tamper-evident hash chain
+// and installs the triggers that write every change to an audited table into
+// audit_logs with a diff of the changed columns.
+// The actor, school, IP and user agent come from the app.* session settings
+// set by the connection pool for audited requests (see WithAuditActor).
+// Must run after all other migrations.
+func (db *PostgresDB) RunAuditMigrations(ctx context.Context) error {
+	log.Println("Running audit migrations...")
+
+	// Each school's entries form a hash chain (entries without a school form the
+	// platform chain). seq numbers the chain so a deleted entry leaves a gap.
+	auditChainColumns := `
+		ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS seq BIGINT;
+		ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+		ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash VARCHAR(64);
+	`
+	if err := db.Exec(ctx, auditChainColumns); err != nil {
+		return err
+	}
+
+	// The hash covers every column and the previous hash, encoded as a JSON
+	// array so field boundaries are unambiguous
+	auditHashFunction := `
+		CREATE OR REPLACE FUNCTION audit_log_hash(prev TEXT, a audit_logs) RETURNS TEXT AS $$
+			SELECT encode(sha256(convert_to(jsonb_build_array(
+				prev, a.seq, a.id, a.school_id, a.user_id, a.action, a.entity_type, a.entity_id,
+				a.old_values, a.new_values, a.ip_address, a.user_agent,
+				to_char(a.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US')
+			)::text, 'UTF8')), 'hex')
+		$$ LANGUAGE sql STABLE;
+	`
+	if err := db.Exec(ctx, auditHashFunction); err != nil {
+		return err
+	}
+
+	// Chain entries written before the chain existed, oldest first
+	auditBackfill := `
+		DO $$
+		DECLARE
+			r RECORD;
+			last_seq BIGINT;
+			last_hash TEXT;
+		BEGIN
+			LOCK TABLE audit_logs IN SHARE ROW EXCLUSIVE MODE;
+			FOR r IN SELECT id, school_id FROM audit_logs WHERE hash IS NULL ORDER BY created_at, id LOOP
+				SELECT seq, hash INTO last_seq, last_hash FROM audit_logs
+				WHERE COALESCE(school_id, '00000000-0000-0000-0000-000000000000') = COALESCE(r.school_id, '00000000-0000-0000-0000-000000000000')
+				  AND hash IS NOT NULL
+				ORDER BY seq DESC LIMIT 1;
+
+				UPDATE audit_logs SET seq = COALESCE(last_seq, 0) + 1, prev_hash = COALESCE(last_hash, repeat('0', 64))
+				WHERE id = r.id;
+				UPDATE audit_logs a SET hash = audit_log_hash(a.prev_hash, a) WHERE a.id = r.id;
+			END LOOP;
+		END $$;
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_chain
+			ON audit_logs ((COALESCE(school_id, '00000000-0000-0000-0000-000000000000')), seq);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
+	`
+	if err := db.Exec(ctx, auditBackfill); err != nil {
+		return err
+	}
+
+	// New entries are appended to their chain under a per-chain lock held until
+	// commit, so concurrent writers cannot fork it. Chained entries are immutable.
+	auditChainTriggers := `
+		CREATE OR REPLACE FUNCTION audit_log_chain() RETURNS trigger AS $$
+		DECLARE
+			last_seq BIGINT;
+			last_hash TEXT;
+		BEGIN
+			PERFORM pg_advisory_xact_lock(hashtextextended('audit_logs:' || COALESCE(NEW.school_id::text, 'platform'), 0));
+			SELECT seq, hash INTO last_seq, last_hash FROM audit_logs
+			WHERE COALESCE(school_id, '00000000-0000-0000-0000-000000000000') = COALESCE(NEW.school_id, '00000000-0000-0000-0000-000000000000')
+			  AND hash IS NOT NULL
+			ORDER BY seq DESC LIMIT 1;
+
+			NEW.seq := COALESCE(last_seq, 0) + 1;
+			NEW.prev_hash := COALESCE(last_hash, repeat('0', 64));
+			NEW.hash := audit_log_hash(NEW.prev_hash, NEW);
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' AND OLD.hash IS NULL THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS audit_logs_chain ON audit_logs;
+		CREATE TRIGGER audit_logs_chain BEFORE INSERT ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_log_chain();
+
+		DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+		CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+	`
+	if err := db.Exec(ctx, auditChainTriggers); err != nil {
+		return err
+	}
+	log.Println("✓ audit_logs hash chain ready")
+
+	// Inserts log the new row, deletes the old one and updates only the columns
+	// that changed. Secrets are never copied; updates touching only bookkeeping
+	// columns are not logged.
+	auditFunction := `
+		CREATE OR REPLACE FUNCTION audit_row_change() RETURNS trigger AS $$
+		DECLARE
+			redacted TEXT[] := ARRAY['password_hash', 'key_hash', 'client_secret', 'secret', 'code_hash', 'token_hash'];
+			noise TEXT[] := ARRAY['updated_at', 'last_login_at', 'last_seen_at', 'last_used_at', 'last_used_ip', 'total_students'];
+			old_row JSONB;
+			new_row JSONB;
+			old_diff JSONB := '{}'::jsonb;
+			new_diff JSONB := '{}'::jsonb;
+			changed BOOLEAN := false;
+			col TEXT;
+			ref JSONB;
+		BEGIN
+			IF TG_OP <> 'INSERT' THEN
+				old_row := to_jsonb(OLD) - redacted;
+			END IF;
+			IF TG_OP <> 'DELETE' THEN
+				new_row := to_jsonb(NEW) - redacted;
+			END IF;
+
+			IF TG_OP = 'UPDATE' THEN
+				FOR col IN SELECT jsonb_object_keys(new_row) LOOP
+					IF old_row -> col IS DISTINCT FROM new_row -> col THEN
+						old_diff := old_diff || jsonb_build_object(col, old_row -> col);
+						new_diff := new_diff || jsonb_build_object(col, new_row -> col);
+						IF NOT col = ANY(noise) THEN
+							changed := true;
+						END IF;
+					END IF;
+				END LOOP;
+				IF NOT changed THEN
+					RETURN NULL;
+				END IF;
+				old_row := old_diff;
+				new_row := new_diff;
+			END IF;
+
+			ref := COALESCE(to_jsonb(NEW), to_jsonb(OLD));
+			INSERT INTO audit_logs (user_id, action, entity_type, entity_id, old_values, new_values, ip_address, user_agent, school_id)
+			VALUES (
+				NULLIF(current_setting('app.actor_id', true), '')::uuid,
+				TG_ARGV[0] || '.' || CASE TG_OP WHEN 'INSERT' THEN 'created' WHEN 'UPDATE' THEN 'updated' ELSE 'deleted' END,
+				TG_ARGV[0],
+				(ref ->> TG_ARGV[1])::uuid,
+				old_row,
+				new_row,
+				NULLIF(current_setting('app.ip_address', true), ''),
+				NULLIF(current_setting('app.user_agent', true), ''),
+				COALESCE((ref ->> 'school_id')::uuid, NULLIF(current_setting('app.school_id', true), '')::uuid)
+			);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+	`
+	if err := db.Exec(ctx, auditFunction); err != nil {
+		return err
+	}
+	log.Println("✓ audit_row_change function ready")
+
+	for _, t := range auditedTables {
+		trigger := fmt.Sprintf(`
+			DROP TRIGGER IF EXISTS audit_%[1]s ON %[1]s;
+			CREATE TRIGGER audit_%[1]s
+				AFTER INSERT OR UPDATE OR DELETE ON %[1]s
+				FOR EACH ROW EXECUTE FUNCTION audit_row_change('%[2]s', '%[3]s');
+		`, t.table, t.entityType, t.idColumn)
+		if err := db.Exec(ctx, trigger); err != nil {
+			return fmt.Errorf("failed to install audit trigger on %s: %w", t.table, err)
+		}
+	}
+	log.Println("✓ audit triggers ready")
+
+	return nil
+}
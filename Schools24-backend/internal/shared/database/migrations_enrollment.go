@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunEnrollmentMigrations creates the enrollment history of students and the
+// triggers that keep it and classes.total_students in step with students.
+// Must run after rollover migrations.
+func (db *PostgresDB) RunEnrollmentMigrations(ctx context.Context) error {
+	log.Println("Running enrollment migrations...")
+
+	// A student is in class_id from start_date until the day before end_date;
+	// the current enrollment has no end_date
+	enrollmentsTable := `
+		CREATE TABLE IF NOT EXISTS student_enrollments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+			class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+			start_date DATE NOT NULL,
+			end_date DATE,
+			reason VARCHAR(30) NOT NULL,
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (end_date IS NULL OR end_date >= start_date)
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_student_enrollments_current ON student_enrollments(student_id) WHERE end_date IS NULL;
+		CREATE INDEX IF NOT EXISTS idx_student_enrollments_student ON student_enrollments(student_id, start_date);
+		CREATE INDEX IF NOT EXISTS idx_student_enrollments_class ON student_enrollments(class_id, start_date);
+		CREATE INDEX IF NOT EXISTS idx_student_enrollments_school_id ON student_enrollments(school_id);
+	`
+	if err := db.Exec(ctx, enrollmentsTable); err != nil {
+		return err
+	}
+	log.Println("✓ student_enrollments table ready")
+
+	// student_class_on resolves the class a student was in on a date
+	enrollmentFunctions := `
+		CREATE OR REPLACE FUNCTION student_class_on(student UUID, on_date DATE) RETURNS UUID AS $$
+			SELECT class_id FROM student_enrollments
+			WHERE student_id = student AND start_date <= on_date
+			  AND (end_date IS NULL OR end_date > on_date)
+			ORDER BY start_date DESC
+			LIMIT 1
+		$$ LANGUAGE sql STABLE;
+
+		CREATE OR REPLACE FUNCTION class_total_students(class UUID) RETURNS VOID AS $$
+			UPDATE classes SET total_students = (
+				SELECT COUNT(*) FROM students WHERE class_id = class AND status = 'active'
+			)
+			WHERE id = class;
+		$$ LANGUAGE sql;
+	`
+	if err := db.Exec(ctx, enrollmentFunctions); err != nil {
+		return err
+	}
+
+	// A change of class or status ends the current enrollment and starts the
+	// next one. The date defaults to the admission date for new students and to
+	// today for changes, and the reason to the kind of change; callers that
+	// know better set app.enrollment_date and app.enrollment_reason for their
+	// transaction.
+	enrollmentTriggers := `
+		CREATE OR REPLACE FUNCTION student_enrollment_change() RETURNS trigger AS $$
+		DECLARE
+			effective DATE := NULLIF(current_setting('app.enrollment_date', true), '')::date;
+			reason TEXT := NULLIF(current_setting('app.enrollment_reason', true), '');
+			enrolled_in UUID := CASE WHEN NEW.status = 'active' THEN NEW.class_id END;
+		BEGIN
+			IF TG_OP = 'UPDATE' AND OLD.class_id IS NOT DISTINCT FROM NEW.class_id AND OLD.status = NEW.status THEN
+				RETURN NULL;
+			END IF;
+			IF TG_OP = 'INSERT' THEN
+				effective := COALESCE(effective, NEW.admission_date, CURRENT_DATE);
+			ELSE
+				effective := COALESCE(effective, CURRENT_DATE);
+			END IF;
+
+			UPDATE student_enrollments SET end_date = GREATEST(effective, start_date)
+			WHERE student_id = NEW.id AND end_date IS NULL AND class_id IS DISTINCT FROM enrolled_in;
+
+			IF enrolled_in IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM student_enrollments WHERE student_id = NEW.id AND end_date IS NULL
+			) THEN
+				INSERT INTO student_enrollments (school_id, student_id, class_id, start_date, reason, created_by)
+				VALUES (
+					NEW.school_id, NEW.id, enrolled_in, effective,
+					COALESCE(reason, CASE WHEN TG_OP = 'INSERT' THEN 'admission' ELSE 'class_change' END),
+					NULLIF(current_setting('app.actor_id', true), '')::uuid
+				);
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE FUNCTION student_class_count_change() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP <> 'INSERT' AND OLD.class_id IS NOT NULL THEN
+				PERFORM class_total_students(OLD.class_id);
+			END IF;
+			IF TG_OP <> 'DELETE' AND NEW.class_id IS NOT NULL
+			   AND (TG_OP = 'INSERT' OR NEW.class_id IS DISTINCT FROM OLD.class_id) THEN
+				PERFORM class_total_students(NEW.class_id);
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS students_enrollment ON students;
+		CREATE TRIGGER students_enrollment
+			AFTER INSERT OR UPDATE OF class_id, status ON students
+			FOR EACH ROW EXECUTE FUNCTION student_enrollment_change();
+
+		DROP TRIGGER IF EXISTS students_class_count ON students;
+		CREATE TRIGGER students_class_count
+			AFTER INSERT OR UPDATE OF class_id, status OR DELETE ON students
+			FOR EACH ROW EXECUTE FUNCTION student_class_count_change();
+	`
+	if err := db.Exec(ctx, enrollmentTriggers); err != nil {
+		return err
+	}
+	log.Println("✓ enrollment triggers ready")
+
+	// Students enrolled before history was kept start in their current class
+	// from admission, or from the day after they were last marked in another class
+	enrollmentBackfill := `
+		INSERT INTO student_enrollments (school_id, student_id, class_id, start_date, reason)
+		SELECT s.school_id, s.id, s.class_id,
+		       GREATEST(
+				COALESCE(s.admission_date, s.created_at::date, CURRENT_DATE),
+				COALESCE((SELECT MAX(a.date) + 1 FROM attendance a WHERE a.student_id = s.id AND a.class_id <> s.class_id), '-infinity'::date)
+		       ),
+		       'backfill'
+		FROM students s
+		WHERE s.class_id IS NOT NULL AND s.status = 'active'
+		  AND NOT EXISTS (SELECT 1 FROM student_enrollments e WHERE e.student_id = s.id);
+
+		-- Admissions recorded before the trigger used the admission date started on
+		-- the day the student was created; move them back to the admission date
+		UPDATE student_enrollments e SET start_date = s.admission_date
+		FROM students s
+		WHERE s.id = e.student_id AND e.reason = 'admission' AND s.admission_date < e.start_date
+		  AND NOT EXISTS (
+			SELECT 1 FROM student_enrollments p
+			WHERE p.student_id = e.student_id AND p.start_date < e.start_date
+		  );
+
+		UPDATE classes c SET total_students = (
+			SELECT COUNT(*) FROM students s WHERE s.class_id = c.id AND s.status = 'active'
+		)
+		WHERE total_students IS DISTINCT FROM (
+			SELECT COUNT(*) FROM students s WHERE s.class_id = c.id AND s.status = 'active'
+		);
+	`
+	if err := db.Exec(ctx, enrollmentBackfill); err != nil {
+		return err
+	}
+	log.Println("✓ enrollments backfilled and class counts recounted")
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunFeeMigrations prepares student_fees for generated instalments and adds
+// opt-ins for optional fee items. Must run after tenancy migrations.
+func (db *PostgresDB) RunFeeMigrations(ctx context.Context) error {
+	log.Println("Running fee migrations...")
+
+	// An instalment covers [period_start, period_end) of its item's schedule;
+	// a student is charged once per item and period, so generation can re-run
+	studentFeeColumns := `
+		ALTER TABLE student_fees ADD COLUMN IF NOT EXISTS period_start DATE;
+		ALTER TABLE student_fees ADD COLUMN IF NOT EXISTS period_end DATE;
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_student_fees_instalment ON student_fees(student_id, fee_item_id, period_start);
+		CREATE INDEX IF NOT EXISTS idx_student_fees_fee_item_id ON student_fees(fee_item_id);
+	`
+	if err := db.Exec(ctx, studentFeeColumns); err != nil {
+		return err
+	}
+	log.Println("✓ student_fees instalment columns ready")
+
+	// Optional items are charged only to students who opted in
+	optInsTable := `
+		CREATE TABLE IF NOT EXISTS fee_item_opt_ins (
+			fee_item_id UUID NOT NULL REFERENCES fee_items(id) ON DELETE CASCADE,
+			student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (fee_item_id, student_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_fee_item_opt_ins_student_id ON fee_item_opt_ins(student_id);
+	`
+	if err := db.Exec(ctx, optInsTable); err != nil {
+		return err
+	}
+	log.Println("✓ fee_item_opt_ins table ready")
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunOnboardingMigrations creates the invitation and parent sign-up tables.
+// Must run after tenancy and parent migrations.
+func (db *PostgresDB) RunOnboardingMigrations(ctx context.Context) error {
+	log.Println("Running onboarding migrations...")
+
+	// Invitations issued by school admins. token_hash is set when the
+	// invitation email is sent and replaced on resend.
+	invitationsTable := `
+		CREATE TABLE IF NOT EXISTS invitations (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			email VARCHAR(255) NOT NULL,
+			role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'teacher', 'student', 'staff', 'parent')),
+			full_name VARCHAR(255),
+			-- Parent invitations: child to link on acceptance
+			student_id UUID REFERENCES students(id) ON DELETE CASCADE,
+			relationship VARCHAR(20) CHECK (relationship IN ('mother', 'father', 'guardian', 'other')),
+			-- Student invitations: profile created on acceptance
+			class_id UUID REFERENCES classes(id) ON DELETE SET NULL,
+			admission_number VARCHAR(50),
+			roll_number VARCHAR(50),
+			date_of_birth DATE,
+			invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			token_hash VARCHAR(64) UNIQUE,
+			expires_at TIMESTAMP NOT NULL,
+			sent_at TIMESTAMP,
+			accepted_at TIMESTAMP,
+			accepted_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_invitations_school_id ON invitations(school_id, created_at DESC);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_pending_email ON invitations(school_id, LOWER(email))
+			WHERE accepted_at IS NULL AND revoked_at IS NULL;
+	`
+	if err := db.Exec(ctx, invitationsTable); err != nil {
+		return err
+	}
+	log.Println("✓ invitations table ready")
+
+	// Parent self sign-ups awaiting admin approval; the user stays inactive until approved
+	parentSignupsTable := `
+		CREATE TABLE IF NOT EXISTS parent_signups (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			child_details TEXT,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+			reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			reviewed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_parent_signups_school_status ON parent_signups(school_id, status);
+	`
+	if err := db.Exec(ctx, parentSignupsTable); err != nil {
+		return err
+	}
+	log.Println("✓ parent_signups table ready")
+
+	return nil
+}
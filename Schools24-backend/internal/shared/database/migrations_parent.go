@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunParentMigrations creates the parent-student link table.
+// Must run after tenancy migrations.
+func (db *PostgresDB) RunParentMigrations(ctx context.Context) error {
+	log.Println("Running parent migrations...")
+
+	// Parent-student links: a parent may have many children, a child at most
+	// two guardians (enforced when linking)
+	parentStudentsTable := `
+		CREATE TABLE IF NOT EXISTS parent_students (
+			parent_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+			relationship VARCHAR(20) DEFAULT 'guardian' CHECK (relationship IN ('mother', 'father', 'guardian', 'other')),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (parent_user_id, student_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_parent_students_student_id ON parent_students(student_id);
+		CREATE INDEX IF NOT EXISTS idx_parent_students_school_id ON parent_students(school_id);
+	`
+	if err := db.Exec(ctx, parentStudentsTable); err != nil {
+		return err
+	}
+	log.Println("✓ parent_students table ready")
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"log"
+
+	"github.com/schools24/backend/internal/shared/permission"
+)
+
+// RunRBACMigrations creates the roles and permissions tables and seeds the
+// permission catalog and built-in roles. Must run after tenancy migrations.
+func (db *PostgresDB) RunRBACMigrations(ctx context.Context) error {
+	log.Println("Running RBAC migrations...")
+
+	rbacTables := `
+		CREATE TABLE IF NOT EXISTS permissions (
+			key VARCHAR(100) PRIMARY KEY,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS roles (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID REFERENCES schools(id) ON DELETE CASCADE,
+			name VARCHAR(50) NOT NULL,
+			description TEXT,
+			is_system BOOLEAN DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_school_name
+			ON roles(COALESCE(school_id, '00000000-0000-0000-0000-000000000000'::uuid), name);
+
+		CREATE TABLE IF NOT EXISTS role_permissions (
+			role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+			permission_key VARCHAR(100) NOT NULL REFERENCES permissions(key) ON DELETE CASCADE,
+			PRIMARY KEY (role_id, permission_key)
+		);
+
+		CREATE TABLE IF NOT EXISTS user_roles (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+			assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, role_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+	`
+	if err := db.Exec(ctx, rbacTables); err != nil {
+		return err
+	}
+	log.Println("✓ roles and permissions tables ready")
+
+	// Permission catalog; remember which keys are new so defaults are applied once
+	newPermissions := map[string]bool{}
+	for _, p := range permission.Catalog {
+		// xmax = 0 only for freshly inserted rows
+		var inserted bool
+		err := db.QueryRow(ctx, `
+			INSERT INTO permissions (key, description) VALUES ($1, $2)
+			ON CONFLICT (key) DO UPDATE SET description = EXCLUDED.description
+			RETURNING (xmax = 0)
+		`, p.Key, p.Description).Scan(&inserted)
+		if err != nil {
+			return err
+		}
+		newPermissions[p.Key] = inserted
+	}
+
+	// Built-in roles. New roles get their full default set; existing roles only
+	// receive permissions that did not exist before, so admin edits are kept.
+	for name, defaults := range permission.SystemRoles {
+		var roleID string
+		var created bool
+		err := db.QueryRow(ctx, `
+			INSERT INTO roles (name, description, is_system)
+			VALUES ($1, 'Built-in role', true)
+			ON CONFLICT (COALESCE(school_id, '00000000-0000-0000-0000-000000000000'::uuid), name)
+			DO UPDATE SET is_system = true
+			RETURNING id, (xmax = 0)
+		`, name).Scan(&roleID, &created)
+		if err != nil {
+			return err
+		}
+
+		grants := defaults
+		if defaults == nil {
+			grants = make([]string, 0, len(permission.Catalog))
+			for _, p := range permission.Catalog {
+				grants = append(grants, p.Key)
+			}
+		}
+		for _, key := range grants {
+			if !created && defaults != nil && !newPermissions[key] {
+				continue
+			}
+			if err := db.Exec(ctx, `
+				INSERT INTO role_permissions (role_id, permission_key) VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, roleID, key); err != nil {
+				return err
+			}
+		}
+	}
+	log.Println("✓ built-in roles seeded")
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunRolloverMigrations creates the tables that record academic-year
+// rollovers and the columns they set. Must run after tenancy migrations.
+func (db *PostgresDB) RunRolloverMigrations(ctx context.Context) error {
+	log.Println("Running rollover migrations...")
+
+	// Students who leave or graduate keep their last class; status says they are gone
+	rolloverColumns := `
+		ALTER TABLE students ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+		ALTER TABLE students DROP CONSTRAINT IF EXISTS students_status_check;
+		ALTER TABLE students ADD CONSTRAINT students_status_check CHECK (status IN ('active', 'left', 'graduated'));
+
+		ALTER TABLE timetables ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+	`
+	if err := db.Exec(ctx, rolloverColumns); err != nil {
+		return err
+	}
+	log.Println("✓ student status and timetable archiving ready")
+
+	// A rollover runs as a sequence of steps; step counts the committed ones so
+	// a failed rollover resumes where it stopped. Its student plan is fixed when
+	// it starts and class_map records the class each old class was cloned into.
+	rolloverTables := `
+		CREATE TABLE IF NOT EXISTS academic_rollovers (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			from_year VARCHAR(20) NOT NULL,
+			to_year VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'completed', 'failed')),
+			step INT NOT NULL DEFAULT 0,
+			summary JSONB NOT NULL DEFAULT '{}',
+			error TEXT,
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			completed_at TIMESTAMP,
+			UNIQUE(school_id, from_year)
+		);
+
+		CREATE TABLE IF NOT EXISTS academic_rollover_classes (
+			rollover_id UUID NOT NULL REFERENCES academic_rollovers(id) ON DELETE CASCADE,
+			from_class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+			to_class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+			PRIMARY KEY (rollover_id, from_class_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS academic_rollover_students (
+			rollover_id UUID NOT NULL REFERENCES academic_rollovers(id) ON DELETE CASCADE,
+			student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+			from_class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+			outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('promoted', 'detained', 'left', 'graduated')),
+			target_class_id UUID REFERENCES classes(id) ON DELETE SET NULL,
+			applied_at TIMESTAMP,
+			PRIMARY KEY (rollover_id, student_id)
+		);
+	`
+	if err := db.Exec(ctx, rolloverTables); err != nil {
+		return err
+	}
+	log.Println("✓ academic rollover tables ready")
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunSettingsMigrations prepares the settings table for per-school typed
+// settings. Values are stored as JSON text. Must run after tenancy migrations.
+func (db *PostgresDB) RunSettingsMigrations(ctx context.Context) error {
+	log.Println("Running settings migrations...")
+
+	// Changes are audited by the settings service, keyed by setting so a
+	// setting's history survives it being reset and set again
+	settingsColumns := `
+		ALTER TABLE settings ADD COLUMN IF NOT EXISTS updated_by UUID REFERENCES users(id) ON DELETE SET NULL;
+		DROP TRIGGER IF EXISTS audit_settings ON settings;
+	`
+	if err := db.Exec(ctx, settingsColumns); err != nil {
+		return err
+	}
+	log.Println("✓ settings table ready")
+
+	return nil
+}
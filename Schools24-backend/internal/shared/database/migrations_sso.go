@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// RunSSOMigrations creates the per-school OpenID Connect tables.
+// Must run after tenancy migrations.
+func (db *PostgresDB) RunSSOMigrations(ctx context.Context) error {
+	log.Println("Running SSO migrations...")
+
+	// Identity providers configured by each school. client_secret is empty for
+	// public clients, which rely on PKCE alone.
+	ssoProvidersTable := `
+		CREATE TABLE IF NOT EXISTS sso_providers (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+			slug VARCHAR(50) NOT NULL,
+			display_name VARCHAR(100) NOT NULL,
+			issuer TEXT NOT NULL,
+			client_id TEXT NOT NULL,
+			client_secret TEXT,
+			scopes VARCHAR(255) NOT NULL DEFAULT 'openid email profile',
+			allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (school_id, slug)
+		);
+	`
+	if err := db.Exec(ctx, ssoProvidersTable); err != nil {
+		return err
+	}
+	log.Println("✓ sso_providers table ready")
+
+	// Pending authorization requests (state_hash holds the SHA-256 hash of the state)
+	ssoLoginStatesTable := `
+		CREATE TABLE IF NOT EXISTS sso_login_states (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			provider_id UUID NOT NULL REFERENCES sso_providers(id) ON DELETE CASCADE,
+			state_hash VARCHAR(64) UNIQUE NOT NULL,
+			nonce VARCHAR(64) NOT NULL,
+			code_verifier VARCHAR(128) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_sso_login_states_expires_at ON sso_login_states(expires_at);
+	`
+	if err := db.Exec(ctx, ssoLoginStatesTable); err != nil {
+		return err
+	}
+	log.Println("✓ sso_login_states table ready")
+
+	// Provider accounts linked to users on their first SSO login
+	userIdentitiesTable := `
+		CREATE TABLE IF NOT EXISTS user_identities (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			provider_id UUID NOT NULL REFERENCES sso_providers(id) ON DELETE CASCADE,
+			subject VARCHAR(255) NOT NULL,
+			email VARCHAR(255),
+			linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_login_at TIMESTAMP,
+			UNIQUE (provider_id, subject)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+	`
+	if err := db.Exec(ctx, userIdentitiesTable); err != nil {
+		return err
+	}
+	log.Println("✓ user_identities table ready")
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// DefaultSchoolCode identifies the school that pre-tenancy data is assigned to
+const DefaultSchoolCode = "default"
+
+// tenantTables are the tables owned by a single school.
+// Every row must belong to a school.
+var tenantTables = []string{
+	"classes",
+	"teachers",
+	"students",
+	"subjects",
+	"attendance",
+	"timetables",
+	"homework",
+	"homework_submissions",
+	"grades",
+	"teacher_assignments",
+	"announcements",
+	"messages",
+	"attendance_sessions",
+	"fee_structures",
+	"fee_items",
+	"student_fees",
+	"payments",
+	"settings",
+}
+
+// schoolUniqueColumns were globally unique before tenancy and are now unique per school
+var schoolUniqueColumns = map[string]string{
+	"subjects": "code",
+	"students": "admission_number",
+	"teachers": "employee_id",
+	"settings": "key",
+}
+
+// RunTenancyMigrations creates the schools table and adds school_id to tenant tables.
+// Must run after the core module migrations (auth, student, academic, teacher,
+// attendance and admin), whose tables it scopes.
+func (db *PostgresDB) RunTenancyMigrations(ctx context.Context) error {
+	log.Println("Running tenancy migrations...")
+
+	// Schools table
+	schoolsTable := `
+		CREATE TABLE IF NOT EXISTS schools (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(255) NOT NULL,
+			code VARCHAR(50) UNIQUE NOT NULL,
+			address TEXT,
+			phone VARCHAR(20),
+			email VARCHAR(255),
+			is_active BOOLEAN DEFAULT true,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_schools_code ON schools(code);
+	`
+	if err := db.Exec(ctx, schoolsTable); err != nil {
+		return err
+	}
+	log.Println("✓ schools table ready")
+
+	// Default school for data created before tenancy
+	defaultSchool := `
+		INSERT INTO schools (name, code)
+		VALUES ('Default School', $1)
+		ON CONFLICT (code) DO NOTHING
+	`
+	if err := db.Exec(ctx, defaultSchool, DefaultSchoolCode); err != nil {
+		return err
+	}
+
+	// Users: school_id is NULL only for super admins
+	usersTenancy := `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS school_id UUID REFERENCES schools(id);
+		UPDATE users SET school_id = (SELECT id FROM schools WHERE code = 'default')
+		WHERE school_id IS NULL AND role <> 'super_admin';
+		CREATE INDEX IF NOT EXISTS idx_users_school_id ON users(school_id);
+
+		ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+		ALTER TABLE users ADD CONSTRAINT users_role_check
+			CHECK (role IN ('super_admin', 'admin', 'teacher', 'student', 'staff', 'parent', 'service'));
+
+		ALTER TABLE users DROP CONSTRAINT IF EXISTS users_school_required;
+		ALTER TABLE users ADD CONSTRAINT users_school_required
+			CHECK (role = 'super_admin' OR school_id IS NOT NULL);
+	`
+	if err := db.Exec(ctx, usersTenancy); err != nil {
+		return err
+	}
+	log.Println("✓ users scoped to schools")
+
+	for _, table := range tenantTables {
+		tableTenancy := fmt.Sprintf(`
+			ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS school_id UUID REFERENCES schools(id);
+			UPDATE %[1]s SET school_id = (SELECT id FROM schools WHERE code = 'default') WHERE school_id IS NULL;
+			ALTER TABLE %[1]s ALTER COLUMN school_id SET NOT NULL;
+			CREATE INDEX IF NOT EXISTS idx_%[1]s_school_id ON %[1]s(school_id);
+		`, table)
+		if err := db.Exec(ctx, tableTenancy); err != nil {
+			return fmt.Errorf("failed to scope %s: %w", table, err)
+		}
+
+		if column, ok := schoolUniqueColumns[table]; ok {
+			uniqueness := fmt.Sprintf(`
+				ALTER TABLE %[1]s DROP CONSTRAINT IF EXISTS %[1]s_%[2]s_key;
+				CREATE UNIQUE INDEX IF NOT EXISTS idx_%[1]s_school_%[2]s ON %[1]s(school_id, %[2]s);
+			`, table, column)
+			if err := db.Exec(ctx, uniqueness); err != nil {
+				return fmt.Errorf("failed to scope %s.%s uniqueness: %w", table, column, err)
+			}
+		}
+	}
+	log.Println("✓ tenant tables scoped to schools")
+
+	// Audit logs: school_id is NULL for platform-level events
+	auditTenancy := `
+		ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS school_id UUID REFERENCES schools(id);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_school_id ON audit_logs(school_id);
+	`
+	if err := db.Exec(ctx, auditTenancy); err != nil {
+		return err
+	}
+	log.Println("✓ audit_logs scoped to schools")
+
+	log.Println("All tenancy migrations completed!")
+	return nil
+}
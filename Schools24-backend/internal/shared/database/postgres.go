@@ -29,6 +29,9 @@ func NewPostgresDB(databaseURL string) (*PostgresDB, error) {
 	config.MaxConnIdleTime = 10 * time.Minute
 	config.HealthCheckPeriod = 1 * time.Minute
 
+	// Attribute row changes to the request's actor for the audit triggers
+	config.BeforeAcquire = applyAuditActor
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the sender selected by EMAIL_PROVIDER (sendgrid, file or log)
+func New(cfg config.EmailConfig) Sender {
+	switch cfg.Provider {
+	case "sendgrid":
+		return NewSendGridSender(cfg.SendGridAPIKey, cfg.FromEmail, cfg.FromName)
+	case "file":
+		return NewFileSender(cfg.FileDir)
+	default:
+		return NewLogSender()
+	}
+}
+
+// Delivers reports whether the configured provider sends real email. The log
+// and file senders keep message bodies, which carry password reset and
+// invitation links, in plain text, so they are only for local development.
+func Delivers(cfg config.EmailConfig) bool {
+	return cfg.Provider == "sendgrid"
+}
+
+// LogSender writes messages to the application log (local development)
+type LogSender struct{}
+
+// NewLogSender creates a new log sender
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send logs the message
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender writes each message to its own file in a directory
+type FileSender struct {
+	Dir string
+}
+
+// NewFileSender creates a new file sender
+func NewFileSender(dir string) *FileSender {
+	// Ensure mail directory exists
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+	return &FileSender{Dir: dir}
+}
+
+// Send writes the message to <dir>/<timestamp>-<id>.eml
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	filename := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String())
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(s.Dir, filename), []byte(content), 0644)
+}
+
+// SendGridSender delivers messages through the SendGrid v3 API
+type SendGridSender struct {
+	apiKey    string
+	fromEmail string
+	fromName  string
+	client    *http.Client
+}
+
+// NewSendGridSender creates a new SendGrid sender
+func NewSendGridSender(apiKey, fromEmail, fromName string) *SendGridSender {
+	return &SendGridSender{
+		apiKey:    apiKey,
+		fromEmail: fromEmail,
+		fromName:  fromName,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts the message to SendGrid
+func (s *SendGridSender) Send(ctx context.Context, msg *Message) error {
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []map[string]string{{"email": msg.To}}},
+		},
+		"from":    map[string]string{"email": s.fromEmail, "name": s.fromName},
+		"subject": msg.Subject,
+		"content": []map[string]string{{"type": "text/plain", "value": msg.Body}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var errBody bytes.Buffer
+		errBody.ReadFrom(resp.Body)
+		return fmt.Errorf("sendgrid returned %d: %s", resp.StatusCode, strings.TrimSpace(errBody.String()))
+	}
+	return nil
+}
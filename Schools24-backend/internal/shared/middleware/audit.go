@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/schools24/backend/internal/shared/database"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// AuditActor attributes the row changes of mutating requests to the caller so
+// the audit triggers can record who made them. On authenticated routes it must
+// run after JWTAuth and TenantScope; on public routes only the IP address and
+// user agent are known.
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		actor := database.AuditActor{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		if claims := GetClaims(c); claims != nil {
+			actor.UserID = claims.UserID
+			// Changes made while impersonating belong to the real admin
+			if claims.ActorID != "" {
+				actor.UserID = claims.ActorID
+			}
+		}
+		if schoolID := tenant.SchoolID(c.Request.Context()); schoolID != nil {
+			actor.SchoolID = schoolID.String()
+		}
+
+		c.Request = c.Request.WithContext(database.WithAuditActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/schools24/backend/internal/shared/cache"
+)
+
+// TokenDenylist tracks revoked access tokens until they would have expired anyway.
+// Entries are keyed by token id (jti), by session id (sid) for a revoked login
+// session, or by user id for "sign out everywhere".
+type TokenDenylist struct {
+	store  cache.Store
+	maxTTL time.Duration // Lifetime of the longest-lived access token
+}
+
+// NewTokenDenylist creates a denylist backed by the given store. The store must
+// be shared by every instance and outlive restarts (Redis or Postgres), or a
+// revoked token becomes valid again until it expires.
+func NewTokenDenylist(store cache.Store, maxTTL time.Duration) *TokenDenylist {
+	return &TokenDenylist{store: store, maxTTL: maxTTL}
+}
+
+// RevokeToken denylists a single token until its expiry
+func (d *TokenDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return d.store.Set(ctx, tokenDenylistKey(jti), strconv.FormatInt(expiresAt.Unix(), 10), ttl)
+}
+
+// RevokeSession rejects every token issued for a login session
+func (d *TokenDenylist) RevokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return d.store.Set(ctx, sessionDenylistKey(sessionID), strconv.FormatInt(time.Now().Unix(), 10), d.maxTTL)
+}
+
+// RevokeUser rejects every token issued to the user up to now.
+// Tokens issued within the same second are treated as revoked too.
+func (d *TokenDenylist) RevokeUser(ctx context.Context, userID string) error {
+	return d.store.Set(ctx, userDenylistKey(userID), strconv.FormatInt(time.Now().Unix(), 10), d.maxTTL)
+}
+
+// IsRevoked reports whether the token was revoked individually, through its session,
+// or through its user or impersonating admin
+func (d *TokenDenylist) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if claims.ID != "" {
+		if _, err := d.store.Get(ctx, tokenDenylistKey(claims.ID)); err == nil {
+			return true, nil
+		} else if !cache.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	// Sessions are never resumed once revoked, so any hit rejects the token
+	if claims.SessionID != "" {
+		if _, err := d.store.Get(ctx, sessionDenylistKey(claims.SessionID)); err == nil {
+			return true, nil
+		} else if !cache.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	revoked, err := d.userRevoked(ctx, claims.UserID, claims)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	// Signing out the admin also ends their impersonation tokens
+	if claims.ActorID != "" {
+		return d.userRevoked(ctx, claims.ActorID, claims)
+	}
+	return false, nil
+}
+
+// userRevoked reports whether the token was issued before the user was signed out everywhere
+func (d *TokenDenylist) userRevoked(ctx context.Context, userID string, claims *Claims) (bool, error) {
+	value, err := d.store.Get(ctx, userDenylistKey(userID))
+	if err != nil {
+		if cache.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	revokedAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Unix() <= revokedAt, nil
+}
+
+func tokenDenylistKey(jti string) string {
+	return "denylist:jti:" + jti
+}
+
+func sessionDenylistKey(sessionID string) string {
+	return "denylist:session:" + sessionID
+}
+
+func userDenylistKey(userID string) string {
+	return "denylist:user:" + userID
+}
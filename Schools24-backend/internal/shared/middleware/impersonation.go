@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditLogger records security events (implemented by admin.Repository)
+type AuditLogger interface {
+	LogAudit(ctx context.Context, userID *uuid.UUID, action, entityType string, entityID *uuid.UUID, oldValues, newValues interface{}, ipAddress, userAgent string) error
+}
+
+// ImpersonationGuard makes impersonation tokens read-only and writes every
+// request made with one to the audit log under the real admin. Requests to
+// allowPaths (e.g. logout) may use any method. Must run after TenantScope so
+// the entries land in the school's log.
+func ImpersonationGuard(audit AuditLogger, allowPaths ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowPaths))
+	for _, path := range allowPaths {
+		allowed[path] = true
+	}
+
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || claims.ActorID == "" {
+			c.Next()
+			return
+		}
+
+		actorID, err := uuid.Parse(claims.ActorID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_token",
+				"message": "Malformed impersonation token",
+			})
+			return
+		}
+		targetID, _ := uuid.Parse(claims.UserID)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			if allowed[c.Request.URL.Path] {
+				c.Next()
+			} else {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":   "impersonation_read_only",
+					"message": "Changes cannot be made while viewing as another user",
+				})
+			}
+		}
+
+		details := map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": c.Writer.Status(),
+		}
+		if query := c.Request.URL.RawQuery; query != "" {
+			details["query"] = query
+		}
+		if err := audit.LogAudit(c.Request.Context(), &actorID, "impersonation.request", "user", &targetID,
+			nil, details, c.ClientIP(), c.Request.UserAgent()); err != nil {
+			log.Printf("Failed to audit impersonated request by %s: %v", actorID, err)
+		}
+	}
+}
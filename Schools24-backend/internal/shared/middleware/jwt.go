@@ -2,9 +2,9 @@ package middleware
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,29 +12,35 @@ import (
 
 // JWTConfig holds JWT middleware configuration
 type JWTConfig struct {
-	Secret        string
+	Keys          *KeySet
 	TokenLookup   string // "header:Authorization" or "query:token"
 	TokenHeadName string // "Bearer"
 	SkipPaths     []string
+	Denylist      *TokenDenylist  // Optional: rejects revoked tokens
+	APIKeys       APIKeyValidator // Optional: accepts X-API-Key instead of a bearer token
 }
 
 // DefaultJWTConfig returns default JWT config
-func DefaultJWTConfig(secret string) JWTConfig {
+func DefaultJWTConfig(keys *KeySet) JWTConfig {
 	return JWTConfig{
-		Secret:        secret,
+		Keys:          keys,
 		TokenLookup:   "header:Authorization",
 		TokenHeadName: "Bearer",
-		SkipPaths:     []string{"/health", "/ready", "/api/v1/auth/login", "/api/v1/auth/register"},
+		SkipPaths:     []string{"/health", "/ready", "/api/v1/auth/login", "/api/v1/auth/register", "/api/v1/auth/refresh", "/api/v1/auth/forgot-password", "/api/v1/auth/reset-password", "/api/v1/auth/verify-email", "/api/v1/auth/otp", "/api/v1/auth/invitations/accept", "/api/v1/auth/sso"},
 	}
 }
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID   string   `json:"user_id"`
-	Email    string   `json:"email"`
-	Role     string   `json:"role"`
-	SchoolID string   `json:"school_id"`
-	Roles    []string `json:"roles"`
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	Role      string   `json:"role"`
+	SchoolID  string   `json:"school_id"`
+	Roles     []string `json:"roles"`
+	MFA       bool     `json:"mfa,omitempty"`      // Second factor was verified at login
+	Purpose   string   `json:"purpose,omitempty"`  // Set on single-purpose tokens, which cannot access the API
+	SessionID string   `json:"sid,omitempty"`      // Login session the token belongs to
+	ActorID   string   `json:"actor_id,omitempty"` // Admin acting as UserID on an impersonation token
 	jwt.RegisteredClaims
 }
 
@@ -50,6 +56,12 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 			}
 		}
 
+		// Service accounts authenticate with an API key
+		if key := c.GetHeader(HeaderAPIKey); key != "" && cfg.APIKeys != nil {
+			authenticateAPIKey(c, cfg.APIKeys, key)
+			return
+		}
+
 		// Extract token
 		token, err := extractToken(c, cfg)
 		if err != nil {
@@ -61,7 +73,7 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 		}
 
 		// Parse and validate token
-		claims, err := parseToken(token, cfg.Secret)
+		claims, err := cfg.Keys.Parse(token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error":   "invalid_token",
@@ -70,6 +82,35 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 			return
 		}
 
+		// Single-purpose tokens (e.g. MFA challenges) are not access tokens
+		if claims.Purpose != "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_token",
+				"message": "Token cannot be used for API access",
+			})
+			return
+		}
+
+		// Reject tokens revoked by logout, session revocation or forced sign-out
+		if cfg.Denylist != nil {
+			revoked, err := cfg.Denylist.IsRevoked(c.Request.Context(), claims)
+			if err != nil {
+				log.Printf("Token denylist lookup failed: %v", err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error":   "auth_unavailable",
+					"message": "Unable to verify token, please retry",
+				})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error":   "token_revoked",
+					"message": "Token has been revoked",
+				})
+				return
+			}
+		}
+
 		// Set claims in context for handlers
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
@@ -118,45 +159,28 @@ func extractToken(c *gin.Context, cfg JWTConfig) (string, error) {
 	}
 }
 
-// parseToken parses and validates JWT token
-func parseToken(tokenString, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token claims")
+// GetUserID extracts user ID from Gin context
+func GetUserID(c *gin.Context) string {
+	if id, exists := c.Get("user_id"); exists {
+		return id.(string)
 	}
-
-	return claims, nil
+	return ""
 }
 
-// GenerateToken generates a new JWT token (utility for auth module)
-func GenerateToken(secret string, claims Claims, expiry time.Duration) (string, error) {
-	claims.RegisteredClaims = jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		NotBefore: jwt.NewNumericDate(time.Now()),
+// GetActorID returns the real admin behind an impersonation token, or "" for normal tokens
+func GetActorID(c *gin.Context) string {
+	if claims := GetClaims(c); claims != nil {
+		return claims.ActorID
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return ""
 }
 
-// GetUserID extracts user ID from Gin context
-func GetUserID(c *gin.Context) string {
-	if id, exists := c.Get("user_id"); exists {
-		return id.(string)
+// GetClaims extracts the parsed token claims from Gin context
+func GetClaims(c *gin.Context) *Claims {
+	if claims, exists := c.Get("claims"); exists {
+		return claims.(*Claims)
 	}
-	return ""
+	return nil
 }
 
 // GetRole extracts role from Gin context
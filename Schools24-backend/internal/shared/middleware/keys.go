@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// KeySetConfig configures token signing keys.
+//
+// With KeysDir empty, tokens are signed and verified with the shared HMAC
+// secret (HS256). Otherwise every "<kid>.pem" file in KeysDir is loaded:
+// RSA keys use RS256 and Ed25519 keys use EdDSA. Private keys can sign and
+// verify; public-key-only files verify tokens of retired keys.
+//
+// Rotation:
+//  1. Add the new private key file and deploy. It is published in the JWKS
+//     but not yet used, so verifiers can pick it up.
+//  2. Point SigningKeyID at the new kid and deploy.
+//  3. Once the longest token lifetime has passed, delete the old file.
+type KeySetConfig struct {
+	KeysDir      string
+	SigningKeyID string
+	HMACSecret   string
+	AcceptHMAC   bool // Also verify legacy HS256 tokens while migrating to asymmetric keys
+}
+
+// verificationKey is a public key (and, for the signer, its private half)
+type verificationKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	public  crypto.PublicKey
+	private crypto.Signer
+}
+
+// KeySet signs tokens with one active key and verifies against all known keys
+type KeySet struct {
+	signing    *verificationKey
+	keys       map[string]*verificationKey
+	hmacSecret []byte
+}
+
+// NewHMACKeySet returns a key set that signs and verifies with a shared secret
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		keys:       map[string]*verificationKey{},
+		hmacSecret: []byte(secret),
+	}
+}
+
+// LoadKeySet builds a key set from configuration
+func LoadKeySet(cfg KeySetConfig) (*KeySet, error) {
+	if cfg.KeysDir == "" {
+		return NewHMACKeySet(cfg.HMACSecret), nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	ks := &KeySet{keys: map[string]*verificationKey{}}
+	if cfg.AcceptHMAC && cfg.HMACSecret != "" {
+		ks.hmacSecret = []byte(cfg.HMACSecret)
+	}
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadKeyFile(file, kid)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+		}
+		ks.keys[kid] = key
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("no *.pem keys found in %s", cfg.KeysDir)
+	}
+
+	signing, ok := ks.keys[cfg.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found in %s", cfg.SigningKeyID, cfg.KeysDir)
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", cfg.SigningKeyID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// loadKeyFile parses a PEM encoded RSA or Ed25519 key
+func loadKeyFile(path, kid string) (*verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &verificationKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.public, key.private = jwt.SigningMethodRS256, &k.PublicKey, k
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.public, key.private = jwt.SigningMethodEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// Sign issues a token for the claims, stamping the active key ID
+func (ks *KeySet) Sign(claims Claims, expiry time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	if ks.signing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(ks.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.kid
+	return token.SignedString(ks.signing.private)
+}
+
+// Parse validates a token against the key named by its kid header
+func (ks *KeySet) Parse(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ks.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+
+	return claims, nil
+}
+
+// keyFunc picks the verification key and rejects algorithm mismatches
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(ks.hmacSecret) == 0 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return ks.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys of the set. HMAC secrets are never published.
+func (ks *KeySet) JWKS() []JWK {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// JWKSHandler serves the key set at /.well-known/jwks.json
+func JWKSHandler(ks *KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": ks.JWKS()})
+	}
+}
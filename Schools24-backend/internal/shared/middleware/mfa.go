@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurposeMFAChallenge marks the short-lived token issued between password and TOTP checks
+const PurposeMFAChallenge = "mfa_challenge"
+
+// RequireMFA blocks users holding any of the given roles, as their primary or
+// an additional role, unless their token was issued after a verified second
+// factor. Users of other roles pass through.
+func RequireMFA(roles ...string) gin.HandlerFunc {
+	required := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		if role = strings.TrimSpace(role); role != "" {
+			required[role] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || claims.MFA || !holdsAny(claims, required) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "mfa_required",
+			"message": "Two-factor authentication is required for this account. Enroll at /api/v1/auth/mfa and log in again.",
+		})
+	}
+}
+
+// holdsAny reports whether the token's primary or additional roles include one of roles
+func holdsAny(claims *Claims, roles map[string]bool) bool {
+	if roles[claims.Role] {
+		return true
+	}
+	for _, role := range claims.Roles {
+		if roles[role] {
+			return true
+		}
+	}
+	return false
+}
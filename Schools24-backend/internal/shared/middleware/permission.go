@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/schools24/backend/internal/shared/cache"
+)
+
+// PermissionResolver loads the effective permissions of a user from all their roles
+type PermissionResolver interface {
+	UserPermissions(ctx context.Context, userID string) ([]string, error)
+}
+
+// Authorizer checks permissions, caching each user's set for a short time.
+// Role assignment changes call Invalidate; edits to a role's permission set
+// reach its users once their cached entries expire.
+type Authorizer struct {
+	resolver PermissionResolver
+	store    cache.Store
+	ttl      time.Duration
+}
+
+// NewAuthorizer creates an authorizer backed by the given cache store
+func NewAuthorizer(resolver PermissionResolver, store cache.Store, ttl time.Duration) *Authorizer {
+	return &Authorizer{resolver: resolver, store: store, ttl: ttl}
+}
+
+// RequirePermission creates middleware that requires all of the given permissions.
+// Requests made with an API key are checked against the key's scopes instead.
+// Must run after JWTAuth.
+func (a *Authorizer) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := GetUserID(c)
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "User not authenticated",
+			})
+			return
+		}
+
+		// API key requests are limited to the key's scopes
+		granted, ok := apiKeyScopes(c)
+		if !ok {
+			var err error
+			granted, err = a.Permissions(c.Request.Context(), userID)
+			if err != nil {
+				log.Printf("Permission lookup failed for user %s: %v", userID, err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error":   "auth_unavailable",
+					"message": "Unable to check permissions, please retry",
+				})
+				return
+			}
+		}
+
+		for _, p := range permissions {
+			if !granted[p] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":   "forbidden",
+					"message": "Missing permission: " + p,
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// Permissions returns the user's permission set, from cache when fresh
+func (a *Authorizer) Permissions(ctx context.Context, userID string) (map[string]bool, error) {
+	// Entries carry their own expiry because the in-memory cache ignores per-key TTLs
+	if value, err := a.store.Get(ctx, permissionCacheKey(userID)); err == nil {
+		if expiry, list, ok := strings.Cut(value, "|"); ok {
+			if unix, err := strconv.ParseInt(expiry, 10, 64); err == nil && time.Now().Unix() < unix {
+				return permissionSet(list), nil
+			}
+		}
+	} else if !cache.IsNotFound(err) {
+		return nil, err
+	}
+
+	permissions, err := a.resolver.UserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := strings.Join(permissions, ",")
+	value := strconv.FormatInt(time.Now().Add(a.ttl).Unix(), 10) + "|" + list
+	if err := a.store.Set(ctx, permissionCacheKey(userID), value, a.ttl); err != nil {
+		log.Printf("Failed to cache permissions for user %s: %v", userID, err)
+	}
+	return permissionSet(list), nil
+}
+
+// Invalidate drops a user's cached permissions after their roles change
+func (a *Authorizer) Invalidate(ctx context.Context, userID string) error {
+	return a.store.Delete(ctx, permissionCacheKey(userID))
+}
+
+func permissionSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, p := range strings.Split(list, ",") {
+		if p != "" {
+			set[p] = true
+		}
+	}
+	return set
+}
+
+func permissionCacheKey(userID string) string {
+	return "permissions:user:" + userID
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/shared/tenant"
+)
+
+// RoleSuperAdmin can manage every school
+const RoleSuperAdmin = "super_admin"
+
+// TenantScope scopes the request context to the school in the JWT claims.
+// Super admins are unscoped unless they pick a school with the X-School-ID header.
+// Must run after JWTAuth.
+func TenantScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil {
+			c.Next()
+			return
+		}
+
+		schoolIDStr := claims.SchoolID
+		if claims.Role == RoleSuperAdmin {
+			schoolIDStr = c.GetHeader(tenant.HeaderSchoolID)
+			if schoolIDStr == "" {
+				c.Next()
+				return
+			}
+		}
+
+		schoolID, err := uuid.Parse(schoolIDStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "school_required",
+				"message": "Request is not associated with a school",
+			})
+			return
+		}
+
+		c.Set("school_id", schoolID.String())
+		c.Request = c.Request.WithContext(tenant.WithSchoolID(c.Request.Context(), schoolID))
+		c.Next()
+	}
+}
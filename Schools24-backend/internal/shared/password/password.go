@@ -0,0 +1,136 @@
+package password
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+	"github.com/schools24/backend/internal/shared/database"
+)
+
+// Manager hashes passwords and enforces the password policy, including
+// the reuse check against a user's recent passwords
+type Manager struct {
+	db           *database.PostgresDB
+	minLength    int
+	maxLength    int
+	rejectCommon bool
+	historyCount int
+	algorithm    string
+	argon2       argon2Params
+	bcryptCost   int
+}
+
+// PolicyError explains why a password was rejected; Code is a stable API error code
+type PolicyError struct {
+	Code    string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// ErrReused is returned when a new password matches one of the user's recent passwords
+var ErrReused = &PolicyError{Code: "password_reused", Message: "password was used recently, choose a different one"}
+
+// AsPolicyError returns the policy violation wrapped in err, if any
+func AsPolicyError(err error) (*PolicyError, bool) {
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr, true
+	}
+	return nil, false
+}
+
+// New creates a password manager from PASSWORD_* settings
+func New(db *database.PostgresDB, cfg config.PasswordConfig) *Manager {
+	algorithm := cfg.Algorithm
+	if algorithm != AlgorithmBcrypt {
+		algorithm = AlgorithmArgon2id
+	}
+	return &Manager{
+		db:           db,
+		minLength:    cfg.MinLength,
+		maxLength:    cfg.MaxLength,
+		rejectCommon: cfg.RejectCommon,
+		historyCount: cfg.HistoryCount,
+		algorithm:    algorithm,
+		argon2: argon2Params{
+			memory:      uint32(cfg.Argon2MemoryKB),
+			iterations:  uint32(cfg.Argon2Iterations),
+			parallelism: uint8(cfg.Argon2Parallelism),
+		},
+		bcryptCost: cfg.BcryptCost,
+	}
+}
+
+// CheckReuse rejects a password matching the user's current hash or one of
+// their last N passwords
+func (m *Manager) CheckReuse(ctx context.Context, userID uuid.UUID, password, currentHash string) error {
+	if m.historyCount <= 0 {
+		return nil
+	}
+
+	hashes := []string{}
+	if currentHash != "" {
+		hashes = append(hashes, currentHash)
+	}
+
+	rows, err := m.db.Query(ctx, `
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, m.historyCount)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return err
+		}
+		// The newest entry is usually the current hash
+		if hash != currentHash {
+			hashes = append(hashes, hash)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		match, err := m.Verify(password, hash)
+		if err != nil && !errors.Is(err, ErrUnknownHash) {
+			return err
+		}
+		if match {
+			return ErrReused
+		}
+	}
+	return nil
+}
+
+// Remember records a newly set password hash and drops entries beyond the history size
+func (m *Manager) Remember(ctx context.Context, userID uuid.UUID, hash string) error {
+	if m.historyCount <= 0 {
+		return nil
+	}
+
+	// The DELETE does not see the row inserted by the CTE, so it keeps one fewer
+	query := `
+		WITH added AS (
+			INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2)
+		)
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $3
+		)
+	`
+	return m.db.Exec(ctx, query, userID, hash, m.historyCount-1)
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// bcryptMaxBytes is the longest input bcrypt accepts
+const bcryptMaxBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords holds the embedded list, lowercased
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(list string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[line] = struct{}{}
+	}
+	return set
+}
+
+// Validate checks a new password against the length limits and the common-password list.
+// It does not check reuse; see CheckReuse.
+func (m *Manager) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < m.minLength {
+		return &PolicyError{
+			Code:    "password_too_short",
+			Message: fmt.Sprintf("password must be at least %d characters", m.minLength),
+		}
+	}
+	if (m.maxLength > 0 && length > m.maxLength) || (m.algorithm == AlgorithmBcrypt && len(password) > bcryptMaxBytes) {
+		return &PolicyError{
+			Code:    "password_too_long",
+			Message: "password is too long",
+		}
+	}
+
+	if m.rejectCommon {
+		if _, found := commonPasswords[strings.ToLower(password)]; found {
+			return &PolicyError{
+				Code:    "password_too_common",
+				Message: "password is too common, choose a less predictable one",
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Package permission defines the permission catalog and the built-in roles.
+package permission
+
+// Permission keys checked by middleware.RequirePermission
+const (
+	AdminDashboardRead = "admin.dashboard.read"
+
+	UsersRead        = "users.read"
+	UsersManage      = "users.manage"
+	UsersImpersonate = "users.impersonate"
+
+	StudentsManage = "students.manage"
+	TeachersManage = "teachers.manage"
+	ClassesManage  = "classes.manage"
+	SubjectsManage = "subjects.manage"
+
+	FeesStructuresRead   = "fees.structures.read"
+	FeesStructuresManage = "fees.structures.manage"
+	FeesPaymentsRead     = "fees.payments.read"
+	FeesPaymentsRecord   = "fees.payments.record"
+
+	DataExport             = "data.export"
+	AuditRead              = "audit.read"
+	SecurityLockoutsManage = "security.lockouts.manage"
+	RolesManage            = "roles.manage"
+	APIKeysManage          = "api_keys.manage"
+	SSOManage              = "sso.manage"
+	SettingsManage         = "settings.manage"
+	AcademicYearRollover   = "academic_year.rollover"
+
+	TeacherPortal       = "teacher.portal"
+	AttendanceMark      = "attendance.mark"
+	HomeworkCreate      = "homework.create"
+	GradesEnter         = "grades.enter"
+	AnnouncementsCreate = "announcements.create"
+
+	ParentPortal = "parent.portal"
+
+	// Platform permissions can only be held by roles shared across all schools
+	SchoolsManage = "schools.manage"
+)
+
+// Definition describes a permission
+type Definition struct {
+	Key         string
+	Description string
+	Platform    bool // Not grantable by school-scoped roles
+	SelfScoped  bool // Only reaches the holder's own linked records, so granting it needs no matching permission
+}
+
+// Catalog lists every permission known to the application
+var Catalog = []Definition{
+	{Key: AdminDashboardRead, Description: "View the admin dashboard"},
+	{Key: UsersRead, Description: "View user accounts"},
+	{Key: UsersManage, Description: "Create, update, deactivate and sign out users"},
+	{Key: UsersImpersonate, Description: "View the app as another user, read-only"},
+	{Key: StudentsManage, Description: "Create students"},
+	{Key: TeachersManage, Description: "Create teachers"},
+	{Key: ClassesManage, Description: "Create classes"},
+	{Key: SubjectsManage, Description: "Create subjects"},
+	{Key: FeesStructuresRead, Description: "View fee structures"},
+	{Key: FeesStructuresManage, Description: "Create fee structures"},
+	{Key: FeesPaymentsRead, Description: "View payments"},
+	{Key: FeesPaymentsRecord, Description: "Record fee payments"},
+	{Key: DataExport, Description: "Export users, students, fees and attendance to CSV or XLSX"},
+	{Key: AuditRead, Description: "View audit logs"},
+	{Key: SecurityLockoutsManage, Description: "View and clear login lockouts"},
+	{Key: RolesManage, Description: "Manage roles and role assignments"},
+	{Key: APIKeysManage, Description: "Manage service accounts and their API keys"},
+	{Key: SSOManage, Description: "Configure single sign-on providers"},
+	{Key: SettingsManage, Description: "Change school settings such as the academic calendar and grading scale"},
+	{Key: AcademicYearRollover, Description: "Move the school into the next academic year"},
+	{Key: TeacherPortal, Description: "Use the teacher dashboard and class lists"},
+	{Key: AttendanceMark, Description: "Mark attendance"},
+	{Key: HomeworkCreate, Description: "Create homework"},
+	{Key: GradesEnter, Description: "Enter grades"},
+	{Key: AnnouncementsCreate, Description: "Create announcements"},
+	{Key: ParentPortal, Description: "View linked children's records", SelfScoped: true},
+	{Key: SchoolsManage, Description: "Provision and manage schools", Platform: true},
+}
+
+// Lookup returns the definition of a permission key
+func Lookup(key string) (Definition, bool) {
+	for _, d := range Catalog {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// SystemRoles are the built-in roles matching users.role. Their permission sets
+// are seeded on first start and can then be changed by a super admin.
+// A nil set means every permission.
+var SystemRoles = map[string][]string{
+	"super_admin": nil,
+	"admin": {
+		AdminDashboardRead, UsersRead, UsersManage, UsersImpersonate, StudentsManage, TeachersManage,
+		ClassesManage, SubjectsManage, FeesStructuresRead, FeesStructuresManage,
+		FeesPaymentsRead, FeesPaymentsRecord, DataExport, AuditRead, SecurityLockoutsManage, RolesManage,
+		APIKeysManage, SSOManage, SettingsManage, AcademicYearRollover, TeacherPortal, AttendanceMark, HomeworkCreate, GradesEnter, AnnouncementsCreate,
+	},
+	"teacher": {TeacherPortal, AttendanceMark, HomeworkCreate, GradesEnter, AnnouncementsCreate},
+	"staff":   {},
+	"student": {},
+	"parent":  {ParentPortal},
+	// Service accounts act only through the scopes of their API keys
+	"service": {},
+}
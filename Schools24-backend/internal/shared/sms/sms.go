@@ -0,0 +1,129 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/schools24/backend/internal/config"
+)
+
+// Message is a text message to a phone number in E.164 format
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender delivers text messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the sender selected by SMS_PROVIDER (twilio, file or log)
+func New(cfg config.SMSConfig) Sender {
+	switch cfg.Provider {
+	case "twilio":
+		return NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFromPhone)
+	case "file":
+		return NewFileSender(cfg.FileDir)
+	default:
+		return NewLogSender()
+	}
+}
+
+// Delivers reports whether the configured provider sends real text messages.
+// The log and file senders keep login codes in plain text, so they are only
+// for local development.
+func Delivers(cfg config.SMSConfig) bool {
+	return cfg.Provider == "twilio"
+}
+
+// LogSender writes messages to the application log (local development)
+type LogSender struct{}
+
+// NewLogSender creates a new log sender
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send logs the message
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("[sms] to=%s\n%s", msg.To, msg.Body)
+	return nil
+}
+
+// FileSender writes each message to its own file in a directory
+type FileSender struct {
+	Dir string
+}
+
+// NewFileSender creates a new file sender
+func NewFileSender(dir string) *FileSender {
+	// Ensure message directory exists
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+	return &FileSender{Dir: dir}
+}
+
+// Send writes the message to <dir>/<timestamp>-<id>.txt
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	filename := fmt.Sprintf("%s-%s.txt", time.Now().Format("20060102T150405"), uuid.New().String())
+	content := fmt.Sprintf("To: %s\nDate: %s\n\n%s\n", msg.To, time.Now().Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(s.Dir, filename), []byte(content), 0644)
+}
+
+// TwilioSender delivers messages through the Twilio Messages API
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	fromPhone  string
+	client     *http.Client
+}
+
+// NewTwilioSender creates a new Twilio sender
+func NewTwilioSender(accountSID, authToken, fromPhone string) *TwilioSender {
+	return &TwilioSender{
+		accountSID: accountSID,
+		authToken:  authToken,
+		fromPhone:  fromPhone,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts the message to Twilio
+func (s *TwilioSender) Send(ctx context.Context, msg *Message) error {
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", s.fromPhone)
+	form.Set("Body", msg.Body)
+
+	endpoint := "https://api.twilio.com/2010-04-01/Accounts/" + s.accountSID + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var errBody bytes.Buffer
+		errBody.ReadFrom(resp.Body)
+		return fmt.Errorf("twilio returned %d: %s", resp.StatusCode, strings.TrimSpace(errBody.String()))
+	}
+	return nil
+}
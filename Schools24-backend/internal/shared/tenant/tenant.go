@@ -0,0 +1,39 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoSchool is returned when a write needs a school but the request has none
+// (a super admin that did not select one with the X-School-ID header)
+var ErrNoSchool = errors.New("no school selected for this request")
+
+// HeaderSchoolID lets super admins act on behalf of a school
+const HeaderSchoolID = "X-School-ID"
+
+type schoolIDKey struct{}
+
+// WithSchoolID returns a context scoped to the given school
+func WithSchoolID(ctx context.Context, schoolID uuid.UUID) context.Context {
+	return context.WithValue(ctx, schoolIDKey{}, schoolID)
+}
+
+// SchoolID returns the school the request is scoped to, or nil if it is unscoped.
+// Repositories pass it straight to queries as `($n::uuid IS NULL OR school_id = $n)`.
+func SchoolID(ctx context.Context) *uuid.UUID {
+	if id, ok := ctx.Value(schoolIDKey{}).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// RequireSchoolID returns the request's school for inserts of tenant-owned rows
+func RequireSchoolID(ctx context.Context) (uuid.UUID, error) {
+	if id := SchoolID(ctx); id != nil {
+		return *id, nil
+	}
+	return uuid.Nil, ErrNoSchool
+}